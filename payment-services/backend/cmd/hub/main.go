@@ -0,0 +1,255 @@
+// Command hub runs the payment billing hub API server.
+//
+// Every setting can be given as a flag or an environment variable; flags
+// take precedence.
+//
+//	-addr              ADDR                  listen address (default ":" + PORT, or ":8080")
+//	-database-url      DATABASE_URL          PostgreSQL connection string (required)
+//	-migrate           MIGRATE_ON_START      apply pending migrations at startup (default true)
+//	-read-timeout      HTTP_READ_TIMEOUT     time allowed to read a request (default 15s)
+//	-write-timeout     HTTP_WRITE_TIMEOUT    time allowed to write a response (default 2m)
+//	-idle-timeout      HTTP_IDLE_TIMEOUT     keep-alive idle timeout (default 60s)
+//	-shutdown-delay    SHUTDOWN_DELAY        time readiness fails before draining starts (default 0)
+//	-shutdown-timeout  SHUTDOWN_TIMEOUT      time allowed for in-flight requests to drain (default 25s)
+//	-cors-origins      CORS_ALLOWED_ORIGINS  comma-separated origins allowed to make CORS requests
+//	-cache-ttl         CACHE_TTL             how long synced data is served from the cache (default 15m)
+//	-session-ttl       SESSION_TTL           how long login sessions last (default 12h)
+//	-sync-interval     SYNC_INTERVAL         how often connections are synced in the background; 0 disables (default 10m)
+//	-allow-plaintext-credentials  ALLOW_PLAINTEXT_CREDENTIALS  start without a credential keyring (default false)
+//
+// Durations use Go syntax such as "30s" or "5m". The credential keyring and
+// secret backends are configured through the environment variables read by
+// the secrets package (CREDENTIAL_KEK, VAULT_ADDR and so on). Without a
+// keyring the server refuses to start, because stored credentials would be
+// kept in plaintext, unless plaintext credentials are explicitly allowed.
+//
+// On SIGTERM or SIGINT the server fails its readiness check, waits for the
+// shutdown delay, then stops accepting connections and waits for in-flight
+// requests to finish. Syncs in progress are then cancelled and recorded as
+// interrupted.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/api"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/auth"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/db"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/secrets"
+)
+
+// config holds the server settings
+type config struct {
+	addr        string
+	databaseURL string
+	migrate     bool
+
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+
+	corsOrigins  string
+	cacheTTL     time.Duration
+	sessionTTL   time.Duration
+	syncInterval time.Duration
+
+	allowPlaintextCredentials bool
+}
+
+func main() {
+	cfg, err := loadConfig(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hub:", err)
+		os.Exit(2)
+	}
+	if err := run(cfg); err != nil {
+		log.Fatalf("hub: %v", err)
+	}
+}
+
+// loadConfig reads the configuration from the environment, then flags
+func loadConfig(args []string) (config, error) {
+	var cfg config
+	var errs []error
+	env := envReader{errs: &errs}
+
+	defaultAddr := ":8080"
+	if port := os.Getenv("PORT"); port != "" {
+		defaultAddr = ":" + port
+	}
+
+	fs := flag.NewFlagSet("hub", flag.ContinueOnError)
+	fs.StringVar(&cfg.addr, "addr", env.string("ADDR", defaultAddr), "listen address")
+	fs.StringVar(&cfg.databaseURL, "database-url", env.string("DATABASE_URL", ""), "PostgreSQL connection string")
+	fs.BoolVar(&cfg.migrate, "migrate", env.bool("MIGRATE_ON_START", true), "apply pending migrations at startup")
+	fs.DurationVar(&cfg.readTimeout, "read-timeout", env.duration("HTTP_READ_TIMEOUT", 15*time.Second), "time allowed to read a request")
+	fs.DurationVar(&cfg.writeTimeout, "write-timeout", env.duration("HTTP_WRITE_TIMEOUT", 2*time.Minute), "time allowed to write a response")
+	fs.DurationVar(&cfg.idleTimeout, "idle-timeout", env.duration("HTTP_IDLE_TIMEOUT", 60*time.Second), "keep-alive idle timeout")
+	fs.DurationVar(&cfg.shutdownDelay, "shutdown-delay", env.duration("SHUTDOWN_DELAY", 0), "time readiness fails before draining starts")
+	fs.DurationVar(&cfg.shutdownTimeout, "shutdown-timeout", env.duration("SHUTDOWN_TIMEOUT", 25*time.Second), "time allowed for in-flight requests to drain")
+	fs.StringVar(&cfg.corsOrigins, "cors-origins", env.string("CORS_ALLOWED_ORIGINS", ""), "comma-separated origins allowed to make CORS requests")
+	fs.DurationVar(&cfg.cacheTTL, "cache-ttl", env.duration("CACHE_TTL", api.DefaultCacheTTL), "how long synced data is served from the cache")
+	fs.DurationVar(&cfg.sessionTTL, "session-ttl", env.duration("SESSION_TTL", auth.DefaultSessionTTL), "how long login sessions last")
+	fs.DurationVar(&cfg.syncInterval, "sync-interval", env.duration("SYNC_INTERVAL", 10*time.Minute), "how often connections are synced in the background; 0 disables")
+	fs.BoolVar(&cfg.allowPlaintextCredentials, "allow-plaintext-credentials", env.bool("ALLOW_PLAINTEXT_CREDENTIALS", false), "start without a credential keyring")
+
+	if err := errors.Join(errs...); err != nil {
+		return cfg, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if cfg.databaseURL == "" {
+		return cfg, fmt.Errorf("DATABASE_URL or -database-url must be set")
+	}
+	if cfg.sessionTTL <= 0 {
+		return cfg, fmt.Errorf("session TTL must be positive")
+	}
+	if cfg.syncInterval < 0 {
+		return cfg, fmt.Errorf("sync interval cannot be negative")
+	}
+	return cfg, nil
+}
+
+// envReader reads typed environment variables, collecting parse errors
+type envReader struct {
+	errs *[]error
+}
+
+func (e envReader) string(name, def string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return def
+}
+
+func (e envReader) bool(name string, def bool) bool {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		*e.errs = append(*e.errs, fmt.Errorf("invalid %s %q: must be true or false", name, value))
+		return def
+	}
+	return b
+}
+
+func (e envReader) duration(name string, def time.Duration) time.Duration {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		*e.errs = append(*e.errs, fmt.Errorf("invalid %s %q: %w", name, value, err))
+		return def
+	}
+	return d
+}
+
+// splitOrigins parses a comma-separated origin list, dropping empty entries
+func splitOrigins(list string) []string {
+	var origins []string
+	for _, origin := range strings.Split(list, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, strings.TrimRight(origin, "/"))
+		}
+	}
+	return origins
+}
+
+func run(cfg config) error {
+	keyring, err := secrets.LoadKeyringFromEnv()
+	if err != nil {
+		return err
+	}
+	if keyring == nil {
+		if !cfg.allowPlaintextCredentials {
+			return fmt.Errorf("%s or %s must be set to encrypt stored credentials; set ALLOW_PLAINTEXT_CREDENTIALS=true to store them in plaintext",
+				secrets.EnvKEK, secrets.EnvKEKFile)
+		}
+		log.Printf("%s is not set; credentials are stored in plaintext", secrets.EnvKEK)
+	}
+	resolver := secrets.NewResolverFromEnv()
+
+	database, err := db.New(cfg.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if cfg.migrate {
+		if err := database.Migrate(); err != nil {
+			return fmt.Errorf("migrations failed: %w", err)
+		}
+		log.Println("migrations applied")
+	}
+
+	server := api.NewServer(database)
+	server.SetCacheTTL(cfg.cacheTTL)
+	server.SetKeyring(keyring)
+	server.SetSecretResolver(resolver)
+	if origins := splitOrigins(cfg.corsOrigins); len(origins) > 0 {
+		server.SetAllowedOrigins(origins)
+	}
+	server.Auth().SetSessionTTL(cfg.sessionTTL)
+
+	httpServer := &http.Server{
+		Addr:              cfg.addr,
+		Handler:           server.Router(),
+		ReadHeaderTimeout: cfg.readTimeout,
+		ReadTimeout:       cfg.readTimeout,
+		WriteTimeout:      cfg.writeTimeout,
+		IdleTimeout:       cfg.idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.syncInterval > 0 {
+		server.StartSyncScheduler(ctx, cfg.syncInterval)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", cfg.addr)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Fail readiness first so load balancers stop routing here, then drain
+	log.Println("shutting down")
+	server.BeginShutdown()
+	time.Sleep(cfg.shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+	defer cancel()
+	httpErr := httpServer.Shutdown(shutdownCtx)
+	syncErr := server.StopSyncs(shutdownCtx)
+	if err := errors.Join(httpErr, syncErr); err != nil {
+		return fmt.Errorf("shutdown incomplete: %w", err)
+	}
+	log.Println("server stopped")
+	return nil
+}
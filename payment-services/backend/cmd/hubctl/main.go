@@ -0,0 +1,279 @@
+// Command hubctl runs administrative tasks against the payment billing hub database.
+//
+// Usage:
+//
+//	hubctl migrate up               apply all pending migrations
+//	hubctl migrate down [n]         revert the last n migrations (default 1)
+//	hubctl migrate status           list applied and pending migrations
+//	hubctl credentials genkey       print a new random key-encryption key
+//	hubctl credentials rotate [-f]  encrypt credentials under the current key
+//	hubctl credentials decrypt      restore all credentials to plaintext
+//	hubctl users add <username> [display name]
+//	hubctl users passwd <username>
+//	hubctl users disable <username>
+//	hubctl users enable <username>
+//	hubctl users list
+//	hubctl users admin <username> on|off
+//	hubctl users grant <username> <connection-id> viewer|operator|admin
+//	hubctl users revoke <username> <connection-id>
+//
+// users add and users passwd read the new password from the first line of
+// standard input.
+//
+// The database is taken from the DATABASE_URL environment variable and the
+// keyring from CREDENTIAL_KEK, CREDENTIAL_KEK_FILE, CREDENTIAL_KEK_ID and
+// CREDENTIAL_KEK_OLD.
+//
+// To rotate keys, add the new key to the keyring, make it current with
+// CREDENTIAL_KEK_ID, list the old key in CREDENTIAL_KEK_OLD (or keep it in
+// the key file) and run "hubctl credentials rotate". Once that succeeds the
+// old key can be removed. After "hubctl credentials decrypt", the server only
+// starts without a keyring if ALLOW_PLAINTEXT_CREDENTIALS=true.
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/auth"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/db"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/secrets"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "hubctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() error {
+	return fmt.Errorf("usage: hubctl migrate up | migrate down [n] | migrate status | " +
+		"credentials genkey | credentials rotate [-f] | credentials decrypt | " +
+		"users add <username> [display name] | users passwd|disable|enable <username> | users list | " +
+		"users admin <username> on|off | users grant <username> <connection-id> <role> | users revoke <username> <connection-id>")
+}
+
+func run(args []string) error {
+	if len(args) < 2 {
+		return usage()
+	}
+
+	// genkey needs no database
+	if args[0] == "credentials" && args[1] == "genkey" {
+		key := make([]byte, secrets.KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return nil
+	}
+
+	connString := os.Getenv("DATABASE_URL")
+	if connString == "" {
+		return fmt.Errorf("DATABASE_URL is not set")
+	}
+
+	database, err := db.New(connString)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	switch args[0] {
+	case "migrate":
+		return runMigrate(database, args[1:])
+	case "credentials":
+		return runCredentials(database, args[1:])
+	case "users":
+		return runUsers(database, args[1:])
+	}
+	return usage()
+}
+
+func runMigrate(database *db.DB, args []string) error {
+	switch args[0] {
+	case "up":
+		if err := database.Migrate(); err != nil {
+			return err
+		}
+		fmt.Println("migrations applied")
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		if err := database.MigrateDown(steps); err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", steps)
+		return nil
+
+	case "status":
+		statuses, err := database.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%03d  %-30s  %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	}
+
+	return usage()
+}
+
+func runCredentials(database *db.DB, args []string) error {
+	keyring, err := secrets.LoadKeyringFromEnv()
+	if err != nil {
+		return err
+	}
+	if keyring == nil {
+		return fmt.Errorf("%s or %s must be set", secrets.EnvKEK, secrets.EnvKEKFile)
+	}
+
+	switch args[0] {
+	case "rotate":
+		force := len(args) > 1 && (args[1] == "-f" || args[1] == "--force")
+		result, err := database.RewriteCredentials(keyring, true, force)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("encrypted %d credential(s) under key %q, %d already current\n",
+			result.Rewritten, keyring.CurrentKeyID(), result.Unchanged)
+		return nil
+
+	case "decrypt":
+		result, err := database.RewriteCredentials(keyring, false, false)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("decrypted %d credential(s), %d already plaintext\n", result.Rewritten, result.Unchanged)
+		return nil
+	}
+
+	return usage()
+}
+
+func runUsers(database *db.DB, args []string) error {
+	store := auth.NewStore(database)
+	ctx := context.Background()
+
+	if args[0] == "list" {
+		users, err := store.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			state := "active"
+			if !user.IsActive {
+				state = "disabled"
+			} else if user.IsAdmin {
+				state = "admin"
+			}
+			fmt.Printf("%-30s  %-8s  %s\n", user.Username, state, user.DisplayName)
+		}
+		return nil
+	}
+
+	if len(args) < 2 {
+		return usage()
+	}
+	username := args[1]
+
+	switch args[0] {
+	case "add":
+		password, err := readPassword()
+		if err != nil {
+			return err
+		}
+		user, err := store.CreateUser(ctx, username, strings.Join(args[2:], " "), password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user %s (id %d)\n", user.Username, user.ID)
+		return nil
+
+	case "passwd":
+		password, err := readPassword()
+		if err != nil {
+			return err
+		}
+		if err := store.SetPassword(ctx, username, password); err != nil {
+			return err
+		}
+		fmt.Printf("password changed for %s; existing sessions ended\n", username)
+		return nil
+
+	case "disable", "enable":
+		if err := store.SetActive(ctx, username, args[0] == "enable"); err != nil {
+			return err
+		}
+		fmt.Printf("%sd user %s\n", args[0], username)
+		return nil
+
+	case "admin":
+		if len(args) < 3 || (args[2] != "on" && args[2] != "off") {
+			return usage()
+		}
+		if err := store.SetAdmin(ctx, username, args[2] == "on"); err != nil {
+			return err
+		}
+		fmt.Printf("administrator rights %s for %s\n", args[2], username)
+		return nil
+
+	case "grant", "revoke":
+		if len(args) < 3 || (args[0] == "grant" && len(args) < 4) {
+			return usage()
+		}
+		userID, err := store.UserID(ctx, username)
+		if err != nil {
+			return err
+		}
+		connectionID, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid connection ID %q", args[2])
+		}
+		if args[0] == "revoke" {
+			if err := store.RevokeRole(ctx, userID, connectionID); err != nil {
+				return err
+			}
+			fmt.Printf("revoked %s's role on connection %d\n", username, connectionID)
+			return nil
+		}
+		role := models.Role(args[3])
+		if err := store.GrantRole(ctx, userID, connectionID, role); err != nil {
+			return err
+		}
+		fmt.Printf("granted %s the %s role on connection %d\n", username, role, connectionID)
+		return nil
+	}
+
+	return usage()
+}
+
+// readPassword reads a password from the first line of standard input
+func readPassword() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password from standard input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
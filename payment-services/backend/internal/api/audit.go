@@ -0,0 +1,397 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// maxAuditedRequest caps the body of an audited call, which is read into
+// memory before the handler runs
+const maxAuditedRequest = 1 << 20
+
+// maxAuditResponse is how much of a response is kept to find a created object's ID
+const maxAuditResponse = 64 << 10
+
+// redacted replaces secret values in audited request bodies
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are JSON keys whose values are never written to the audit log
+var sensitiveKeys = map[string]bool{
+	"password":       true,
+	"api_key":        true,
+	"apikey":         true,
+	"client_secret":  true,
+	"secret":         true,
+	"token":          true,
+	"credentials":    true,
+	"card_number":    true,
+	"number":         true, // card number inside payment method details
+	"cvc":            true,
+	"cvv":            true,
+	"account_number": true,
+	"routing_number": true,
+}
+
+// isSensitiveKey reports whether a JSON key names a secret
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	return sensitiveKeys[key] ||
+		strings.Contains(key, "password") ||
+		strings.Contains(key, "secret") ||
+		strings.HasSuffix(key, "_token") ||
+		strings.HasSuffix(key, "_key")
+}
+
+// redact returns a copy of a decoded JSON value with secret values replaced
+func redact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if isSensitiveKey(key) {
+				out[key] = redacted
+			} else {
+				out[key] = redact(value)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = redact(value)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// redactBody redacts a JSON request body; other bodies are recorded only by size
+func redactBody(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		out, _ := json.Marshal(map[string]interface{}{"unparsed_bytes": len(body)})
+		return out
+	}
+	out, err := json.Marshal(redact(decoded))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// auditWriter captures the status and start of the response for the audit log
+type auditWriter struct {
+	http.ResponseWriter
+	status         int
+	body           bytes.Buffer
+	upstreamStatus *int
+}
+
+func (w *auditWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if room := maxAuditResponse - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(len(b), room)])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// noteUpstreamStatus records the status a billing platform returned, for the
+// audit log. The error responders call it, so failures are recorded even
+// from clients that were not given an upstreamObserver.
+func noteUpstreamStatus(w http.ResponseWriter, status int) {
+	for {
+		if aw, ok := w.(*auditWriter); ok {
+			aw.upstreamStatus = &status
+			return
+		}
+		wrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = wrapper.Unwrap()
+	}
+}
+
+// auditWriterKey is the request context key holding the call's *auditWriter
+type auditWriterKey struct{}
+
+// upstreamObserver returns a function that records each status a billing
+// platform returns in the call's audit event, successes included, or nil
+// when the call is not audited. Clients given it report every response, so
+// the last one the handler caused is the one recorded.
+func upstreamObserver(r *http.Request) func(status int) {
+	aw, ok := r.Context().Value(auditWriterKey{}).(*auditWriter)
+	if !ok {
+		return nil
+	}
+	return func(status int) {
+		aw.upstreamStatus = &status
+	}
+}
+
+// observeUpstream returns a copy of provider that reports its responses to
+// the call's audit event, or provider itself when there is nothing to report to
+func observeUpstream(r *http.Request, provider platforms.BillingProvider) platforms.BillingProvider {
+	observe := upstreamObserver(r)
+	if o, ok := provider.(platforms.ResponseObservable); ok && observe != nil {
+		return o.WithResponseObserver(observe)
+	}
+	return provider
+}
+
+// pathWildcards returns the names of the {wildcards} in a route pattern, in order
+func pathWildcards(pattern string) []string {
+	var names []string
+	for _, segment := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.TrimSuffix(strings.Trim(segment, "{}"), "..."))
+		}
+	}
+	return names
+}
+
+// auditTarget picks the ID of the object a call acted on: the final path
+// wildcard, else the ID of the object the call created, else the connection
+func auditTarget(pattern string, r *http.Request, w *auditWriter) string {
+	wildcards := pathWildcards(pattern)
+	if len(wildcards) > 0 && strings.HasSuffix(pattern, "{"+wildcards[len(wildcards)-1]+"}") {
+		return r.PathValue(wildcards[len(wildcards)-1])
+	}
+
+	if w.status >= 200 && w.status < 300 {
+		var created struct {
+			ID interface{} `json:"id"`
+		}
+		if json.Unmarshal(w.body.Bytes(), &created) == nil && created.ID != nil {
+			switch id := created.ID.(type) {
+			case string:
+				return id
+			case float64:
+				return strconv.FormatFloat(id, 'f', -1, 64)
+			}
+		}
+	}
+
+	if len(wildcards) > 0 {
+		return r.PathValue(wildcards[len(wildcards)-1])
+	}
+	return ""
+}
+
+// audit records every mutating call to a route in audit_events, including
+// calls the role check rejects
+func (s *Server) audit(pattern string, access routeAccess, next http.Handler) http.Handler {
+	method, _, _ := strings.Cut(pattern, " ")
+	if method == "" || !isWriteMethod(method) || readOnlyPosts[pattern] {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// An oversized call is refused but still recorded, without its body
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAuditedRequest))
+		var maxBytesErr *http.MaxBytesError
+		tooLarge := errors.As(err, &maxBytesErr)
+		if err != nil && !tooLarge {
+			respondError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		event := models.AuditEvent{
+			Method:     r.Method,
+			Route:      pattern,
+			Path:       r.URL.Path,
+			RemoteAddr: r.RemoteAddr,
+		}
+		if !tooLarge {
+			event.RequestBody = redactBody(body)
+		}
+		if user, ok := userFromContext(r.Context()); ok {
+			event.ActorUserID = &user.ID
+			event.ActorUsername = user.Username
+		}
+
+		// Look the platform up first: the call may delete the connection
+		if access.idParam != "" {
+			if id, err := strconv.ParseInt(r.PathValue(access.idParam), 10, 64); err == nil {
+				event.ConnectionID = &id
+				s.db.Pool().QueryRow(context.Background(), `
+					SELECT platform_type FROM platform_connections WHERE id = $1
+				`, id).Scan(&event.PlatformType)
+			}
+		}
+
+		aw := &auditWriter{ResponseWriter: w}
+		if tooLarge {
+			respondError(aw, http.StatusRequestEntityTooLarge, "Request body too large")
+		} else {
+			next.ServeHTTP(aw, r.WithContext(context.WithValue(r.Context(), auditWriterKey{}, aw)))
+		}
+
+		event.StatusCode = aw.status
+		if event.StatusCode == 0 {
+			event.StatusCode = http.StatusOK
+		}
+		event.UpstreamStatus = aw.upstreamStatus
+		event.TargetID = auditTarget(pattern, r, aw)
+		if event.ConnectionID == nil && pattern == "POST /api/connections" && event.StatusCode < 300 {
+			if id, err := strconv.ParseInt(event.TargetID, 10, 64); err == nil {
+				event.ConnectionID = &id
+				var created models.PlatformConnection
+				if json.Unmarshal(aw.body.Bytes(), &created) == nil {
+					event.PlatformType = string(created.PlatformType)
+				}
+			}
+		}
+
+		if err := s.recordAuditEvent(event); err != nil {
+			log.Printf("audit: failed to record %s %s: %v", r.Method, r.URL.Path, err)
+		}
+	})
+}
+
+func (s *Server) recordAuditEvent(event models.AuditEvent) error {
+	_, err := s.db.Pool().Exec(context.Background(), `
+		INSERT INTO audit_events (
+			actor_user_id, actor_username, connection_id, platform_type, method, route, path,
+			target_id, request_body, status_code, upstream_status, remote_addr
+		) VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''), $9, $10, $11, NULLIF($12, ''))
+	`, event.ActorUserID, event.ActorUsername, event.ConnectionID, event.PlatformType, event.Method, event.Route,
+		event.Path, event.TargetID, event.RequestBody, event.StatusCode, event.UpstreamStatus, event.RemoteAddr)
+	return err
+}
+
+// Audit handlers
+
+// handleListAuditEvents lists audit events, newest first. Global administrators
+// see every event; other users see events for connections they administer.
+// Filters: connection_id, actor, platform, method, route, target_id, since,
+// until (RFC 3339), before_id and limit (default 100, at most 1000).
+func (s *Server) handleListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	user, _ := userFromContext(r.Context())
+	query := r.URL.Query()
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !user.IsAdmin {
+		addCondition("connection_id IN (SELECT connection_id FROM connection_roles WHERE user_id = $%d AND role = 'admin')", user.ID)
+	}
+	if v := query.Get("connection_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid connection_id")
+			return
+		}
+		addCondition("connection_id = $%d", id)
+	}
+	if v := query.Get("before_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid before_id")
+			return
+		}
+		addCondition("id < $%d", id)
+	}
+	for param, column := range map[string]string{
+		"actor":     "actor_username",
+		"platform":  "platform_type",
+		"method":    "method",
+		"route":     "route",
+		"target_id": "target_id",
+	} {
+		if v := query.Get(param); v != "" {
+			addCondition(column+" = $%d", v)
+		}
+	}
+	for param, operator := range map[string]string{"since": ">=", "until": "<"} {
+		if v := query.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid "+param+": expected RFC 3339 time")
+				return
+			}
+			addCondition("occurred_at "+operator+" $%d", t)
+		}
+	}
+
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(n, 1000)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Pool().Query(context.Background(), `
+		SELECT id, occurred_at, actor_user_id, COALESCE(actor_username, ''), connection_id,
+		       COALESCE(platform_type, ''), method, route, path, COALESCE(target_id, ''),
+		       request_body, status_code, upstream_status, COALESCE(remote_addr, '')
+		FROM audit_events
+		`+where+`
+		ORDER BY id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		err := rows.Scan(
+			&e.ID, &e.OccurredAt, &e.ActorUserID, &e.ActorUsername, &e.ConnectionID,
+			&e.PlatformType, &e.Method, &e.Route, &e.Path, &e.TargetID,
+			&e.RequestBody, &e.StatusCode, &e.UpstreamStatus, &e.RemoteAddr,
+		)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, events)
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsSensitiveKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"password", true},
+		{"new_password", true},
+		{"Password", true},
+		{"api_key", true},
+		{"APIKey", true},
+		{"publishable_key", true},
+		{"client_secret", true},
+		{"webhook_secret", true},
+		{"secret_ref", true},
+		{"token", true},
+		{"confirmation_token", true},
+		{"credentials", true},
+		{"number", true},
+		{"card_number", true},
+		{"cvc", true},
+		{"CVV", true},
+		{"account_number", true},
+		{"routing_number", true},
+
+		{"email", false},
+		{"name", false},
+		{"amount", false},
+		{"currency", false},
+		{"key", false},
+		{"tokens_used", false},
+		{"invoice", false},
+		{"last4", false},
+		{"exp_month", false},
+	}
+	for _, tt := range tests {
+		if got := isSensitiveKey(tt.key); got != tt.want {
+			t.Errorf("isSensitiveKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "empty", body: "", want: ""},
+		{name: "whitespace", body: " \n", want: ""},
+		{name: "nothing secret", body: `{"email":"jo@example.com","amount":500}`, want: `{"amount":500,"email":"jo@example.com"}`},
+		{
+			name: "connection credentials",
+			body: `{"name":"Acme","credentials":{"api_key":"sk_live_abc","webhook_secret":"whsec_1"}}`,
+			want: `{"credentials":"[REDACTED]","name":"Acme"}`,
+		},
+		{
+			name: "credentials nested in an array",
+			body: `{"connections":[{"name":"Acme","credentials":{"api_key":"sk_live_abc"}}]}`,
+			want: `{"connections":[{"credentials":"[REDACTED]","name":"Acme"}]}`,
+		},
+		{
+			name: "card number",
+			body: `{"type":"card","card":{"number":"4242424242424242","cvc":"123","exp_month":12,"last4":"4242"}}`,
+			want: `{"card":{"cvc":"[REDACTED]","exp_month":12,"last4":"4242","number":"[REDACTED]"},"type":"card"}`,
+		},
+		{
+			name: "secret keys at any depth",
+			body: `{"payment_method_data":{"billing_details":{"name":"Jo"},"us_bank_account":{"account_number":"000123","routing_number":"110000000"}}}`,
+			want: `{"payment_method_data":{"billing_details":{"name":"Jo"},"us_bank_account":{"account_number":"[REDACTED]","routing_number":"[REDACTED]"}}}`,
+		},
+		{name: "secret object replaced whole", body: `{"password":{"old":"a","new":"b"}}`, want: `{"password":"[REDACTED]"}`},
+		{name: "top-level array", body: `[{"token":"tok_1"},{"id":"x"}]`, want: `[{"token":"[REDACTED]"},{"id":"x"}]`},
+		{name: "not JSON", body: `api_key=sk_live_abc`, want: `{"unparsed_bytes":19}`},
+		{name: "truncated JSON", body: `{"api_key":"sk_live`, want: `{"unparsed_bytes":19}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(redactBody([]byte(tt.body)))
+			if got != tt.want {
+				t.Errorf("redactBody(%s) = %s, want %s", tt.body, got, tt.want)
+			}
+			if strings.Contains(got, "sk_live") || strings.Contains(got, "4242424242424242") {
+				t.Errorf("redactBody(%s) kept a secret: %s", tt.body, got)
+			}
+		})
+	}
+}
+
+func TestRedactLeavesInputAlone(t *testing.T) {
+	input := map[string]interface{}{
+		"credentials": map[string]interface{}{"api_key": "sk_live_abc"},
+		"lines":       []interface{}{map[string]interface{}{"token": "tok_1"}},
+	}
+	redact(input)
+	if input["credentials"].(map[string]interface{})["api_key"] != "sk_live_abc" {
+		t.Error("redact modified the credentials it was given")
+	}
+	if input["lines"].([]interface{})[0].(map[string]interface{})["token"] != "tok_1" {
+		t.Error("redact modified a nested array element")
+	}
+}
+
+func TestUpstreamObserver(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/stripe/1/refunds", nil)
+	if upstreamObserver(r) != nil {
+		t.Fatal("upstreamObserver of an unaudited request is not nil")
+	}
+
+	aw := &auditWriter{ResponseWriter: httptest.NewRecorder()}
+	r = r.WithContext(context.WithValue(r.Context(), auditWriterKey{}, aw))
+	observe := upstreamObserver(r)
+	observe(409)
+	observe(200)
+	if aw.upstreamStatus == nil || *aw.upstreamStatus != 200 {
+		t.Errorf("upstream status = %v, want the last response, 200", aw.upstreamStatus)
+	}
+}
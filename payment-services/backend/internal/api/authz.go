@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// adminRoutes need the admin role on their connection rather than operator.
+// Every DELETE route needs admin as well.
+var adminRoutes = map[string]bool{
+	"PUT /api/connections/{id}":                                true,
+	"GET /api/connections/{id}/roles":                          true,
+	"PUT /api/connections/{id}/roles/{userId}":                 true,
+	"POST /api/stripe/{connectionId}/prices/{priceId}/archive": true,
+
+	"POST /api/stripe/{connectionId}/credit-notes/{creditNoteId}/void": true,
+	"POST /api/stripe/{connectionId}/invoices/{invoiceId}/void":        true,
+}
+
+// readOnlyPosts are POST routes that change nothing, such as previews. They
+// need only the viewer role and skip the write guard, idempotency and audit log.
+var readOnlyPosts = map[string]bool{
+	"POST /api/stripe/{connectionId}/credit-notes/preview": true,
+}
+
+// globalAdminRoutes are not tied to one connection and need a global administrator
+var globalAdminRoutes = map[string]bool{
+	"POST /api/connections": true,
+}
+
+// routeAccess describes who may call a route
+type routeAccess struct {
+	role        models.Role // role needed on the connection; "" for routes without one
+	idParam     string      // path wildcard holding the connection ID
+	globalAdmin bool
+}
+
+// accessFor derives a route's access rule from its pattern: reads need
+// viewer, writes operator, deletes and adminRoutes admin
+func accessFor(pattern string) routeAccess {
+	if globalAdminRoutes[pattern] {
+		return routeAccess{globalAdmin: true}
+	}
+
+	method, path, _ := strings.Cut(pattern, " ")
+	var access routeAccess
+	switch {
+	case strings.Contains(path, "{connectionId}"):
+		access.idParam = "connectionId"
+	case strings.HasPrefix(path, "/api/connections/{id}"):
+		access.idParam = "id"
+	default:
+		return access
+	}
+
+	switch {
+	case method == http.MethodDelete || adminRoutes[pattern]:
+		access.role = models.RoleAdmin
+	case method == http.MethodGet || method == http.MethodHead || readOnlyPosts[pattern]:
+		access.role = models.RoleViewer
+	default:
+		access.role = models.RoleOperator
+	}
+	return access
+}
+
+// routeMux registers handlers behind the access check and audit log for their pattern
+type routeMux struct {
+	*http.ServeMux
+	s *Server
+}
+
+// HandleFunc registers handler for pattern, requiring the role accessFor
+// derives, replaying retries that carry an Idempotency-Key, enforcing the
+// connection's mode on writes and auditing the call if it mutates anything
+func (m routeMux) HandleFunc(pattern string, handler http.HandlerFunc) {
+	access := accessFor(pattern)
+	guarded := m.s.idempotent(pattern, access, m.s.guardWrites(pattern, access, handler))
+	m.ServeMux.Handle(pattern, m.s.audit(pattern, access, m.s.authorize(access, guarded)))
+}
+
+// authorize rejects requests whose user lacks the access a route needs.
+// Connections the user has no role on are reported as not found.
+func (s *Server) authorize(access routeAccess, next http.Handler) http.Handler {
+	if !access.globalAdmin && access.role == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := userFromContext(r.Context())
+		if !ok {
+			respondError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+
+		if access.globalAdmin {
+			if !user.IsAdmin {
+				respondError(w, http.StatusForbidden, "Administrator access required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		connectionID, err := strconv.ParseInt(r.PathValue(access.idParam), 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid connection ID")
+			return
+		}
+
+		role, err := s.auth.ConnectionRole(r.Context(), user, connectionID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if role == "" {
+			respondError(w, http.StatusNotFound, "Connection not found")
+			return
+		}
+		if !role.Includes(access.role) {
+			respondError(w, http.StatusForbidden, "This action requires the "+string(access.role)+" role on the connection")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Role handlers
+
+func (s *Server) handleListConnectionRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	roles, err := s.auth.ListConnectionRoles(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, roles)
+}
+
+func (s *Server) handleGrantConnectionRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+	userID, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Role models.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Role.Valid() {
+		respondError(w, http.StatusBadRequest, "role must be viewer, operator or admin")
+		return
+	}
+
+	if err := s.auth.GrantRole(r.Context(), userID, id, req.Role); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (s *Server) handleRevokeConnectionRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+	userID, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := s.auth.RevokeRole(r.Context(), userID, id); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
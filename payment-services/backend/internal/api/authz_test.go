@@ -0,0 +1,239 @@
+package api
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// registeredPatterns returns the pattern of every route registered on the
+// routeMux in server.go, so the table below has to change with the routes
+func registeredPatterns(t *testing.T) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "server.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patterns []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		// mux.ServeMux.HandleFunc registers outside the routeMux and is skipped
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "HandleFunc" {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); !ok || ident.Name != "mux" {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			t.Errorf("%s: route registered with a non-literal pattern", fset.Position(call.Pos()))
+			return true
+		}
+		pattern, _ := strconv.Unquote(lit.Value)
+		patterns = append(patterns, pattern)
+		return true
+	})
+	return patterns
+}
+
+func TestAccessForEveryRoute(t *testing.T) {
+	// public and globalAdmin stand for the routes with no connection role
+	const (
+		public      models.Role = "(public)"
+		globalAdmin models.Role = "(global admin)"
+		viewer                  = models.RoleViewer
+		operator                = models.RoleOperator
+		admin                   = models.RoleAdmin
+	)
+
+	// Written out in full so a change to any route's access has to be deliberate
+	want := map[string]models.Role{
+		"GET /health":                                                                                public,
+		"GET /health/live":                                                                           public,
+		"GET /health/ready":                                                                          public,
+		"POST /api/auth/login":                                                                       public,
+		"POST /api/auth/logout":                                                                      public,
+		"GET /api/auth/me":                                                                           public,
+		"GET /api/connections":                                                                       public,
+		"POST /api/connections":                                                                      globalAdmin,
+		"GET /api/connections/{id}":                                                                  viewer,
+		"PUT /api/connections/{id}":                                                                  admin,
+		"DELETE /api/connections/{id}":                                                               admin,
+		"POST /api/connections/{id}/test":                                                            operator,
+		"POST /api/connections/{id}/sync":                                                            operator,
+		"GET /api/connections/{id}/sync":                                                             viewer,
+		"POST /api/connections/{id}/confirmations":                                                   operator,
+		"GET /api/connections/{id}/roles":                                                            admin,
+		"PUT /api/connections/{id}/roles/{userId}":                                                   admin,
+		"DELETE /api/connections/{id}/roles/{userId}":                                                admin,
+		"GET /api/connections/{id}/customers":                                                        viewer,
+		"POST /api/connections/{id}/customers":                                                       operator,
+		"GET /api/connections/{id}/customers/{customerId}":                                           viewer,
+		"GET /api/connections/{id}/subscriptions":                                                    viewer,
+		"GET /api/connections/{id}/subscriptions/{subscriptionId}":                                   viewer,
+		"GET /api/connections/{id}/invoices":                                                         viewer,
+		"GET /api/connections/{id}/payments":                                                         viewer,
+		"GET /api/connections/{id}/products":                                                         viewer,
+		"GET /api/audit":                                                                             public,
+		"GET /api/tree":                                                                              public,
+		"GET /api/platforms":                                                                         public,
+		"GET /api/maxio/{connectionId}/customers":                                                    viewer,
+		"POST /api/maxio/{connectionId}/customers":                                                   operator,
+		"GET /api/maxio/{connectionId}/customers/{customerId}":                                       viewer,
+		"PUT /api/maxio/{connectionId}/customers/{customerId}":                                       operator,
+		"GET /api/maxio/{connectionId}/subscriptions":                                                viewer,
+		"POST /api/maxio/{connectionId}/subscriptions":                                               operator,
+		"GET /api/maxio/{connectionId}/subscriptions/{subscriptionId}":                               viewer,
+		"GET /api/maxio/{connectionId}/products":                                                     viewer,
+		"GET /api/maxio/{connectionId}/products/{productId}":                                         viewer,
+		"PUT /api/maxio/{connectionId}/products/{productId}":                                         operator,
+		"GET /api/maxio/{connectionId}/product-families":                                             viewer,
+		"POST /api/maxio/{connectionId}/product-families":                                            operator,
+		"GET /api/maxio/{connectionId}/product-families/{familyId}/products":                         viewer,
+		"POST /api/maxio/{connectionId}/product-families/{familyId}/products":                        operator,
+		"GET /api/maxio/{connectionId}/invoices":                                                     viewer,
+		"GET /api/maxio/{connectionId}/payments":                                                     viewer,
+		"GET /api/zuora/{connectionId}/accounts":                                                     viewer,
+		"GET /api/zuora/{connectionId}/accounts/{accountId}":                                         viewer,
+		"GET /api/zuora/{connectionId}/subscriptions":                                                viewer,
+		"GET /api/zuora/{connectionId}/subscriptions/{subscriptionId}":                               viewer,
+		"GET /api/zuora/{connectionId}/products":                                                     viewer,
+		"GET /api/zuora/{connectionId}/products/{productId}":                                         viewer,
+		"GET /api/zuora/{connectionId}/products/{productId}/rate-plans":                              viewer,
+		"GET /api/zuora/{connectionId}/invoices":                                                     viewer,
+		"GET /api/zuora/{connectionId}/payments":                                                     viewer,
+		"GET /api/stripe/{connectionId}/customers":                                                   viewer,
+		"POST /api/stripe/{connectionId}/customers":                                                  operator,
+		"GET /api/stripe/{connectionId}/customers/{customerId}":                                      viewer,
+		"PUT /api/stripe/{connectionId}/customers/{customerId}":                                      operator,
+		"GET /api/stripe/{connectionId}/customers/{customerId}/payment-methods":                      viewer,
+		"POST /api/stripe/{connectionId}/customers/{customerId}/payment-methods":                     operator,
+		"DELETE /api/stripe/{connectionId}/customers/{customerId}/payment-methods/{paymentMethodId}": admin,
+		"PUT /api/stripe/{connectionId}/customers/{customerId}/default-payment-method":               operator,
+		"GET /api/stripe/{connectionId}/customers/{customerId}/setup-intents":                        viewer,
+		"POST /api/stripe/{connectionId}/customers/{customerId}/setup-intents":                       operator,
+		"GET /api/stripe/{connectionId}/setup-intents/{setupIntentId}":                               viewer,
+		"POST /api/stripe/{connectionId}/setup-intents/{setupIntentId}/cancel":                       operator,
+		"GET /api/stripe/{connectionId}/subscriptions":                                               viewer,
+		"POST /api/stripe/{connectionId}/subscriptions":                                              operator,
+		"GET /api/stripe/{connectionId}/subscriptions/{subscriptionId}":                              viewer,
+		"PUT /api/stripe/{connectionId}/subscriptions/{subscriptionId}":                              operator,
+		"DELETE /api/stripe/{connectionId}/subscriptions/{subscriptionId}":                           admin,
+		"GET /api/stripe/{connectionId}/products":                                                    viewer,
+		"POST /api/stripe/{connectionId}/products":                                                   operator,
+		"GET /api/stripe/{connectionId}/products/{productId}":                                        viewer,
+		"GET /api/stripe/{connectionId}/prices":                                                      viewer,
+		"POST /api/stripe/{connectionId}/prices":                                                     operator,
+		"GET /api/stripe/{connectionId}/prices/{priceId}":                                            viewer,
+		"POST /api/stripe/{connectionId}/prices/{priceId}/archive":                                   admin,
+		"GET /api/stripe/{connectionId}/invoices":                                                    viewer,
+		"GET /api/stripe/{connectionId}/invoices/{invoiceId}":                                        viewer,
+		"POST /api/stripe/{connectionId}/invoices":                                                   operator,
+		"DELETE /api/stripe/{connectionId}/invoices/{invoiceId}":                                     admin,
+		"POST /api/stripe/{connectionId}/invoices/{invoiceId}/items":                                 operator,
+		"POST /api/stripe/{connectionId}/invoices/{invoiceId}/finalize":                              operator,
+		"POST /api/stripe/{connectionId}/invoices/{invoiceId}/pay":                                   operator,
+		"POST /api/stripe/{connectionId}/invoices/{invoiceId}/send":                                  operator,
+		"POST /api/stripe/{connectionId}/invoices/{invoiceId}/void":                                  admin,
+		"POST /api/stripe/{connectionId}/invoices/{invoiceId}/mark-uncollectible":                    operator,
+		"GET /api/stripe/{connectionId}/payments":                                                    viewer,
+		"GET /api/stripe/{connectionId}/coupons":                                                     viewer,
+		"POST /api/stripe/{connectionId}/coupons":                                                    operator,
+		"GET /api/stripe/{connectionId}/coupons/{couponId}":                                          viewer,
+		"PUT /api/stripe/{connectionId}/coupons/{couponId}":                                          operator,
+		"DELETE /api/stripe/{connectionId}/coupons/{couponId}":                                       admin,
+		"GET /api/stripe/{connectionId}/refunds":                                                     viewer,
+		"POST /api/stripe/{connectionId}/refunds":                                                    operator,
+		"GET /api/stripe/{connectionId}/refunds/{refundId}":                                          viewer,
+		"GET /api/stripe/{connectionId}/credit-notes":                                                viewer,
+		"POST /api/stripe/{connectionId}/credit-notes":                                               operator,
+		"POST /api/stripe/{connectionId}/credit-notes/preview":                                       viewer,
+		"GET /api/stripe/{connectionId}/credit-notes/{creditNoteId}":                                 viewer,
+		"POST /api/stripe/{connectionId}/credit-notes/{creditNoteId}/void":                           admin,
+		"GET /api/recurly/{connectionId}/accounts":                                                   viewer,
+		"POST /api/recurly/{connectionId}/accounts":                                                  operator,
+		"GET /api/recurly/{connectionId}/accounts/{accountId}":                                       viewer,
+		"POST /api/recurly/{connectionId}/accounts/{accountId}/invoices":                             operator,
+		"GET /api/recurly/{connectionId}/subscriptions":                                              viewer,
+		"POST /api/recurly/{connectionId}/subscriptions":                                             operator,
+		"GET /api/recurly/{connectionId}/subscriptions/{subscriptionId}":                             viewer,
+		"GET /api/recurly/{connectionId}/plans":                                                      viewer,
+		"POST /api/recurly/{connectionId}/plans":                                                     operator,
+		"GET /api/recurly/{connectionId}/plans/{planId}":                                             viewer,
+		"GET /api/recurly/{connectionId}/invoices":                                                   viewer,
+		"GET /api/recurly/{connectionId}/invoices/{invoiceId}":                                       viewer,
+		"POST /api/recurly/{connectionId}/invoices/{invoiceId}/transactions":                         operator,
+		"GET /api/recurly/{connectionId}/transactions":                                               viewer,
+		"GET /api/recurly/{connectionId}/transactions/{transactionId}":                               viewer,
+		"GET /api/preferences/{key}":                                                                 public,
+		"PUT /api/preferences/{key}":                                                                 public,
+		"DELETE /api/preferences/{key}":                                                              public,
+	}
+
+	patterns := registeredPatterns(t)
+	if len(patterns) == 0 {
+		t.Fatal("found no routes in server.go")
+	}
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		seen[pattern] = true
+		role, ok := want[pattern]
+		if !ok {
+			t.Errorf("%s is registered but has no expected access here", pattern)
+			continue
+		}
+
+		access := accessFor(pattern)
+		switch role {
+		case public:
+			if access != (routeAccess{}) {
+				t.Errorf("%s: access = %+v, want none", pattern, access)
+			}
+		case globalAdmin:
+			if access != (routeAccess{globalAdmin: true}) {
+				t.Errorf("%s: access = %+v, want global admin", pattern, access)
+			}
+		default:
+			idParam := "id"
+			if strings.Contains(pattern, "{connectionId}") {
+				idParam = "connectionId"
+			}
+			if access != (routeAccess{role: role, idParam: idParam}) {
+				t.Errorf("%s: access = %+v, want %s on {%s}", pattern, access, role, idParam)
+			}
+		}
+	}
+	for pattern := range want {
+		if !seen[pattern] {
+			t.Errorf("%s is expected but not registered", pattern)
+		}
+	}
+}
+
+func TestAccessListsNameRegisteredRoutes(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, pattern := range registeredPatterns(t) {
+		registered[pattern] = true
+	}
+	for name, routes := range map[string]map[string]bool{
+		"adminRoutes":       adminRoutes,
+		"readOnlyPosts":     readOnlyPosts,
+		"globalAdminRoutes": globalAdminRoutes,
+	} {
+		for pattern := range routes {
+			if !registered[pattern] {
+				t.Errorf("%s lists %s, which is not registered", name, pattern)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// confirmationHeader carries the token from POST /api/connections/{id}/confirmations
+const confirmationHeader = "X-Confirmation-Token"
+
+// proxyCollections are the /api/connections/{id}/... collections whose writes
+// go through to the billing platform
+var proxyCollections = map[string]bool{
+	"customers":     true,
+	"subscriptions": true,
+	"invoices":      true,
+	"payments":      true,
+	"products":      true,
+}
+
+// isProxyRoute reports whether a route passes a call through to the billing
+// platform, as opposed to managing the connection itself
+func isProxyRoute(path string) bool {
+	if strings.Contains(path, "{connectionId}") {
+		return true
+	}
+	rest, ok := strings.CutPrefix(path, "/api/connections/{id}/")
+	if !ok {
+		return false
+	}
+	collection, _, _ := strings.Cut(rest, "/")
+	return proxyCollections[collection]
+}
+
+// isWriteMethod reports whether method may change data
+func isWriteMethod(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// writeRule is what guardWrites requires before letting a request through
+type writeRule int
+
+const (
+	writeAllowed           writeRule = iota
+	writeRejected                    // read_only connection
+	writeNeedsConfirmation           // live or confirm_required connection
+)
+
+// writeRuleFor returns the rule for a request with method on a connection in
+// mode. Writes to a live connection always need confirmation, whatever its
+// mode says, so read_write only lifts the requirement for sandboxes.
+func writeRuleFor(mode models.ConnectionMode, isSandbox bool, method string) writeRule {
+	switch {
+	case !isWriteMethod(method):
+		return writeAllowed
+	case mode == models.ModeReadOnly:
+		return writeRejected
+	case mode == models.ModeConfirmRequired || !isSandbox:
+		return writeNeedsConfirmation
+	}
+	return writeAllowed
+}
+
+// guardWrites enforces the connection's mode on writes that go through to the
+// billing platform: read-only connections reject them, and live and
+// confirm_required connections need a confirmation token in X-Confirmation-Token
+func (s *Server) guardWrites(pattern string, access routeAccess, next http.Handler) http.Handler {
+	method, path, _ := strings.Cut(pattern, " ")
+	if !isWriteMethod(method) || !isProxyRoute(path) || readOnlyPosts[pattern] {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connectionID, err := strconv.ParseInt(r.PathValue(access.idParam), 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid connection ID")
+			return
+		}
+
+		var mode models.ConnectionMode
+		var isSandbox bool
+		err = s.db.Pool().QueryRow(context.Background(), `
+			SELECT mode, is_sandbox FROM platform_connections WHERE id = $1
+		`, connectionID).Scan(&mode, &isSandbox)
+		if err != nil {
+			respondError(w, http.StatusNotFound, "Connection not found")
+			return
+		}
+
+		switch writeRuleFor(mode, isSandbox, r.Method) {
+		case writeRejected:
+			respondError(w, http.StatusForbidden, "Connection is read-only")
+			return
+		case writeNeedsConfirmation:
+			user, _ := userFromContext(r.Context())
+			ok, err := s.auth.UseConfirmation(r.Context(), r.Header.Get(confirmationHeader), user, connectionID, r.Method, r.URL.Path)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if !ok {
+				respondError(w, http.StatusPreconditionRequired,
+					"This connection requires confirmation: request a token from POST /api/connections/"+
+						strconv.FormatInt(connectionID, 10)+"/confirmations and send it in "+confirmationHeader)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Confirmation handlers
+
+// handleCreateConfirmation issues a single-use token approving one write,
+// identified by its method and path, on a live or confirm_required connection
+func (s *Server) handleCreateConfirmation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	var req struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Method = strings.ToUpper(req.Method)
+	if !isWriteMethod(req.Method) {
+		respondError(w, http.StatusBadRequest, "method must be POST, PUT or DELETE")
+		return
+	}
+	if !strings.HasPrefix(req.Path, "/api/") {
+		respondError(w, http.StatusBadRequest, "path must be the /api/ path of the write to confirm")
+		return
+	}
+
+	user, _ := userFromContext(r.Context())
+	confirmation, err := s.auth.CreateConfirmation(r.Context(), user, id, req.Method, req.Path)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, confirmation)
+}
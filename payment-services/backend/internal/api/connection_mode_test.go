@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+func TestWriteRuleFor(t *testing.T) {
+	// An empty mode stands for the default a new connection gets
+	tests := []struct {
+		mode      models.ConnectionMode
+		isSandbox bool
+		method    string
+		want      writeRule
+	}{
+		{"", true, http.MethodGet, writeAllowed},
+		{"", true, http.MethodPost, writeAllowed},
+		{"", false, http.MethodGet, writeAllowed},
+		{"", false, http.MethodPost, writeNeedsConfirmation},
+		{"", false, http.MethodDelete, writeNeedsConfirmation},
+
+		{models.ModeReadOnly, true, http.MethodGet, writeAllowed},
+		{models.ModeReadOnly, true, http.MethodPost, writeRejected},
+		{models.ModeReadOnly, false, http.MethodHead, writeAllowed},
+		{models.ModeReadOnly, false, http.MethodPut, writeRejected},
+		{models.ModeReadOnly, false, http.MethodDelete, writeRejected},
+
+		{models.ModeReadWrite, true, http.MethodGet, writeAllowed},
+		{models.ModeReadWrite, true, http.MethodPost, writeAllowed},
+		{models.ModeReadWrite, false, http.MethodGet, writeAllowed},
+
+		// A live connection set back to read_write still needs confirmation
+		{models.ModeReadWrite, false, http.MethodPost, writeNeedsConfirmation},
+		{models.ModeReadWrite, false, http.MethodPut, writeNeedsConfirmation},
+		{models.ModeReadWrite, false, http.MethodDelete, writeNeedsConfirmation},
+
+		{models.ModeConfirmRequired, true, http.MethodOptions, writeAllowed},
+		{models.ModeConfirmRequired, true, http.MethodPost, writeNeedsConfirmation},
+		{models.ModeConfirmRequired, false, http.MethodGet, writeAllowed},
+		{models.ModeConfirmRequired, false, http.MethodPut, writeNeedsConfirmation},
+		{models.ModeConfirmRequired, false, http.MethodDelete, writeNeedsConfirmation},
+	}
+	for _, tt := range tests {
+		mode := tt.mode
+		if mode == "" {
+			mode = models.DefaultConnectionMode(tt.isSandbox)
+		}
+		if got := writeRuleFor(mode, tt.isSandbox, tt.method); got != tt.want {
+			t.Errorf("writeRuleFor(%s, sandbox=%v, %s) = %d, want %d", mode, tt.isSandbox, tt.method, got, tt.want)
+		}
+	}
+}
@@ -3,11 +3,19 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/db"
 	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
 	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/maxio"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/recurly"
 	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/stripe"
 	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/zuora"
 )
@@ -15,11 +23,15 @@ import (
 // Connection handlers
 
 func (s *Server) handleListConnections(w http.ResponseWriter, r *http.Request) {
+	user, _ := userFromContext(r.Context())
+
+	// Only list connections the user has a role on
 	rows, err := s.db.Pool().Query(context.Background(), `
-		SELECT id, platform_type, name, COALESCE(subdomain, ''), COALESCE(base_url, ''), is_sandbox, status, COALESCE(error_message, ''), last_sync_at, created_at, updated_at
+		SELECT id, platform_type, name, COALESCE(subdomain, ''), COALESCE(base_url, ''), is_sandbox, mode, status, COALESCE(error_message, ''), last_sync_at, created_at, updated_at
 		FROM platform_connections
+		WHERE $1 OR id IN (SELECT connection_id FROM connection_roles WHERE user_id = $2)
 		ORDER BY name
-	`)
+	`, user.IsAdmin, user.ID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -31,7 +43,7 @@ func (s *Server) handleListConnections(w http.ResponseWriter, r *http.Request) {
 		var conn models.PlatformConnection
 		err := rows.Scan(
 			&conn.ID, &conn.PlatformType, &conn.Name, &conn.Subdomain, &conn.BaseURL,
-			&conn.IsSandbox, &conn.Status, &conn.ErrorMessage, &conn.LastSyncAt,
+			&conn.IsSandbox, &conn.Mode, &conn.Status, &conn.ErrorMessage, &conn.LastSyncAt,
 			&conn.CreatedAt, &conn.UpdatedAt,
 		)
 		if err != nil {
@@ -56,23 +68,53 @@ func (s *Server) handleCreateConnection(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validate credentials based on platform type
-	switch req.PlatformType {
-	case models.PlatformMaxio:
-		if req.APIKey == "" {
-			respondError(w, http.StatusBadRequest, "API key is required for Maxio")
-			return
-		}
-	case models.PlatformZuora:
-		if req.ClientID == "" || req.ClientSecret == "" {
-			respondError(w, http.StatusBadRequest, "Client ID and Client Secret are required for Zuora")
+	def, ok := platforms.Lookup(req.PlatformType)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Unsupported platform type: "+string(req.PlatformType))
+		return
+	}
+
+	// Validate credentials against the platform's schema; a secret reference
+	// stands in for a value
+	credentials := req.CredentialValues()
+	supplied := make(map[string]string, len(credentials)+len(req.CredentialRefs))
+	for k, v := range credentials {
+		supplied[k] = v
+	}
+	for credentialType, ref := range req.CredentialRefs {
+		if err := s.validateCredentialRef(def, credentialType, ref); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
 			return
 		}
-	case models.PlatformStripe:
-		if req.APIKey == "" {
-			respondError(w, http.StatusBadRequest, "API key is required for Stripe")
+		if credentials[credentialType] != "" {
+			respondError(w, http.StatusBadRequest, credentialType+" cannot have both a value and a secret reference")
 			return
 		}
+		supplied[credentialType] = ref
+	}
+	if field, missing := def.MissingCredential(supplied); missing {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("%s is required for %s", field.Label, def.DisplayName))
+		return
+	}
+
+	// Reject credentials or data centers that contradict is_sandbox
+	cfg := platforms.ConnectionConfig{
+		Subdomain:   req.Subdomain,
+		BaseURL:     req.BaseURL,
+		IsSandbox:   req.IsSandbox,
+		Credentials: credentials,
+	}
+	if err := def.CheckSandbox(cfg); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = models.DefaultConnectionMode(req.IsSandbox)
+	}
+	if !req.Mode.Valid() {
+		respondError(w, http.StatusBadRequest, "mode must be read_only, read_write or confirm_required")
+		return
 	}
 
 	ctx := context.Background()
@@ -86,39 +128,32 @@ func (s *Server) handleCreateConnection(w http.ResponseWriter, r *http.Request)
 	// Insert connection
 	var connID int64
 	err = tx.QueryRow(ctx, `
-		INSERT INTO platform_connections (platform_type, name, subdomain, base_url, is_sandbox, status)
-		VALUES ($1, $2, $3, $4, $5, 'pending')
+		INSERT INTO platform_connections (platform_type, name, subdomain, base_url, is_sandbox, mode, status)
+		VALUES ($1, $2, $3, $4, $5, $6, 'pending')
 		RETURNING id
-	`, req.PlatformType, req.Name, req.Subdomain, req.BaseURL, req.IsSandbox).Scan(&connID)
+	`, req.PlatformType, req.Name, req.Subdomain, req.BaseURL, req.IsSandbox, req.Mode).Scan(&connID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Insert credentials based on platform type
-	switch req.PlatformType {
-	case models.PlatformMaxio, models.PlatformStripe:
-		_, err = tx.Exec(ctx, `
-			INSERT INTO platform_credentials (connection_id, credential_type, credential_value)
-			VALUES ($1, 'api_key', $2)
-		`, connID, req.APIKey)
-	case models.PlatformZuora:
-		_, err = tx.Exec(ctx, `
-			INSERT INTO platform_credentials (connection_id, credential_type, credential_value)
-			VALUES ($1, 'client_id', $2)
-		`, connID, req.ClientID)
-		if err != nil {
+	// Insert the credentials declared by the platform
+	for _, field := range def.Credentials {
+		if ref := req.CredentialRefs[field.Type]; ref != "" {
+			if err := db.SaveCredentialRef(ctx, tx, connID, field.Type, ref); err != nil {
+				respondError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			continue
+		}
+		value := credentials[field.Type]
+		if value == "" {
+			continue
+		}
+		if err := db.SaveCredential(ctx, tx, s.keyring, connID, field.Type, value); err != nil {
 			respondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
-		_, err = tx.Exec(ctx, `
-			INSERT INTO platform_credentials (connection_id, credential_type, credential_value)
-			VALUES ($1, 'client_secret', $2)
-		`, connID, req.ClientSecret)
-	}
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
-		return
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -129,11 +164,11 @@ func (s *Server) handleCreateConnection(w http.ResponseWriter, r *http.Request)
 	// Return the created connection
 	var conn models.PlatformConnection
 	err = s.db.Pool().QueryRow(ctx, `
-		SELECT id, platform_type, name, COALESCE(subdomain, ''), COALESCE(base_url, ''), is_sandbox, status, COALESCE(error_message, ''), last_sync_at, created_at, updated_at
+		SELECT id, platform_type, name, COALESCE(subdomain, ''), COALESCE(base_url, ''), is_sandbox, mode, status, COALESCE(error_message, ''), last_sync_at, created_at, updated_at
 		FROM platform_connections WHERE id = $1
 	`, connID).Scan(
 		&conn.ID, &conn.PlatformType, &conn.Name, &conn.Subdomain, &conn.BaseURL,
-		&conn.IsSandbox, &conn.Status, &conn.ErrorMessage, &conn.LastSyncAt,
+		&conn.IsSandbox, &conn.Mode, &conn.Status, &conn.ErrorMessage, &conn.LastSyncAt,
 		&conn.CreatedAt, &conn.UpdatedAt,
 	)
 	if err != nil {
@@ -154,11 +189,11 @@ func (s *Server) handleGetConnection(w http.ResponseWriter, r *http.Request) {
 
 	var conn models.PlatformConnection
 	err = s.db.Pool().QueryRow(context.Background(), `
-		SELECT id, platform_type, name, COALESCE(subdomain, ''), COALESCE(base_url, ''), is_sandbox, status, COALESCE(error_message, ''), last_sync_at, created_at, updated_at
+		SELECT id, platform_type, name, COALESCE(subdomain, ''), COALESCE(base_url, ''), is_sandbox, mode, status, COALESCE(error_message, ''), last_sync_at, created_at, updated_at
 		FROM platform_connections WHERE id = $1
 	`, id).Scan(
 		&conn.ID, &conn.PlatformType, &conn.Name, &conn.Subdomain, &conn.BaseURL,
-		&conn.IsSandbox, &conn.Status, &conn.ErrorMessage, &conn.LastSyncAt,
+		&conn.IsSandbox, &conn.Mode, &conn.Status, &conn.ErrorMessage, &conn.LastSyncAt,
 		&conn.CreatedAt, &conn.UpdatedAt,
 	)
 	if err != nil {
@@ -177,47 +212,154 @@ func (s *Server) handleUpdateConnection(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var req struct {
-		Name      string `json:"name"`
-		Subdomain string `json:"subdomain"`
-		BaseURL   string `json:"base_url"`
-		IsSandbox bool   `json:"is_sandbox"`
-		APIKey    string `json:"api_key,omitempty"`
-	}
+	var req models.UpdateConnectionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
+	if req.Mode != "" && !req.Mode.Valid() {
+		respondError(w, http.StatusBadRequest, "mode must be read_only, read_write or confirm_required")
+		return
+	}
+
 	ctx := context.Background()
 
-	// Update connection
-	_, err = s.db.Pool().Exec(ctx, `
+	var platformType, subdomain, baseURL string
+	err = s.db.Pool().QueryRow(ctx, `
+		SELECT platform_type, COALESCE(subdomain, ''), COALESCE(base_url, '')
+		FROM platform_connections WHERE id = $1
+	`, id).Scan(&platformType, &subdomain, &baseURL)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Connection not found")
+		return
+	}
+	def, ok := platforms.Lookup(models.PlatformType(platformType))
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Unsupported platform type: "+platformType)
+		return
+	}
+
+	// New credentials must be ones the platform uses, given once each
+	credentials := req.CredentialValues()
+	for credentialType := range credentials {
+		if !declaresCredential(def, credentialType) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("%s does not use a %s credential", def.DisplayName, credentialType))
+			return
+		}
+	}
+	for credentialType, ref := range req.CredentialRefs {
+		if err := s.validateCredentialRef(def, credentialType, ref); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if credentials[credentialType] != "" {
+			respondError(w, http.StatusBadRequest, credentialType+" cannot have both a value and a secret reference")
+			return
+		}
+	}
+
+	// Build the configuration the connection would have after the update:
+	// stored credentials overlaid with the new values and references
+	cfg := platforms.ConnectionConfig{
+		ID:        id,
+		Subdomain: req.Subdomain,
+		BaseURL:   req.BaseURL,
+		IsSandbox: req.IsSandbox,
+	}
+	cfg.Credentials, err = s.db.LoadCredentials(ctx, s.keyring, s.resolver, id)
+	storedReadable := err == nil
+	if !storedReadable {
+		cfg.Credentials = map[string]string{}
+	}
+	for credentialType, value := range credentials {
+		cfg.Credentials[credentialType] = value
+	}
+	for credentialType, ref := range req.CredentialRefs {
+		value, err := s.resolver.Resolve(ctx, ref)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", credentialType, err))
+			return
+		}
+		cfg.Credentials[credentialType] = value
+	}
+
+	// Reject a change that would leave is_sandbox contradicting the
+	// credentials or data center
+	if err := def.CheckSandbox(cfg); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Changing how the hub reaches the platform must leave a working
+	// connection, so test the new settings before saving anything
+	credentialsChanged := len(credentials) > 0 || len(req.CredentialRefs) > 0
+	if credentialsChanged || req.Subdomain != subdomain || req.BaseURL != baseURL {
+		if !storedReadable && len(cfg.Credentials) < len(def.Credentials) {
+			respondError(w, http.StatusInternalServerError, "Cannot read the stored credentials to test the new settings")
+			return
+		}
+		candidate, err := def.NewProvider(cfg)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := candidate.TestConnection(); err != nil {
+			respondError(w, http.StatusBadRequest, "New settings failed the connection test: "+err.Error())
+			return
+		}
+	}
+
+	// Update the connection and its credentials together, bumping
+	// config_version so cached clients are rebuilt with the new settings
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
 		UPDATE platform_connections
-		SET name = $1, subdomain = $2, base_url = $3, is_sandbox = $4, updated_at = NOW()
+		SET name = $1, subdomain = $2, base_url = $3, is_sandbox = $4,
+		    mode = COALESCE(NULLIF($6, ''), CASE
+		        WHEN is_sandbox AND NOT $4 AND mode = 'read_write' THEN 'confirm_required' ELSE mode END),
+		    config_version = config_version + 1, updated_at = NOW()
 		WHERE id = $5
-	`, req.Name, req.Subdomain, req.BaseURL, req.IsSandbox, id)
+	`, req.Name, req.Subdomain, req.BaseURL, req.IsSandbox, id, req.Mode)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Update API key if provided
-	if req.APIKey != "" {
-		_, err = s.db.Pool().Exec(ctx, `
-			INSERT INTO platform_credentials (connection_id, credential_type, credential_value)
-			VALUES ($1, 'api_key', $2)
-			ON CONFLICT (connection_id, credential_type) DO UPDATE SET credential_value = $2
-		`, id, req.APIKey)
-		if err != nil {
+	// Save new credential values
+	for _, field := range def.Credentials {
+		value := credentials[field.Type]
+		if value == "" {
+			continue
+		}
+		if err := db.SaveCredential(ctx, tx, s.keyring, id, field.Type, value); err != nil {
 			respondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
-		// Clear cached clients
-		delete(s.maxioClients, id)
-		delete(s.stripeClients, id)
 	}
 
+	// Replace credentials with secret references if provided
+	for credentialType, ref := range req.CredentialRefs {
+		if err := db.SaveCredentialRef(ctx, tx, id, credentialType, ref); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Drop the cached provider now rather than on its next version check
+	s.providers.Invalidate(id)
+
 	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
@@ -235,9 +377,7 @@ func (s *Server) handleDeleteConnection(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	delete(s.maxioClients, id)
-	delete(s.stripeClients, id)
-	delete(s.zuoraClients, id)
+	s.providers.Invalidate(id)
 	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -249,43 +389,19 @@ func (s *Server) handleTestConnection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get platform type
-	var platformType string
-	err = s.db.Pool().QueryRow(context.Background(), `
-		SELECT platform_type FROM platform_connections WHERE id = $1
-	`, id).Scan(&platformType)
+	provider, err := s.getProvider(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
+	provider = observeUpstream(r, provider)
 
-	// Test connection based on platform type
-	var testErr error
-	switch platformType {
-	case "maxio":
-		client, err := s.getMaxioClient(id)
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		testErr = client.TestConnection()
-	case "stripe":
-		client, err := s.getStripeClient(id)
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		testErr = client.TestConnection()
-	case "zuora":
-		client, err := s.getZuoraClient(id)
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		testErr = client.TestConnection()
-	default:
-		respondError(w, http.StatusBadRequest, "Unsupported platform type: "+platformType)
-		return
+	testErr := provider.TestConnection()
+
+	// Check the environment the credentials reach against is_sandbox
+	var environment *platforms.EnvironmentReport
+	if detector, ok := provider.(platforms.EnvironmentDetector); ok && testErr == nil {
+		environment, testErr = detector.DetectEnvironment()
 	}
 
 	if testErr != nil {
@@ -298,70 +414,98 @@ func (s *Server) handleTestConnection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if environment != nil {
+		var isSandbox bool
+		err := s.db.Pool().QueryRow(context.Background(), `
+			SELECT is_sandbox FROM platform_connections WHERE id = $1
+		`, id).Scan(&isSandbox)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if environment.IsSandbox != isSandbox {
+			displayName := string(provider.Platform())
+			if def, ok := platforms.Lookup(provider.Platform()); ok {
+				displayName = def.DisplayName
+			}
+			message := platforms.SandboxMismatch(displayName, environment.IsSandbox)
+			s.db.Pool().Exec(context.Background(), `
+				UPDATE platform_connections SET status = 'error', error_message = $1, updated_at = NOW()
+				WHERE id = $2
+			`, message, id)
+			respondJSON(w, http.StatusConflict, map[string]interface{}{
+				"error":       message,
+				"environment": environment,
+			})
+			return
+		}
+	}
+
 	// Update status to connected
 	s.db.Pool().Exec(context.Background(), `
 		UPDATE platform_connections SET status = 'connected', error_message = NULL, updated_at = NOW()
 		WHERE id = $1
 	`, id)
 
-	respondJSON(w, http.StatusOK, map[string]string{"status": "connected"})
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "connected",
+		"environment": environment,
+	})
 }
 
 // Tree handler
 func (s *Server) handleGetTree(w http.ResponseWriter, r *http.Request) {
+	user, _ := userFromContext(r.Context())
+
+	// Only include connections the user has a role on
 	rows, err := s.db.Pool().Query(context.Background(), `
-		SELECT id, platform_type, name, COALESCE(subdomain, ''), COALESCE(base_url, ''), is_sandbox, status
-		FROM platform_connections
-		ORDER BY name
-	`)
+		SELECT c.id, c.platform_type, c.name, COALESCE(c.subdomain, ''), COALESCE(c.base_url, ''), c.is_sandbox, c.status,
+		       CASE WHEN $1 THEN 'admin' ELSE COALESCE(cr.role, '') END
+		FROM platform_connections c
+		LEFT JOIN connection_roles cr ON cr.connection_id = c.id AND cr.user_id = $2
+		WHERE $1 OR cr.role IS NOT NULL
+		ORDER BY c.name
+	`, user.IsAdmin, user.ID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	defer rows.Close()
 
-	// Create the three vendor root nodes
-	vendorNodes := map[string]*models.TreeNode{
-		"maxio": {
-			ID:           "vendor-maxio",
-			Type:         "vendor-maxio",
-			Name:         "Maxio (Chargify)",
-			PlatformType: "maxio",
-			IsExpandable: true,
-			Children:     []*models.TreeNode{},
-		},
-		"stripe": {
-			ID:           "vendor-stripe",
-			Type:         "vendor-stripe",
-			Name:         "Stripe",
-			PlatformType: "stripe",
+	// Create a vendor root node for every registered platform
+	defs := platforms.All()
+	vendorNodes := make(map[models.PlatformType]*models.TreeNode, len(defs))
+	for _, def := range defs {
+		vendorNodes[def.Type] = &models.TreeNode{
+			ID:           "vendor-" + string(def.Type),
+			Type:         "vendor-" + string(def.Type),
+			Name:         def.DisplayName,
+			PlatformType: string(def.Type),
 			IsExpandable: true,
 			Children:     []*models.TreeNode{},
-		},
-		"zuora": {
-			ID:           "vendor-zuora",
-			Type:         "vendor-zuora",
-			Name:         "Zuora",
-			PlatformType: "zuora",
-			IsExpandable: true,
-			Children:     []*models.TreeNode{},
-		},
+		}
 	}
 
 	for rows.Next() {
 		var id int64
-		var platformType, name, subdomain, baseURL, status string
+		var platformType, name, subdomain, baseURL, status, role string
 		var isSandbox bool
-		if err := rows.Scan(&id, &platformType, &name, &subdomain, &baseURL, &isSandbox, &status); err != nil {
+		if err := rows.Scan(&id, &platformType, &name, &subdomain, &baseURL, &isSandbox, &status, &role); err != nil {
 			respondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
+		def, ok := platforms.Lookup(models.PlatformType(platformType))
+		if !ok {
+			continue
+		}
+
 		// Build connection data for the frontend
 		connectionData := map[string]interface{}{
 			"id":         id,
 			"name":       name,
 			"is_sandbox": isSandbox,
+			"role":       role,
 		}
 		if subdomain != "" {
 			connectionData["subdomain"] = subdomain
@@ -370,56 +514,13 @@ func (s *Server) handleGetTree(w http.ResponseWriter, r *http.Request) {
 			connectionData["base_url"] = baseURL
 		}
 
-		// Create connection node with entity containers
-		children := []*models.TreeNode{
-			{
-				ID:           "customers-" + strconv.FormatInt(id, 10),
-				Type:         "customers",
-				Name:         "Customers",
-				ConnectionID: &id,
-				PlatformType: platformType,
-				IsExpandable: true,
-			},
-			{
-				ID:           "subscriptions-" + strconv.FormatInt(id, 10),
-				Type:         "subscriptions",
-				Name:         "Subscriptions",
-				ConnectionID: &id,
-				PlatformType: platformType,
-				IsExpandable: true,
-			},
-			{
-				ID:           "product-families-" + strconv.FormatInt(id, 10),
-				Type:         "product-families",
-				Name:         "Product Families",
-				ConnectionID: &id,
-				PlatformType: platformType,
-				IsExpandable: true,
-			},
-			{
-				ID:           "invoices-" + strconv.FormatInt(id, 10),
-				Type:         "invoices",
-				Name:         "Invoices",
-				ConnectionID: &id,
-				PlatformType: platformType,
-				IsExpandable: true,
-			},
-			{
-				ID:           "payments-" + strconv.FormatInt(id, 10),
-				Type:         "payments",
-				Name:         "Payments",
-				ConnectionID: &id,
-				PlatformType: platformType,
-				IsExpandable: true,
-			},
-		}
-
-		// Add Stripe-specific containers
-		if platformType == "stripe" {
+		// Create connection node with the platform's entity containers
+		children := make([]*models.TreeNode, 0, len(def.TreeContainers))
+		for _, container := range def.TreeContainers {
 			children = append(children, &models.TreeNode{
-				ID:           "coupons-" + strconv.FormatInt(id, 10),
-				Type:         "coupons",
-				Name:         "Coupons",
+				ID:           container.Type + "-" + strconv.FormatInt(id, 10),
+				Type:         container.Type,
+				Name:         container.Name,
 				ConnectionID: &id,
 				PlatformType: platformType,
 				IsExpandable: true,
@@ -437,30 +538,42 @@ func (s *Server) handleGetTree(w http.ResponseWriter, r *http.Request) {
 			Data:         connectionData,
 		}
 
-		// Add connection to the appropriate vendor node
-		if vendorNode, ok := vendorNodes[platformType]; ok {
-			vendorNode.Children = append(vendorNode.Children, connectionNode)
-		}
+		vendorNode := vendorNodes[def.Type]
+		vendorNode.Children = append(vendorNode.Children, connectionNode)
 	}
 
-	// Return the three vendor root nodes in order
-	tree := []*models.TreeNode{
-		vendorNodes["maxio"],
-		vendorNodes["stripe"],
-		vendorNodes["zuora"],
+	// Return the vendor root nodes in registry order
+	tree := make([]*models.TreeNode, 0, len(defs))
+	for _, def := range defs {
+		tree = append(tree, vendorNodes[def.Type])
 	}
 
 	respondJSON(w, http.StatusOK, tree)
 }
 
+// Platform handlers
+func (s *Server) handleListPlatforms(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, platforms.All())
+}
+
 // Preference handlers
+// handleGetPreference returns the user's value for a preference, falling back
+// to the global default
 func (s *Server) handleGetPreference(w http.ResponseWriter, r *http.Request) {
 	key := r.PathValue("key")
+	if _, ok := preferenceSchemas[key]; !ok {
+		respondError(w, http.StatusNotFound, "Preference not found")
+		return
+	}
+	user, _ := userFromContext(r.Context())
 
 	var value json.RawMessage
 	err := s.db.Pool().QueryRow(context.Background(), `
-		SELECT preference_value FROM user_preferences WHERE preference_key = $1
-	`, key).Scan(&value)
+		SELECT preference_value FROM user_preferences
+		WHERE preference_key = $1 AND (user_id = $2 OR user_id IS NULL)
+		ORDER BY user_id NULLS LAST
+		LIMIT 1
+	`, key, user.ID).Scan(&value)
 	if err != nil {
 		respondError(w, http.StatusNotFound, "Preference not found")
 		return
@@ -471,20 +584,44 @@ func (s *Server) handleGetPreference(w http.ResponseWriter, r *http.Request) {
 	w.Write(value)
 }
 
+// handleUpdatePreference stores the user's value for a preference. With
+// ?default=true a global administrator sets the default for everyone instead.
 func (s *Server) handleUpdatePreference(w http.ResponseWriter, r *http.Request) {
 	key := r.PathValue("key")
+	user, _ := userFromContext(r.Context())
 
 	var value json.RawMessage
 	if err := json.NewDecoder(r.Body).Decode(&value); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
+	if err := validatePreference(key, value); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	_, err := s.db.Pool().Exec(context.Background(), `
-		INSERT INTO user_preferences (preference_key, preference_value)
-		VALUES ($1, $2)
-		ON CONFLICT (preference_key) DO UPDATE SET preference_value = $2, updated_at = NOW()
-	`, key, value)
+	setDefault, _ := strconv.ParseBool(r.URL.Query().Get("default"))
+	if setDefault && !user.IsAdmin {
+		respondError(w, http.StatusForbidden, "Administrator access required")
+		return
+	}
+
+	var err error
+	if setDefault {
+		_, err = s.db.Pool().Exec(context.Background(), `
+			INSERT INTO user_preferences (preference_key, preference_value)
+			VALUES ($1, $2)
+			ON CONFLICT (preference_key) WHERE user_id IS NULL
+			DO UPDATE SET preference_value = $2, updated_at = NOW()
+		`, key, value)
+	} else {
+		_, err = s.db.Pool().Exec(context.Background(), `
+			INSERT INTO user_preferences (user_id, preference_key, preference_value)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, preference_key) WHERE user_id IS NOT NULL
+			DO UPDATE SET preference_value = $3, updated_at = NOW()
+		`, user.ID, key, value)
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -493,109 +630,171 @@ func (s *Server) handleUpdatePreference(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
-// Helper to get or create Maxio client
-func (s *Server) getMaxioClient(connectionID int64) (*maxio.Client, error) {
-	if client, ok := s.maxioClients[connectionID]; ok {
-		return client, nil
-	}
-
-	ctx := context.Background()
-
-	// Get connection details
-	var subdomain string
-	err := s.db.Pool().QueryRow(ctx, `
-		SELECT subdomain FROM platform_connections WHERE id = $1 AND platform_type = 'maxio'
-	`, connectionID).Scan(&subdomain)
-	if err != nil {
-		return nil, err
+// handleResetPreference removes the user's value so the global default applies again
+func (s *Server) handleResetPreference(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if _, ok := preferenceSchemas[key]; !ok {
+		respondError(w, http.StatusNotFound, "Preference not found")
+		return
 	}
+	user, _ := userFromContext(r.Context())
 
-	// Get API key
-	var apiKey string
-	err = s.db.Pool().QueryRow(ctx, `
-		SELECT credential_value FROM platform_credentials
-		WHERE connection_id = $1 AND credential_type = 'api_key'
-	`, connectionID).Scan(&apiKey)
+	_, err := s.db.Pool().Exec(context.Background(), `
+		DELETE FROM user_preferences WHERE user_id = $1 AND preference_key = $2
+	`, user.ID, key)
 	if err != nil {
-		return nil, err
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	client := maxio.NewClient(subdomain, apiKey)
-	s.maxioClients[connectionID] = client
-	return client, nil
+	respondJSON(w, http.StatusOK, map[string]string{"status": "reset"})
 }
 
-// Helper to get or create Zuora client
-func (s *Server) getZuoraClient(connectionID int64) (*zuora.Client, error) {
-	if client, ok := s.zuoraClients[connectionID]; ok {
-		return client, nil
+// errConnectionNotFound is returned by getProvider and the client helpers
+// when the connection does not exist
+var errConnectionNotFound = errors.New("connection not found")
+
+// respondConnectionError responds to a failure to load a connection's
+// provider or client: 404 if the connection does not exist, 500 otherwise
+func respondConnectionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errConnectionNotFound) {
+		respondError(w, http.StatusNotFound, "Connection not found")
+		return
 	}
+	respondError(w, http.StatusInternalServerError, err.Error())
+}
+
+// secretRefTTL bounds how long a provider built from secret references is
+// reused, since the referenced secrets can change without the hub knowing
+const secretRefTTL = 5 * time.Minute
 
+// Helper to get or create the provider for a connection. The cached provider
+// is reused until the connection's config_version changes.
+func (s *Server) getProvider(connectionID int64) (platforms.BillingProvider, error) {
 	ctx := context.Background()
 
-	// Get connection details including base_url
-	var baseURL string
-	var isSandbox bool
+	// Get connection details
+	var platformType string
+	var version int64
+	var usesSecretRefs bool
+	cfg := platforms.ConnectionConfig{ID: connectionID}
 	err := s.db.Pool().QueryRow(ctx, `
-		SELECT COALESCE(base_url, ''), is_sandbox FROM platform_connections WHERE id = $1 AND platform_type = 'zuora'
-	`, connectionID).Scan(&baseURL, &isSandbox)
+		SELECT platform_type, COALESCE(subdomain, ''), COALESCE(base_url, ''), is_sandbox, config_version,
+		       EXISTS (SELECT 1 FROM platform_credentials WHERE connection_id = $1 AND secret_ref IS NOT NULL)
+		FROM platform_connections WHERE id = $1
+	`, connectionID).Scan(&platformType, &cfg.Subdomain, &cfg.BaseURL, &cfg.IsSandbox, &version, &usesSecretRefs)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errConnectionNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Fall back to default URLs if base_url is not set
-	if baseURL == "" {
-		if isSandbox {
-			baseURL = "https://rest.sandbox.na.zuora.com"
-		} else {
-			baseURL = "https://rest.na.zuora.com"
+	return s.providers.Get(connectionID, version, func() (platforms.BillingProvider, time.Duration, error) {
+		def, ok := platforms.Lookup(models.PlatformType(platformType))
+		if !ok {
+			return nil, 0, fmt.Errorf("unsupported platform type: %s", platformType)
+		}
+
+		// Get credentials, decrypting stored values and resolving secret references
+		cfg.Credentials, err = s.db.LoadCredentials(ctx, s.keyring, s.resolver, connectionID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		provider, err := def.NewProvider(cfg)
+		if err != nil {
+			return nil, 0, err
+		}
+		if usesSecretRefs {
+			return provider, secretRefTTL, nil
+		}
+		return provider, 0, nil
+	})
+}
+
+// Helper to check a platform uses a credential type
+func declaresCredential(def platforms.Definition, credentialType string) bool {
+	for _, field := range def.Credentials {
+		if field.Type == credentialType {
+			return true
 		}
 	}
+	return false
+}
 
-	// Get client_id
-	var clientID string
-	err = s.db.Pool().QueryRow(ctx, `
-		SELECT credential_value FROM platform_credentials
-		WHERE connection_id = $1 AND credential_type = 'client_id'
-	`, connectionID).Scan(&clientID)
+// Helper to check a secret reference names one of the platform's credentials
+// and uses an enabled backend
+func (s *Server) validateCredentialRef(def platforms.Definition, credentialType, ref string) error {
+	if !declaresCredential(def, credentialType) {
+		return fmt.Errorf("%s does not use a %s credential", def.DisplayName, credentialType)
+	}
+	if err := s.resolver.Validate(ref); err != nil {
+		return fmt.Errorf("%s: %w", credentialType, err)
+	}
+	return nil
+}
+
+// Helper to get the Maxio client for a connection; its responses are
+// reported to the request's audit event
+func (s *Server) getMaxioClient(r *http.Request, connectionID int64) (*maxio.Client, error) {
+	provider, err := s.getProvider(connectionID)
 	if err != nil {
 		return nil, err
 	}
+	p, ok := provider.(*maxio.Provider)
+	if !ok {
+		return nil, fmt.Errorf("connection %d is not a Maxio connection", connectionID)
+	}
+	if observe := upstreamObserver(r); observe != nil {
+		return p.Client().WithResponseObserver(observe), nil
+	}
+	return p.Client(), nil
+}
 
-	// Get client_secret
-	var clientSecret string
-	err = s.db.Pool().QueryRow(ctx, `
-		SELECT credential_value FROM platform_credentials
-		WHERE connection_id = $1 AND credential_type = 'client_secret'
-	`, connectionID).Scan(&clientSecret)
+// Helper to get the Zuora client for a connection; its responses are
+// reported to the request's audit event
+func (s *Server) getZuoraClient(r *http.Request, connectionID int64) (*zuora.Client, error) {
+	provider, err := s.getProvider(connectionID)
 	if err != nil {
 		return nil, err
 	}
-
-	client := zuora.NewClient(baseURL, clientID, clientSecret)
-	s.zuoraClients[connectionID] = client
-	return client, nil
+	p, ok := provider.(*zuora.Provider)
+	if !ok {
+		return nil, fmt.Errorf("connection %d is not a Zuora connection", connectionID)
+	}
+	if observe := upstreamObserver(r); observe != nil {
+		return p.Client().WithResponseObserver(observe), nil
+	}
+	return p.Client(), nil
 }
 
-// Helper to get or create Stripe client
+// Helper to get the Stripe client for a connection
 func (s *Server) getStripeClient(connectionID int64) (*stripe.Client, error) {
-	if client, ok := s.stripeClients[connectionID]; ok {
-		return client, nil
+	provider, err := s.getProvider(connectionID)
+	if err != nil {
+		return nil, err
 	}
+	p, ok := provider.(*stripe.Provider)
+	if !ok {
+		return nil, fmt.Errorf("connection %d is not a Stripe connection", connectionID)
+	}
+	return p.Client(), nil
+}
 
-	ctx := context.Background()
-
-	// Get API key
-	var apiKey string
-	err := s.db.Pool().QueryRow(ctx, `
-		SELECT credential_value FROM platform_credentials
-		WHERE connection_id = $1 AND credential_type = 'api_key'
-	`, connectionID).Scan(&apiKey)
+// Helper to get the Recurly client for a connection; its responses are
+// reported to the request's audit event
+func (s *Server) getRecurlyClient(r *http.Request, connectionID int64) (*recurly.Client, error) {
+	provider, err := s.getProvider(connectionID)
 	if err != nil {
 		return nil, err
 	}
-
-	client := stripe.NewClient(apiKey)
-	s.stripeClients[connectionID] = client
-	return client, nil
+	p, ok := provider.(*recurly.Provider)
+	if !ok {
+		return nil, fmt.Errorf("connection %d is not a Recurly connection", connectionID)
+	}
+	if observe := upstreamObserver(r); observe != nil {
+		return p.Client().WithResponseObserver(observe), nil
+	}
+	return p.Client(), nil
 }
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/auth"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// sessionCookie carries the session token for browser clients; API clients
+// send the same token as "Authorization: Bearer <token>"
+const sessionCookie = "hub_session"
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// userFromContext returns the signed-in user for a request that passed authMiddleware
+func userFromContext(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(userContextKey).(models.User)
+	return user, ok
+}
+
+// sessionToken returns the request's bearer token or session cookie, and
+// whether it came from the cookie
+func sessionToken(r *http.Request) (string, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, _ := strings.Cut(header, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token), false
+		}
+		return "", false
+	}
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		return cookie.Value, true
+	}
+	return "", false
+}
+
+// authMiddleware requires a valid session for every /api/ route except login
+// and webhook deliveries
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/api/auth/login" || r.Method == http.MethodOptions ||
+			isWebhookRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, fromCookie := sessionToken(r)
+		user, err := s.auth.Authenticate(r.Context(), token)
+		if errors.Is(err, auth.ErrInvalidSession) {
+			respondError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// Browsers attach the cookie to cross-site requests, so state-changing
+		// requests authenticated by it must come from an allowed origin
+		if fromCookie && r.Method != http.MethodGet && r.Method != http.MethodHead && !s.sameOrAllowedOrigin(r) {
+			respondError(w, http.StatusForbidden, "Cross-origin request not allowed")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+	})
+}
+
+// sameOrAllowedOrigin reports whether the request's Origin, if any, is this
+// server or one of the allowed CORS origins
+func (s *Server) sameOrAllowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return true
+	}
+	return s.originAllowed(origin)
+}
+
+// Auth handlers
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	session, err := s.auth.Login(r.Context(), req.Username, req.Password, r.UserAgent())
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		respondError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+	respondJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	token, _ := sessionToken(r)
+	if err := s.auth.Logout(r.Context(), token); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+	respondJSON(w, http.StatusOK, map[string]string{"status": "logged out"})
+}
+
+func (s *Server) handleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	respondJSON(w, http.StatusOK, user)
+}
+
+// Helper to tell whether the client reached us over HTTPS, directly or via a proxy
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// originAllowed reports whether origin may make credentialed CORS requests
+func (s *Server) originAllowed(origin string) bool {
+	return slices.Contains(s.allowedOrigins, origin)
+}
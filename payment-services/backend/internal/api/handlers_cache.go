@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// DefaultCacheTTL is how long after a successful sync list endpoints are served from the cache
+const DefaultCacheTTL = 15 * time.Minute
+
+// cacheCursorPrefix marks the cursors the cache hands out. A listing that
+// started from the cache continues from it, so its pages stay consistent.
+const cacheCursorPrefix = "cache-"
+
+// Orders matching each platform's own list order. Page-numbered platforms
+// are listed in the order the first full sync fetched them.
+const (
+	syncOrder    = "id"
+	stripeOrder  = "(raw_data->>'created')::BIGINT DESC, external_id DESC"
+	recurlyOrder = "raw_data->>'created_at' DESC, external_id DESC"
+)
+
+// cacheState describes the cache for a connection as seen by one request
+type cacheState struct {
+	fresh    bool
+	syncedAt time.Time
+}
+
+// cacheFor decides whether a list request for the connection can be answered
+// from the cache. Requests continuing a live listing (starting_after, or a
+// platform cursor) always go live. For platforms whose incremental syncs
+// cannot see updates, only a full sync counts towards freshness.
+func (s *Server) cacheFor(r *http.Request, connectionID int64) cacheState {
+	query := r.URL.Query()
+	if live, _ := strconv.ParseBool(query.Get("live")); live {
+		return cacheState{}
+	}
+	if query.Has("starting_after") {
+		return cacheState{}
+	}
+	cursor := query.Get("cursor")
+	fromCache := strings.HasPrefix(cursor, cacheCursorPrefix)
+	if cursor != "" && !fromCache {
+		return cacheState{}
+	}
+
+	var platformType string
+	var lastSyncAt, lastFullSyncAt *time.Time
+	err := s.db.Pool().QueryRow(context.Background(), `
+		SELECT platform_type, last_sync_at,
+		       (SELECT MAX(finished_at) FROM sync_runs WHERE connection_id = $1 AND mode = $2 AND status = $3)
+		FROM platform_connections WHERE id = $1
+	`, connectionID, models.SyncFull, models.SyncSucceeded).Scan(&platformType, &lastSyncAt, &lastFullSyncAt)
+	if err != nil {
+		return cacheState{}
+	}
+
+	syncedAt := lastSyncAt
+	if def, ok := platforms.Lookup(models.PlatformType(platformType)); ok && def.IncrementalByCreation {
+		syncedAt = lastFullSyncAt
+	}
+	if syncedAt == nil {
+		return cacheState{}
+	}
+
+	return cacheState{
+		fresh:    fromCache || time.Since(*syncedAt) < s.cacheTTL,
+		syncedAt: *syncedAt,
+	}
+}
+
+// setCacheHeaders reports where the response came from and, for cache hits, how old it is
+func setCacheHeaders(w http.ResponseWriter, cache cacheState) {
+	if !cache.fresh {
+		w.Header().Set("X-Cache", "MISS")
+		return
+	}
+	w.Header().Set("X-Cache", "HIT")
+	w.Header().Set("X-Cache-Age", strconv.Itoa(int(time.Since(cache.syncedAt).Seconds())))
+	w.Header().Set("X-Cache-Synced-At", cache.syncedAt.UTC().Format(time.RFC3339))
+}
+
+// cachePage selects one page of cached rows, paged the way the live call
+// for the same request would be
+type cachePage struct {
+	orderBy string // one of the *Order constants
+	offset  int
+	limit   int
+}
+
+// numberedPage reads page-number paging parameters, such as page and
+// per_page, defaulting the size as the platform client does
+func numberedPage(r *http.Request, pageParam, sizeParam string, defaultSize int) cachePage {
+	page, _ := strconv.Atoi(r.URL.Query().Get(pageParam))
+	if page < 1 {
+		page = 1
+	}
+	size, _ := strconv.Atoi(r.URL.Query().Get(sizeParam))
+	if size <= 0 {
+		size = defaultSize
+	}
+	return cachePage{orderBy: syncOrder, offset: (page - 1) * size, limit: size}
+}
+
+// cursorPage reads limit and a cache cursor, defaulting the limit as the
+// platform client does
+func cursorPage(r *http.Request, orderBy string, defaultLimit int) cachePage {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	offset, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("cursor"), cacheCursorPrefix))
+	if offset < 0 {
+		offset = 0
+	}
+	return cachePage{orderBy: orderBy, offset: offset, limit: limit}
+}
+
+// nextCursor returns the cache cursor for the page after this one
+func (p cachePage) nextCursor() string {
+	return cacheCursorPrefix + strconv.Itoa(p.offset+p.limit)
+}
+
+// loadCached decodes the raw_data of one page of cached rows for a
+// connection into the platform's own type, and reports whether more rows
+// follow. table must be one of the cached_* tables.
+func loadCached[T any](s *Server, table string, connectionID int64, page cachePage) ([]T, bool, error) {
+	rows, err := s.db.Pool().Query(context.Background(), fmt.Sprintf(`
+		SELECT raw_data FROM %s
+		WHERE connection_id = $1 AND raw_data IS NOT NULL
+		ORDER BY %s
+		LIMIT $2 OFFSET $3
+	`, table, page.orderBy), connectionID, page.limit+1, page.offset)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	result := []T{}
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, false, err
+		}
+		var item T
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return nil, false, fmt.Errorf("failed to decode cached row: %w", err)
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	more := len(result) > page.limit
+	if more {
+		result = result[:page.limit]
+	}
+	return result, more, nil
+}
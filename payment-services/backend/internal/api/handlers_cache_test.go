@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNumberedPage(t *testing.T) {
+	tests := []struct {
+		query string
+		want  cachePage
+	}{
+		{"", cachePage{orderBy: syncOrder, offset: 0, limit: 50}},
+		{"?page=1&per_page=20", cachePage{orderBy: syncOrder, offset: 0, limit: 20}},
+		{"?page=3&per_page=20", cachePage{orderBy: syncOrder, offset: 40, limit: 20}},
+		{"?page=0&per_page=-5", cachePage{orderBy: syncOrder, offset: 0, limit: 50}},
+		{"?page=2", cachePage{orderBy: syncOrder, offset: 50, limit: 50}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/customers"+tt.query, nil)
+		if got := numberedPage(r, "page", "per_page", 50); got != tt.want {
+			t.Errorf("numberedPage(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCursorPage(t *testing.T) {
+	tests := []struct {
+		query      string
+		want       cachePage
+		wantCursor string
+	}{
+		{"", cachePage{orderBy: recurlyOrder, offset: 0, limit: 200}, "cache-200"},
+		{"?limit=10", cachePage{orderBy: recurlyOrder, offset: 0, limit: 10}, "cache-10"},
+		{"?limit=10&cursor=cache-30", cachePage{orderBy: recurlyOrder, offset: 30, limit: 10}, "cache-40"},
+		{"?cursor=cache--4", cachePage{orderBy: recurlyOrder, offset: 0, limit: 200}, "cache-200"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/accounts"+tt.query, nil)
+		got := cursorPage(r, recurlyOrder, 200)
+		if got != tt.want {
+			t.Errorf("cursorPage(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+		if cursor := got.nextCursor(); cursor != tt.wantCursor {
+			t.Errorf("cursorPage(%q).nextCursor() = %q, want %q", tt.query, cursor, tt.wantCursor)
+		}
+	}
+}
+
+func TestCacheForSkipsLiveListings(t *testing.T) {
+	// These never reach the database: they continue a listing the platform started
+	s := &Server{}
+	for _, query := range []string{"?live=true", "?starting_after=cus_123", "?cursor=vendor-opaque"} {
+		r := httptest.NewRequest("GET", "/customers"+query, nil)
+		if cache := s.cacheFor(r, 1); cache.fresh {
+			t.Errorf("cacheFor(%q) served from the cache", query)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package api
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -14,6 +13,7 @@ import (
 func respondAPIError(w http.ResponseWriter, err error) {
 	var apiErr *maxio.APIError
 	if errors.As(err, &apiErr) {
+		noteUpstreamStatus(w, apiErr.StatusCode)
 		// Map Maxio status codes to HTTP status codes
 		statusCode := apiErr.StatusCode
 		// For client errors (4xx), pass through; for server errors default to 502 Bad Gateway
@@ -34,16 +34,30 @@ func (s *Server) handleMaxioListCustomers(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Serve from the local cache when the last sync is recent enough
+	cache := s.cacheFor(r, connectionID)
+	setCacheHeaders(w, cache)
+	if cache.fresh {
+		customers, _, err := loadCached[maxio.Customer](s, "cached_customers", connectionID,
+			numberedPage(r, "page", "per_page", maxio.DefaultPageSize))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, customers)
 		return
 	}
 
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
 
-	customers, err := client.ListCustomers(page, perPage)
+	customers, err := client.ListCustomers(page, perPage, nil)
 	if err != nil {
 		respondAPIError(w, err)
 		return
@@ -59,9 +73,9 @@ func (s *Server) handleMaxioCreateCustomer(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -98,9 +112,9 @@ func (s *Server) handleMaxioGetCustomer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -126,9 +140,9 @@ func (s *Server) handleMaxioUpdateCustomer(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -159,16 +173,30 @@ func (s *Server) handleMaxioListSubscriptions(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Serve from the local cache when the last sync is recent enough
+	cache := s.cacheFor(r, connectionID)
+	setCacheHeaders(w, cache)
+	if cache.fresh {
+		subscriptions, _, err := loadCached[maxio.Subscription](s, "cached_subscriptions", connectionID,
+			numberedPage(r, "page", "per_page", maxio.DefaultPageSize))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, subscriptions)
 		return
 	}
 
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
 
-	subscriptions, err := client.ListSubscriptions(page, perPage)
+	subscriptions, err := client.ListSubscriptions(page, perPage, nil)
 	if err != nil {
 		respondAPIError(w, err)
 		return
@@ -190,9 +218,9 @@ func (s *Server) handleMaxioGetSubscription(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -212,9 +240,9 @@ func (s *Server) handleMaxioCreateSubscription(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -250,9 +278,9 @@ func (s *Server) handleMaxioListProducts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -275,9 +303,9 @@ func (s *Server) handleMaxioListProductFamilies(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -300,9 +328,9 @@ func (s *Server) handleMaxioCreateProductFamily(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -339,9 +367,9 @@ func (s *Server) handleMaxioListProductsByFamily(w http.ResponseWriter, r *http.
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -367,9 +395,9 @@ func (s *Server) handleMaxioCreateProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -419,9 +447,9 @@ func (s *Server) handleMaxioGetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -447,9 +475,9 @@ func (s *Server) handleMaxioUpdateProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -493,9 +521,9 @@ func (s *Server) handleMaxioListInvoices(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	client, err := s.getMaxioClient(connectionID)
+	client, err := s.getMaxioClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -526,18 +554,3 @@ func (s *Server) handleMaxioListPayments(w http.ResponseWriter, r *http.Request)
 		"note":     "Payments are accessed via invoices in Maxio. Use /invoices endpoint.",
 	})
 }
-
-// Platform interface for future abstraction
-type Platform interface {
-	TestConnection() error
-	ListCustomers(page, perPage int) (interface{}, error)
-	GetCustomer(id string) (interface{}, error)
-	CreateCustomer(input interface{}) (interface{}, error)
-	ListSubscriptions(page, perPage int) (interface{}, error)
-	GetSubscription(id string) (interface{}, error)
-	ListProducts(page, perPage int) (interface{}, error)
-	ListInvoices(page, perPage int) (interface{}, error)
-}
-
-// Verify maxio.Client implements platform interface conceptually
-var _ = fmt.Sprintf("Maxio client ready")
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/maxio"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/recurly"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/stripe"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/zuora"
+)
+
+// respondProviderError maps errors from any platform to HTTP status codes
+func respondProviderError(w http.ResponseWriter, err error) {
+	if errors.Is(err, platforms.ErrNotSupported) {
+		respondError(w, http.StatusNotImplemented, err.Error())
+		return
+	}
+
+	var maxioErr *maxio.APIError
+	var stripeErr *stripe.APIError
+	var zuoraErr *zuora.APIError
+	var recurlyErr *recurly.APIError
+	switch {
+	case errors.As(err, &maxioErr):
+		respondAPIError(w, err)
+	case errors.As(err, &stripeErr):
+		respondStripeAPIError(w, err)
+	case errors.As(err, &zuoraErr):
+		respondZuoraAPIError(w, err)
+	case errors.As(err, &recurlyErr):
+		respondRecurlyAPIError(w, err)
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// providerFromRequest resolves the provider for the {id} path value
+func (s *Server) providerFromRequest(w http.ResponseWriter, r *http.Request) (platforms.BillingProvider, bool) {
+	connectionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return nil, false
+	}
+
+	provider, err := s.getProvider(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return nil, false
+	}
+
+	// Stripe writes send the call's idempotency key; see idempotent
+	if p, ok := provider.(*stripe.Provider); ok {
+		if key := idempotencyKey(r); key != "" {
+			provider = stripe.NewProvider(p.Client().WithIdempotencyKey(key))
+		}
+	}
+	return observeUpstream(r, provider), true
+}
+
+// listParamsFromRequest reads the cursor and limit query parameters
+func listParamsFromRequest(r *http.Request) platforms.ListParams {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	return platforms.ListParams{
+		Cursor: r.URL.Query().Get("cursor"),
+		Limit:  limit,
+	}
+}
+
+// respondList writes a normalized list, exposing the next page cursor as a header
+func respondList[T any](w http.ResponseWriter, items []T, nextCursor string) {
+	if items == nil {
+		items = []T{}
+	}
+	if nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) handleListCustomers(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.providerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	customers, next, err := provider.ListCustomers(listParamsFromRequest(r))
+	if err != nil {
+		respondProviderError(w, err)
+		return
+	}
+
+	respondList(w, customers, next)
+}
+
+func (s *Server) handleGetCustomer(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.providerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	customerID := r.PathValue("customerId")
+	if customerID == "" {
+		respondError(w, http.StatusBadRequest, "Customer ID is required")
+		return
+	}
+
+	customer, err := provider.GetCustomer(customerID)
+	if err != nil {
+		respondProviderError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, customer)
+}
+
+func (s *Server) handleCreateCustomer(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.providerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.CreateCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Email == "" {
+		respondError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	customer, err := provider.CreateCustomer(input)
+	if err != nil {
+		respondProviderError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, customer)
+}
+
+func (s *Server) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.providerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	subscriptions, next, err := provider.ListSubscriptions(listParamsFromRequest(r))
+	if err != nil {
+		respondProviderError(w, err)
+		return
+	}
+
+	respondList(w, subscriptions, next)
+}
+
+func (s *Server) handleGetSubscription(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.providerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	subscriptionID := r.PathValue("subscriptionId")
+	if subscriptionID == "" {
+		respondError(w, http.StatusBadRequest, "Subscription ID is required")
+		return
+	}
+
+	subscription, err := provider.GetSubscription(subscriptionID)
+	if err != nil {
+		respondProviderError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, subscription)
+}
+
+func (s *Server) handleListInvoices(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.providerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	invoices, next, err := provider.ListInvoices(listParamsFromRequest(r))
+	if err != nil {
+		respondProviderError(w, err)
+		return
+	}
+
+	respondList(w, invoices, next)
+}
+
+func (s *Server) handleListPayments(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.providerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	payments, next, err := provider.ListPayments(listParamsFromRequest(r))
+	if err != nil {
+		respondProviderError(w, err)
+		return
+	}
+
+	respondList(w, payments, next)
+}
+
+func (s *Server) handleListProducts(w http.ResponseWriter, r *http.Request) {
+	provider, ok := s.providerFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	products, next, err := provider.ListProducts(listParamsFromRequest(r))
+	if err != nil {
+		respondProviderError(w, err)
+		return
+	}
+
+	respondList(w, products, next)
+}
@@ -0,0 +1,512 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/recurly"
+)
+
+// respondRecurlyAPIError handles errors from the Recurly API, returning appropriate HTTP status codes
+func respondRecurlyAPIError(w http.ResponseWriter, err error) {
+	var apiErr *recurly.APIError
+	if errors.As(err, &apiErr) {
+		noteUpstreamStatus(w, apiErr.StatusCode)
+		statusCode := apiErr.StatusCode
+		if statusCode < 400 || statusCode >= 600 {
+			statusCode = http.StatusBadGateway
+		}
+		respondError(w, statusCode, apiErr.Message)
+		return
+	}
+	respondError(w, http.StatusInternalServerError, err.Error())
+}
+
+// recurlyListParams reads the limit and cursor query parameters
+func recurlyListParams(r *http.Request) (int, string) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	return limit, r.URL.Query().Get("cursor")
+}
+
+// respondRecurlyList writes a page of results, passing the next cursor in a header
+func respondRecurlyList[T any](w http.ResponseWriter, list *recurly.List[T]) {
+	if next := list.NextCursor(); next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+	data := list.Data
+	if data == nil {
+		data = []T{}
+	}
+	respondJSON(w, http.StatusOK, data)
+}
+
+// respondCachedRecurlyList writes a page read from the cache like
+// respondRecurlyList, with a cache cursor for the next page
+func respondCachedRecurlyList[T any](w http.ResponseWriter, items []T, more bool, page cachePage) {
+	if more {
+		w.Header().Set("X-Next-Cursor", page.nextCursor())
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) handleRecurlyListAccounts(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Serve from the local cache when the last sync is recent enough
+	cache := s.cacheFor(r, connectionID)
+	setCacheHeaders(w, cache)
+	if cache.fresh {
+		page := cursorPage(r, recurlyOrder, recurly.DefaultListLimit)
+		accounts, more, err := loadCached[recurly.Account](s, "cached_customers", connectionID, page)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondCachedRecurlyList(w, accounts, more, page)
+		return
+	}
+
+	limit, cursor := recurlyListParams(r)
+	result, err := client.ListAccounts(limit, cursor, nil)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondRecurlyList(w, result)
+}
+
+func (s *Server) handleRecurlyGetAccount(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	accountID := r.PathValue("accountId")
+	if accountID == "" {
+		respondError(w, http.StatusBadRequest, "Account ID is required")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	account, err := client.GetAccount(accountID)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, account)
+}
+
+func (s *Server) handleRecurlyCreateAccount(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input recurly.AccountInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Code == "" {
+		respondError(w, http.StatusBadRequest, "Account code is required")
+		return
+	}
+
+	account, err := client.CreateAccount(input)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, account)
+}
+
+func (s *Server) handleRecurlyListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Serve from the local cache when the last sync is recent enough
+	cache := s.cacheFor(r, connectionID)
+	setCacheHeaders(w, cache)
+	if cache.fresh {
+		page := cursorPage(r, recurlyOrder, recurly.DefaultListLimit)
+		subscriptions, more, err := loadCached[recurly.Subscription](s, "cached_subscriptions", connectionID, page)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondCachedRecurlyList(w, subscriptions, more, page)
+		return
+	}
+
+	limit, cursor := recurlyListParams(r)
+	result, err := client.ListSubscriptions(limit, cursor, nil)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondRecurlyList(w, result)
+}
+
+func (s *Server) handleRecurlyGetSubscription(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	subscriptionID := r.PathValue("subscriptionId")
+	if subscriptionID == "" {
+		respondError(w, http.StatusBadRequest, "Subscription ID is required")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	subscription, err := client.GetSubscription(subscriptionID)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, subscription)
+}
+
+func (s *Server) handleRecurlyCreateSubscription(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input recurly.SubscriptionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.PlanCode == "" || input.Account.Code == "" {
+		respondError(w, http.StatusBadRequest, "Plan code and account code are required")
+		return
+	}
+
+	subscription, err := client.CreateSubscription(input)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, subscription)
+}
+
+func (s *Server) handleRecurlyListPlans(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	limit, cursor := recurlyListParams(r)
+	result, err := client.ListPlans(limit, cursor)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondRecurlyList(w, result)
+}
+
+func (s *Server) handleRecurlyGetPlan(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	planID := r.PathValue("planId")
+	if planID == "" {
+		respondError(w, http.StatusBadRequest, "Plan ID is required")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	plan, err := client.GetPlan(planID)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, plan)
+}
+
+func (s *Server) handleRecurlyCreatePlan(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input recurly.PlanInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Code == "" || input.Name == "" {
+		respondError(w, http.StatusBadRequest, "Plan code and name are required")
+		return
+	}
+
+	plan, err := client.CreatePlan(input)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, plan)
+}
+
+func (s *Server) handleRecurlyListInvoices(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	limit, cursor := recurlyListParams(r)
+	result, err := client.ListInvoices(limit, cursor)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondRecurlyList(w, result)
+}
+
+func (s *Server) handleRecurlyGetInvoice(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	invoiceID := r.PathValue("invoiceId")
+	if invoiceID == "" {
+		respondError(w, http.StatusBadRequest, "Invoice ID is required")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	invoice, err := client.GetInvoice(invoiceID)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, invoice)
+}
+
+func (s *Server) handleRecurlyCreateInvoice(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	accountID := r.PathValue("accountId")
+	if accountID == "" {
+		respondError(w, http.StatusBadRequest, "Account ID is required")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input recurly.InvoiceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Currency == "" {
+		respondError(w, http.StatusBadRequest, "Currency is required")
+		return
+	}
+
+	collection, err := client.CreateInvoice(accountID, input)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, collection)
+}
+
+func (s *Server) handleRecurlyListTransactions(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	limit, cursor := recurlyListParams(r)
+	result, err := client.ListTransactions(limit, cursor)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondRecurlyList(w, result)
+}
+
+func (s *Server) handleRecurlyGetTransaction(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	transactionID := r.PathValue("transactionId")
+	if transactionID == "" {
+		respondError(w, http.StatusBadRequest, "Transaction ID is required")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	transaction, err := client.GetTransaction(transactionID)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, transaction)
+}
+
+func (s *Server) handleRecurlyCreateTransaction(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	invoiceID := r.PathValue("invoiceId")
+	if invoiceID == "" {
+		respondError(w, http.StatusBadRequest, "Invoice ID is required")
+		return
+	}
+
+	client, err := s.getRecurlyClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input recurly.ExternalTransactionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.PaymentMethod == "" || input.Amount <= 0 {
+		respondError(w, http.StatusBadRequest, "Payment method and a positive amount are required")
+		return
+	}
+
+	transaction, err := client.CreateTransaction(invoiceID, input)
+	if err != nil {
+		respondRecurlyAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, transaction)
+}
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -13,6 +17,7 @@ import (
 func respondStripeAPIError(w http.ResponseWriter, err error) {
 	var apiErr *stripe.APIError
 	if errors.As(err, &apiErr) {
+		noteUpstreamStatus(w, apiErr.StatusCode)
 		statusCode := apiErr.StatusCode
 		if statusCode < 400 || statusCode >= 600 {
 			statusCode = http.StatusBadGateway
@@ -32,14 +37,28 @@ func (s *Server) handleStripeListCustomers(w http.ResponseWriter, r *http.Reques
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Serve from the local cache when the last sync is recent enough
+	cache := s.cacheFor(r, connectionID)
+	setCacheHeaders(w, cache)
+	if cache.fresh {
+		customers, _, err := loadCached[stripe.Customer](s, "cached_customers", connectionID,
+			cursorPage(r, stripeOrder, stripe.DefaultListLimit))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, customers)
 		return
 	}
 
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	startingAfter := r.URL.Query().Get("starting_after")
 
-	result, err := client.ListCustomers(limit, startingAfter)
+	result, err := client.ListCustomers(limit, startingAfter, 0)
 	if err != nil {
 		respondStripeAPIError(w, err)
 		return
@@ -63,7 +82,7 @@ func (s *Server) handleStripeGetCustomer(w http.ResponseWriter, r *http.Request)
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -83,9 +102,9 @@ func (s *Server) handleStripeCreateCustomer(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -117,9 +136,9 @@ func (s *Server) handleStripeUpdateCustomer(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -147,14 +166,28 @@ func (s *Server) handleStripeListSubscriptions(w http.ResponseWriter, r *http.Re
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Serve from the local cache when the last sync is recent enough
+	cache := s.cacheFor(r, connectionID)
+	setCacheHeaders(w, cache)
+	if cache.fresh {
+		subscriptions, _, err := loadCached[stripe.Subscription](s, "cached_subscriptions", connectionID,
+			cursorPage(r, stripeOrder, stripe.DefaultListLimit))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, subscriptions)
 		return
 	}
 
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	startingAfter := r.URL.Query().Get("starting_after")
 
-	result, err := client.ListSubscriptions(limit, startingAfter)
+	result, err := client.ListSubscriptions(limit, startingAfter, 0)
 	if err != nil {
 		respondStripeAPIError(w, err)
 		return
@@ -178,7 +211,7 @@ func (s *Server) handleStripeGetSubscription(w http.ResponseWriter, r *http.Requ
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -198,9 +231,9 @@ func (s *Server) handleStripeCreateProduct(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -236,7 +269,7 @@ func (s *Server) handleStripeListProducts(w http.ResponseWriter, r *http.Request
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -267,7 +300,7 @@ func (s *Server) handleStripeGetProduct(w http.ResponseWriter, r *http.Request)
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -289,7 +322,7 @@ func (s *Server) handleStripeListPrices(w http.ResponseWriter, r *http.Request)
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -315,7 +348,7 @@ func (s *Server) handleStripeListInvoices(w http.ResponseWriter, r *http.Request
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -346,7 +379,7 @@ func (s *Server) handleStripeGetInvoice(w http.ResponseWriter, r *http.Request)
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -368,7 +401,7 @@ func (s *Server) handleStripeListPayments(w http.ResponseWriter, r *http.Request
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -392,9 +425,9 @@ func (s *Server) handleStripeCreatePrice(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -444,9 +477,9 @@ func (s *Server) handleStripeCreateSubscription(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -497,7 +530,7 @@ func (s *Server) handleStripeListCoupons(w http.ResponseWriter, r *http.Request)
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -528,7 +561,7 @@ func (s *Server) handleStripeGetCoupon(w http.ResponseWriter, r *http.Request) {
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -548,9 +581,9 @@ func (s *Server) handleStripeCreateCoupon(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -610,9 +643,9 @@ func (s *Server) handleStripeUpdateCoupon(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -646,9 +679,9 @@ func (s *Server) handleStripeDeleteCoupon(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -678,7 +711,7 @@ func (s *Server) handleStripeGetPrice(w http.ResponseWriter, r *http.Request) {
 
 	client, err := s.getStripeClient(connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -704,9 +737,9 @@ func (s *Server) handleStripeArchivePrice(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -734,9 +767,9 @@ func (s *Server) handleStripeUpdateSubscription(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -774,9 +807,9 @@ func (s *Server) handleStripeCancelSubscription(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	client, err := s.getStripeClient(connectionID)
+	client, err := s.getStripeWriteClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -796,3 +829,880 @@ func (s *Server) handleStripeCancelSubscription(w http.ResponseWriter, r *http.R
 
 	respondJSON(w, http.StatusOK, subscription)
 }
+
+// Refund handlers
+
+// refundReasons are the reasons Stripe accepts for a refund
+var refundReasons = map[string]bool{
+	"duplicate":             true,
+	"fraudulent":            true,
+	"requested_by_customer": true,
+}
+
+func (s *Server) handleStripeListRefunds(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	result, err := client.ListRefunds(limit, query.Get("starting_after"), query.Get("charge"), query.Get("payment_intent"))
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result.Data)
+}
+
+func (s *Server) handleStripeGetRefund(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	refundID := r.PathValue("refundId")
+	if refundID == "" {
+		respondError(w, http.StatusBadRequest, "Refund ID is required")
+		return
+	}
+
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	refund, err := client.GetRefund(refundID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, refund)
+}
+
+func (s *Server) handleStripeCreateRefund(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input stripe.RefundInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Exactly one of charge or payment_intent
+	if (input.Charge == "") == (input.PaymentIntent == "") {
+		respondError(w, http.StatusBadRequest, "either charge or payment_intent is required, but not both")
+		return
+	}
+
+	// Amount is optional; omitting it refunds the full remaining amount
+	if input.Amount < 0 {
+		respondError(w, http.StatusBadRequest, "amount cannot be negative")
+		return
+	}
+
+	if input.Reason != "" && !refundReasons[input.Reason] {
+		respondError(w, http.StatusBadRequest, "reason must be 'duplicate', 'fraudulent', or 'requested_by_customer'")
+		return
+	}
+
+	refund, err := client.CreateRefund(input)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, refund)
+}
+
+// Credit note handlers
+
+// creditNoteReasons are the reasons Stripe accepts for a credit note
+var creditNoteReasons = map[string]bool{
+	"duplicate":              true,
+	"fraudulent":             true,
+	"order_change":           true,
+	"product_unsatisfactory": true,
+}
+
+// decodeCreditNoteInput reads and validates a credit note from the request
+// body, responding with an error and returning false if it is invalid
+func decodeCreditNoteInput(w http.ResponseWriter, r *http.Request) (stripe.CreditNoteInput, bool) {
+	var input stripe.CreditNoteInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return input, false
+	}
+
+	if input.Invoice == "" {
+		respondError(w, http.StatusBadRequest, "invoice is required")
+		return input, false
+	}
+
+	// Either a total amount or itemized lines
+	if (input.Amount > 0) == (len(input.Lines) > 0) {
+		respondError(w, http.StatusBadRequest, "either amount or lines is required, but not both")
+		return input, false
+	}
+	if input.Amount < 0 || input.RefundAmount < 0 || input.CreditAmount < 0 || input.OutOfBandAmount < 0 {
+		respondError(w, http.StatusBadRequest, "amounts cannot be negative")
+		return input, false
+	}
+
+	for i, line := range input.Lines {
+		switch line.Type {
+		case "invoice_line_item":
+			if line.InvoiceLineItem == "" {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("lines[%d]: invoice_line_item is required", i))
+				return input, false
+			}
+		case "custom_line_item":
+			if line.Description == "" || line.UnitAmount <= 0 {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("lines[%d]: description and unit_amount are required for custom_line_item", i))
+				return input, false
+			}
+		default:
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("lines[%d]: type must be 'invoice_line_item' or 'custom_line_item'", i))
+			return input, false
+		}
+	}
+
+	if input.Reason != "" && !creditNoteReasons[input.Reason] {
+		respondError(w, http.StatusBadRequest, "reason must be 'duplicate', 'fraudulent', 'order_change', or 'product_unsatisfactory'")
+		return input, false
+	}
+
+	return input, true
+}
+
+func (s *Server) handleStripeListCreditNotes(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	result, err := client.ListCreditNotes(limit, query.Get("starting_after"), query.Get("invoice"))
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result.Data)
+}
+
+func (s *Server) handleStripeGetCreditNote(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	creditNoteID := r.PathValue("creditNoteId")
+	if creditNoteID == "" {
+		respondError(w, http.StatusBadRequest, "Credit note ID is required")
+		return
+	}
+
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	creditNote, err := client.GetCreditNote(creditNoteID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, creditNote)
+}
+
+func (s *Server) handleStripePreviewCreditNote(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	input, ok := decodeCreditNoteInput(w, r)
+	if !ok {
+		return
+	}
+
+	creditNote, err := client.PreviewCreditNote(input)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, creditNote)
+}
+
+func (s *Server) handleStripeCreateCreditNote(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	input, ok := decodeCreditNoteInput(w, r)
+	if !ok {
+		return
+	}
+
+	creditNote, err := client.CreateCreditNote(input)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, creditNote)
+}
+
+func (s *Server) handleStripeVoidCreditNote(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	creditNoteID := r.PathValue("creditNoteId")
+	if creditNoteID == "" {
+		respondError(w, http.StatusBadRequest, "Credit note ID is required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	creditNote, err := client.VoidCreditNote(creditNoteID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, creditNote)
+}
+
+// Invoice lifecycle handlers
+
+// decodeOptionalBody decodes a JSON request body into v, allowing it to be empty
+func decodeOptionalBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// cacheStripeInvoice writes an invoice changed through the hub to the cache,
+// so cached invoice lists show it before the next sync
+func (s *Server) cacheStripeInvoice(connectionID int64, invoice *stripe.Invoice) {
+	if err := s.syncer.StoreInvoice(context.Background(), connectionID, stripe.NormalizeInvoice(*invoice)); err != nil {
+		log.Printf("stripe: failed to cache invoice %s for connection %d: %v", invoice.ID, connectionID, err)
+	}
+}
+
+// stripeInvoiceTransition applies a lifecycle action to an invoice after
+// checking that its current status allows it. Invalid transitions are
+// rejected with 409 without calling Stripe.
+func (s *Server) stripeInvoiceTransition(w http.ResponseWriter, r *http.Request, action stripe.InvoiceAction,
+	apply func(client *stripe.Client, invoiceID string) (*stripe.Invoice, error)) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	invoiceID := r.PathValue("invoiceId")
+	if invoiceID == "" {
+		respondError(w, http.StatusBadRequest, "Invoice ID is required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	current, err := client.GetInvoice(invoiceID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+	if err := stripe.CheckInvoiceAction(current, action); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	invoice, err := apply(client, invoiceID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	s.cacheStripeInvoice(connectionID, invoice)
+	respondJSON(w, http.StatusOK, invoice)
+}
+
+func (s *Server) handleStripeCreateInvoice(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input stripe.InvoiceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Customer == "" {
+		respondError(w, http.StatusBadRequest, "customer is required")
+		return
+	}
+
+	// Validate collection method
+	switch input.CollectionMethod {
+	case "", "charge_automatically":
+	case "send_invoice":
+		if input.DaysUntilDue <= 0 {
+			respondError(w, http.StatusBadRequest, "days_until_due is required when collection_method is 'send_invoice'")
+			return
+		}
+	default:
+		respondError(w, http.StatusBadRequest, "collection_method must be 'charge_automatically' or 'send_invoice'")
+		return
+	}
+
+	invoice, err := client.CreateInvoice(input)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	s.cacheStripeInvoice(connectionID, invoice)
+	respondJSON(w, http.StatusCreated, invoice)
+}
+
+func (s *Server) handleStripeCreateInvoiceItem(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	invoiceID := r.PathValue("invoiceId")
+	if invoiceID == "" {
+		respondError(w, http.StatusBadRequest, "Invoice ID is required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input stripe.InvoiceItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Either a price or an amount with currency
+	if (input.Price == "") == (input.Amount == 0) {
+		respondError(w, http.StatusBadRequest, "either price or amount is required, but not both")
+		return
+	}
+	if input.Price == "" && input.Currency == "" {
+		respondError(w, http.StatusBadRequest, "currency is required with amount")
+		return
+	}
+	if input.Quantity < 0 {
+		respondError(w, http.StatusBadRequest, "quantity cannot be negative")
+		return
+	}
+
+	// Items can only be added while the invoice is a draft
+	invoice, err := client.GetInvoice(invoiceID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+	if invoice.Status != "draft" {
+		respondError(w, http.StatusConflict, "items can only be added to a draft invoice; this invoice is "+invoice.Status)
+		return
+	}
+
+	item, err := client.CreateInvoiceItem(invoice.Customer, invoiceID, input)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, item)
+}
+
+func (s *Server) handleStripeFinalizeInvoice(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		AutoAdvance *bool `json:"auto_advance"`
+	}
+	if err := decodeOptionalBody(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	s.stripeInvoiceTransition(w, r, stripe.InvoiceFinalize, func(client *stripe.Client, invoiceID string) (*stripe.Invoice, error) {
+		return client.FinalizeInvoice(invoiceID, input.AutoAdvance)
+	})
+}
+
+func (s *Server) handleStripePayInvoice(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		PaymentMethod string `json:"payment_method"`
+		PaidOutOfBand bool   `json:"paid_out_of_band"`
+	}
+	if err := decodeOptionalBody(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.PaymentMethod != "" && input.PaidOutOfBand {
+		respondError(w, http.StatusBadRequest, "payment_method cannot be used with paid_out_of_band")
+		return
+	}
+
+	s.stripeInvoiceTransition(w, r, stripe.InvoicePay, func(client *stripe.Client, invoiceID string) (*stripe.Invoice, error) {
+		return client.PayInvoice(invoiceID, input.PaymentMethod, input.PaidOutOfBand)
+	})
+}
+
+func (s *Server) handleStripeSendInvoice(w http.ResponseWriter, r *http.Request) {
+	s.stripeInvoiceTransition(w, r, stripe.InvoiceSend, func(client *stripe.Client, invoiceID string) (*stripe.Invoice, error) {
+		return client.SendInvoice(invoiceID)
+	})
+}
+
+func (s *Server) handleStripeVoidInvoice(w http.ResponseWriter, r *http.Request) {
+	s.stripeInvoiceTransition(w, r, stripe.InvoiceVoid, func(client *stripe.Client, invoiceID string) (*stripe.Invoice, error) {
+		return client.VoidInvoice(invoiceID)
+	})
+}
+
+func (s *Server) handleStripeMarkInvoiceUncollectible(w http.ResponseWriter, r *http.Request) {
+	s.stripeInvoiceTransition(w, r, stripe.InvoiceMarkUncollectible, func(client *stripe.Client, invoiceID string) (*stripe.Invoice, error) {
+		return client.MarkInvoiceUncollectible(invoiceID)
+	})
+}
+
+func (s *Server) handleStripeDeleteInvoice(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	invoiceID := r.PathValue("invoiceId")
+	if invoiceID == "" {
+		respondError(w, http.StatusBadRequest, "Invoice ID is required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	invoice, err := client.GetInvoice(invoiceID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+	if err := stripe.CheckInvoiceAction(invoice, stripe.InvoiceDelete); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if err := client.DeleteInvoice(invoiceID); err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	if err := s.syncer.DeleteInvoice(context.Background(), connectionID, invoiceID); err != nil {
+		log.Printf("stripe: failed to remove cached invoice %s for connection %d: %v", invoiceID, connectionID, err)
+	}
+	respondJSON(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// Payment method handlers
+
+// stripeCustomerPaymentMethod fetches a payment method, responding with 404
+// and returning false unless it is attached to customerID
+func stripeCustomerPaymentMethod(w http.ResponseWriter, client *stripe.Client, customerID, paymentMethodID string) (*stripe.PaymentMethod, bool) {
+	paymentMethod, err := client.GetPaymentMethod(paymentMethodID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return nil, false
+	}
+	if paymentMethod.Customer != customerID {
+		respondError(w, http.StatusNotFound, "Payment method is not attached to this customer")
+		return nil, false
+	}
+	return paymentMethod, true
+}
+
+func (s *Server) handleStripeListPaymentMethods(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	customerID := r.PathValue("customerId")
+	if customerID == "" {
+		respondError(w, http.StatusBadRequest, "Customer ID is required")
+		return
+	}
+
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	result, err := client.ListPaymentMethods(customerID, query.Get("type"), limit, query.Get("starting_after"))
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result.Data)
+}
+
+func (s *Server) handleStripeAttachPaymentMethod(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	customerID := r.PathValue("customerId")
+	if customerID == "" {
+		respondError(w, http.StatusBadRequest, "Customer ID is required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input struct {
+		PaymentMethod string `json:"payment_method"`
+		SetDefault    bool   `json:"set_default"` // Also make it the customer's default
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.PaymentMethod == "" {
+		respondError(w, http.StatusBadRequest, "payment_method is required")
+		return
+	}
+
+	paymentMethod, err := client.AttachPaymentMethod(input.PaymentMethod, customerID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	result := attachedPaymentMethod{PaymentMethod: paymentMethod}
+	if input.SetDefault {
+		// The payment method stays attached when this fails; the response
+		// says so rather than reporting the whole request as failed
+		if _, err := client.SetDefaultPaymentMethod(customerID, paymentMethod.ID); err != nil {
+			log.Printf("stripe: attached %s to %s for connection %d but failed to make it the default: %v",
+				paymentMethod.ID, customerID, connectionID, err)
+			result.DefaultError = err.Error()
+		} else {
+			result.DefaultSet = true
+		}
+	}
+
+	respondJSON(w, http.StatusCreated, result)
+}
+
+// attachedPaymentMethod is the response to attaching a payment method: the
+// payment method, and whether it was also made the customer's default
+type attachedPaymentMethod struct {
+	*stripe.PaymentMethod
+	DefaultSet   bool   `json:"default_set"`
+	DefaultError string `json:"default_error,omitempty"` // Why the default was not set, when asked to set it
+}
+
+func (s *Server) handleStripeDetachPaymentMethod(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	customerID := r.PathValue("customerId")
+	paymentMethodID := r.PathValue("paymentMethodId")
+	if customerID == "" || paymentMethodID == "" {
+		respondError(w, http.StatusBadRequest, "Customer ID and payment method ID are required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Only detach through the customer it belongs to
+	if _, ok := stripeCustomerPaymentMethod(w, client, customerID, paymentMethodID); !ok {
+		return
+	}
+
+	paymentMethod, err := client.DetachPaymentMethod(paymentMethodID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, paymentMethod)
+}
+
+func (s *Server) handleStripeSetDefaultPaymentMethod(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	customerID := r.PathValue("customerId")
+	if customerID == "" {
+		respondError(w, http.StatusBadRequest, "Customer ID is required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input struct {
+		PaymentMethod string `json:"payment_method"` // Empty to clear the default
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.PaymentMethod != "" {
+		if _, ok := stripeCustomerPaymentMethod(w, client, customerID, input.PaymentMethod); !ok {
+			return
+		}
+	}
+
+	customer, err := client.SetDefaultPaymentMethod(customerID, input.PaymentMethod)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, customer)
+}
+
+// SetupIntent handlers
+
+func (s *Server) handleStripeListSetupIntents(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	customerID := r.PathValue("customerId")
+	if customerID == "" {
+		respondError(w, http.StatusBadRequest, "Customer ID is required")
+		return
+	}
+
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	result, err := client.ListSetupIntents(limit, query.Get("starting_after"), customerID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result.Data)
+}
+
+func (s *Server) handleStripeCreateSetupIntent(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	customerID := r.PathValue("customerId")
+	if customerID == "" {
+		respondError(w, http.StatusBadRequest, "Customer ID is required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	var input stripe.SetupIntentInput
+	if err := decodeOptionalBody(r, &input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	input.Customer = customerID
+
+	if input.Usage != "" && input.Usage != "off_session" && input.Usage != "on_session" {
+		respondError(w, http.StatusBadRequest, "usage must be 'off_session' or 'on_session'")
+		return
+	}
+
+	setupIntent, err := client.CreateSetupIntent(input)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, setupIntent)
+}
+
+func (s *Server) handleStripeGetSetupIntent(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	setupIntentID := r.PathValue("setupIntentId")
+	if setupIntentID == "" {
+		respondError(w, http.StatusBadRequest, "Setup intent ID is required")
+		return
+	}
+
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	setupIntent, err := client.GetSetupIntent(setupIntentID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, setupIntent)
+}
+
+func (s *Server) handleStripeCancelSetupIntent(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	setupIntentID := r.PathValue("setupIntentId")
+	if setupIntentID == "" {
+		respondError(w, http.StatusBadRequest, "Setup intent ID is required")
+		return
+	}
+
+	client, err := s.getStripeWriteClient(r, connectionID)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	setupIntent, err := client.CancelSetupIntent(setupIntentID)
+	if err != nil {
+		respondStripeAPIError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, setupIntent)
+}
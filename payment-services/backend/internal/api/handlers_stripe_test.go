@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/stripe"
+)
+
+// errorMessage returns the error a handler wrote to rec
+func errorMessage(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error body %q: %v", rec.Body.String(), err)
+	}
+	return body.Error
+}
+
+func TestRespondStripeAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{"client error passed through", &stripe.APIError{StatusCode: 400, Message: "Charge ch_1 has already been refunded."}, 400, "Charge ch_1 has already been refunded."},
+		{"card declined", &stripe.APIError{StatusCode: 402, Type: "card_error", Message: "Your card was declined."}, 402, "Your card was declined."},
+		{"not found", stripe.NewAPIError(404, "credit note not found"), 404, "credit note not found"},
+		{"upstream outage", stripe.NewAPIError(503, "API error (status 503): unavailable"), 503, "API error (status 503): unavailable"},
+		{"unexpected success status", stripe.NewAPIError(302, "API error (status 302): "), http.StatusBadGateway, "API error (status 302): "},
+		{"not an API error", errors.New("connection refused"), http.StatusInternalServerError, "connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			respondStripeAPIError(rec, tt.err)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := errorMessage(t, rec); got != tt.wantMessage {
+				t.Errorf("message = %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestDecodeCreditNoteInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string // empty when the input is accepted
+	}{
+		{name: "amount", body: `{"invoice":"in_1","amount":500,"reason":"duplicate"}`},
+		{name: "lines", body: `{"invoice":"in_1","lines":[{"type":"invoice_line_item","invoice_line_item":"il_1","amount":300},{"type":"custom_line_item","description":"Goodwill","unit_amount":250,"quantity":1}]}`},
+		{name: "settlement split", body: `{"invoice":"in_1","amount":500,"refund_amount":300,"credit_amount":200}`},
+
+		{name: "not JSON", body: `invoice=in_1`, wantErr: "Invalid request body"},
+		{name: "no invoice", body: `{"amount":500}`, wantErr: "invoice is required"},
+		{name: "neither amount nor lines", body: `{"invoice":"in_1"}`, wantErr: "either amount or lines"},
+		{name: "both amount and lines", body: `{"invoice":"in_1","amount":500,"lines":[{"type":"invoice_line_item","invoice_line_item":"il_1"}]}`, wantErr: "either amount or lines"},
+		{name: "negative refund", body: `{"invoice":"in_1","amount":500,"refund_amount":-1}`, wantErr: "amounts cannot be negative"},
+		{name: "invoice line without its item", body: `{"invoice":"in_1","lines":[{"type":"invoice_line_item","amount":300}]}`, wantErr: "lines[0]: invoice_line_item is required"},
+		{name: "custom line without a price", body: `{"invoice":"in_1","lines":[{"type":"custom_line_item","description":"Goodwill"}]}`, wantErr: "lines[0]: description and unit_amount"},
+		{name: "unknown line type", body: `{"invoice":"in_1","lines":[{"type":"invoice_line_item","invoice_line_item":"il_1"},{"type":"discount"}]}`, wantErr: "lines[1]: type must be"},
+		{name: "unknown reason", body: `{"invoice":"in_1","amount":500,"reason":"changed_mind"}`, wantErr: "reason must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/stripe/1/credit-notes", strings.NewReader(tt.body))
+			input, ok := decodeCreditNoteInput(rec, r)
+			if tt.wantErr == "" {
+				if !ok || input.Invoice != "in_1" {
+					t.Errorf("decodeCreditNoteInput = %+v, %v; response %d %s", input, ok, rec.Code, rec.Body.String())
+				}
+				return
+			}
+			if ok {
+				t.Fatalf("decodeCreditNoteInput accepted %s", tt.body)
+			}
+			if rec.Code != http.StatusBadRequest || !strings.Contains(errorMessage(t, rec), tt.wantErr) {
+				t.Errorf("response = %d %s, want 400 containing %q", rec.Code, rec.Body.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttachedPaymentMethodJSON(t *testing.T) {
+	paymentMethod := &stripe.PaymentMethod{ID: "pm_1", Type: "card", Customer: "cus_1"}
+	tests := []struct {
+		name   string
+		result attachedPaymentMethod
+		want   string
+	}{
+		{"default set", attachedPaymentMethod{PaymentMethod: paymentMethod, DefaultSet: true}, `"default_set":true`},
+		{"default not asked for", attachedPaymentMethod{PaymentMethod: paymentMethod}, `"default_set":false`},
+		{
+			"attached but default failed",
+			attachedPaymentMethod{PaymentMethod: paymentMethod, DefaultError: "No such customer"},
+			`"default_set":false,"default_error":"No such customer"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The payment method's own fields stay at the top level
+			if !strings.Contains(string(data), `"id":"pm_1"`) || !strings.Contains(string(data), `"customer":"cus_1"`) ||
+				!strings.Contains(string(data), tt.want) {
+				t.Errorf("json = %s, want the payment method and %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/syncer"
+)
+
+// Sync handlers
+
+func (s *Server) handleStartSync(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	provider, err := s.getProvider(id)
+	if err != nil {
+		respondConnectionError(w, err)
+		return
+	}
+
+	// ?full=true ignores the stored cursors and refetches everything
+	full, _ := strconv.ParseBool(r.URL.Query().Get("full"))
+
+	run, err := s.syncer.Start(id, provider, full)
+	if errors.Is(err, syncer.ErrSyncInProgress) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, run)
+}
+
+func (s *Server) handleGetSyncStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	run, err := s.syncer.LatestRun(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if run == nil {
+		respondError(w, http.StatusNotFound, "Connection has not been synced")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, run)
+}
@@ -13,6 +13,7 @@ import (
 func respondZuoraAPIError(w http.ResponseWriter, err error) {
 	var apiErr *zuora.APIError
 	if errors.As(err, &apiErr) {
+		noteUpstreamStatus(w, apiErr.StatusCode)
 		statusCode := apiErr.StatusCode
 		if statusCode < 400 || statusCode >= 600 {
 			statusCode = http.StatusBadGateway
@@ -150,6 +151,29 @@ func invoiceToFrontend(invoice zuora.Invoice) InvoiceFromZuora {
 	return result
 }
 
+// zuoraAccountsToCustomers converts accounts to the frontend format, sorted by organization name
+func zuoraAccountsToCustomers(accounts []zuora.Account) []CustomerFromZuora {
+	customers := make([]CustomerFromZuora, len(accounts))
+	for i, account := range accounts {
+		customers[i] = accountToCustomer(account)
+	}
+
+	// Sort by organization name (ascending)
+	sort.Slice(customers, func(i, j int) bool {
+		return customers[i].Organization < customers[j].Organization
+	})
+	return customers
+}
+
+// zuoraSubscriptionsToFrontend converts subscriptions to the frontend format
+func zuoraSubscriptionsToFrontend(subscriptions []zuora.Subscription) []SubscriptionFromZuora {
+	result := make([]SubscriptionFromZuora, len(subscriptions))
+	for i, sub := range subscriptions {
+		result[i] = subscriptionToFrontend(sub)
+	}
+	return result
+}
+
 // Handlers
 
 func (s *Server) handleZuoraListAccounts(w http.ResponseWriter, r *http.Request) {
@@ -159,9 +183,23 @@ func (s *Server) handleZuoraListAccounts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Serve from the local cache when the last sync is recent enough
+	cache := s.cacheFor(r, connectionID)
+	setCacheHeaders(w, cache)
+	if cache.fresh {
+		cached, _, err := loadCached[zuora.Account](s, "cached_customers", connectionID,
+			numberedPage(r, "page", "page_size", zuora.DefaultPageSize))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, zuoraAccountsToCustomers(cached))
 		return
 	}
 
@@ -174,18 +212,7 @@ func (s *Server) handleZuoraListAccounts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Convert to frontend format
-	customers := make([]CustomerFromZuora, len(accounts))
-	for i, account := range accounts {
-		customers[i] = accountToCustomer(account)
-	}
-
-	// Sort by organization name (ascending)
-	sort.Slice(customers, func(i, j int) bool {
-		return customers[i].Organization < customers[j].Organization
-	})
-
-	respondJSON(w, http.StatusOK, customers)
+	respondJSON(w, http.StatusOK, zuoraAccountsToCustomers(accounts))
 }
 
 func (s *Server) handleZuoraGetAccount(w http.ResponseWriter, r *http.Request) {
@@ -201,9 +228,9 @@ func (s *Server) handleZuoraGetAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -223,9 +250,23 @@ func (s *Server) handleZuoraListSubscriptions(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
+		return
+	}
+
+	// Serve from the local cache when the last sync is recent enough
+	cache := s.cacheFor(r, connectionID)
+	setCacheHeaders(w, cache)
+	if cache.fresh {
+		cached, _, err := loadCached[zuora.Subscription](s, "cached_subscriptions", connectionID,
+			numberedPage(r, "page", "page_size", zuora.DefaultPageSize))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, zuoraSubscriptionsToFrontend(cached))
 		return
 	}
 
@@ -238,13 +279,7 @@ func (s *Server) handleZuoraListSubscriptions(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Convert to frontend format
-	result := make([]SubscriptionFromZuora, len(subscriptions))
-	for i, sub := range subscriptions {
-		result[i] = subscriptionToFrontend(sub)
-	}
-
-	respondJSON(w, http.StatusOK, result)
+	respondJSON(w, http.StatusOK, zuoraSubscriptionsToFrontend(subscriptions))
 }
 
 func (s *Server) handleZuoraGetSubscription(w http.ResponseWriter, r *http.Request) {
@@ -260,9 +295,9 @@ func (s *Server) handleZuoraGetSubscription(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -282,9 +317,9 @@ func (s *Server) handleZuoraListProducts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -319,9 +354,9 @@ func (s *Server) handleZuoraGetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -347,9 +382,9 @@ func (s *Server) handleZuoraListProductRatePlans(w http.ResponseWriter, r *http.
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -375,9 +410,9 @@ func (s *Server) handleZuoraListInvoices(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
@@ -430,9 +465,9 @@ func (s *Server) handleZuoraListPayments(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	client, err := s.getZuoraClient(connectionID)
+	client, err := s.getZuoraClient(r, connectionID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondConnectionError(w, err)
 		return
 	}
 
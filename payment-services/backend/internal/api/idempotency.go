@@ -0,0 +1,344 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/db"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/stripe"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	replayedHeader    = "Idempotent-Replayed"
+)
+
+// idempotencyKeyTTL is how long a key's response is kept, matching Stripe
+const idempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyLease is how long a request holds its key while it runs. If the
+// process dies mid-request the key stays in progress; once the lease has
+// passed, a retry of the same request takes the key over.
+const idempotencyLease = 5 * time.Minute
+
+// maxIdempotencyKeyLength is the longest key Stripe accepts
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentResponse is the largest response stored for replay; larger
+// ones are not stored, leaving Stripe's own idempotency to catch retries
+const maxIdempotentResponse = 1 << 20
+
+type idempotencyContextKey struct{}
+
+// idempotencyKey returns the idempotency key of a Stripe write: the
+// Idempotency-Key it was sent with, or one generated for this call
+func idempotencyKey(r *http.Request) string {
+	key, _ := r.Context().Value(idempotencyContextKey{}).(string)
+	return key
+}
+
+func withIdempotencyKey(r *http.Request, key string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), idempotencyContextKey{}, key))
+}
+
+// isIdempotentRoute reports whether a route may write to Stripe: a write
+// that passes through to the billing platform, whether under /api/stripe/
+// or a platform-agnostic route such as POST /api/connections/{id}/customers
+func isIdempotentRoute(pattern string) bool {
+	method, path, _ := strings.Cut(pattern, " ")
+	return isWriteMethod(method) && !readOnlyPosts[pattern] && isProxyRoute(path)
+}
+
+// idempotencyClaim identifies a request's hold on a key. lockedAt changes
+// when another request takes the key over, so a request that lost its hold
+// cannot complete or release the key under the new holder.
+type idempotencyClaim struct {
+	id       int64
+	lockedAt time.Time
+}
+
+// idempotencyRecord is a stored key; status is nil while its request runs
+type idempotencyRecord struct {
+	userID      *int64
+	requestHash string
+	status      *int
+	contentType *string
+	body        []byte
+}
+
+// idempotencyStore keeps Idempotency-Keys and the responses they produced
+type idempotencyStore interface {
+	// connectionPlatform returns a connection's platform, or
+	// errConnectionNotFound
+	connectionPlatform(ctx context.Context, connectionID int64) (models.PlatformType, error)
+
+	// claim takes key for a request. A key still in progress after its lease
+	// is taken over by the same user sending the same request. ok is false
+	// when the key is held, whether completed or in progress.
+	claim(ctx context.Context, connectionID int64, key string, userID *int64, requestHash string) (claim idempotencyClaim, ok bool, err error)
+
+	// lookup returns the stored key, or nil if there is none
+	lookup(ctx context.Context, connectionID int64, key string) (*idempotencyRecord, error)
+
+	// complete stores the response for replay; release drops the key so the
+	// request can be retried with it
+	complete(ctx context.Context, claim idempotencyClaim, status int, contentType string, body []byte) error
+	release(ctx context.Context, claim idempotencyClaim) error
+}
+
+// idempotentWriter records the response so it can be stored for replay
+type idempotentWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *idempotentWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotentWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body.Len()+len(b) > maxIdempotentResponse {
+		w.overflow = true
+	} else {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets noteUpstreamStatus reach the audit writer underneath
+func (w *idempotentWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// idempotent makes writes to Stripe connections safe to retry. A write sent
+// with an Idempotency-Key header runs once and, if it succeeds, its response
+// is stored; a retry with the same key and request gets that response back
+// with Idempotent-Replayed: true. Reusing a key for a different request is
+// rejected, as is a retry while the first request is still running. Failed
+// requests are not stored so they can be retried with the same key. A write
+// sent without a key gets one generated for the call. Either way the key is
+// passed on to Stripe; see getStripeWriteClient.
+func (s *Server) idempotent(pattern string, access routeAccess, next http.Handler) http.Handler {
+	if !isIdempotentRoute(pattern) {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if len(key) > maxIdempotencyKeyLength {
+			respondError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+		connectionID, err := strconv.ParseInt(r.PathValue(access.idParam), 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid connection ID")
+			return
+		}
+
+		ctx := context.Background()
+
+		// Only Stripe takes idempotency keys; the handler reports a missing connection
+		platform, err := s.idempotency.connectionPlatform(ctx, connectionID)
+		if errors.Is(err, errConnectionNotFound) || (err == nil && platform != models.PlatformStripe) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if key == "" {
+			// One key for the whole call, so the client's own retries of
+			// each write it makes reuse that write's key
+			next.ServeHTTP(w, withIdempotencyKey(r, stripe.NewIdempotencyKey()))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := idempotencyRequestHash(r.Method, r.URL.Path, body)
+
+		var userID *int64
+		if user, ok := userFromContext(r.Context()); ok {
+			userID = &user.ID
+		}
+
+		claim, ok, err := s.idempotency.claim(ctx, connectionID, key, userID, requestHash)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !ok {
+			s.replayIdempotent(w, connectionID, key, userID, requestHash)
+			return
+		}
+
+		iw := &idempotentWriter{ResponseWriter: w}
+		next.ServeHTTP(iw, withIdempotencyKey(r, key))
+
+		if iw.status >= 200 && iw.status < 300 && !iw.overflow {
+			err = s.idempotency.complete(ctx, claim, iw.status, iw.Header().Get("Content-Type"), iw.body.Bytes())
+		} else {
+			err = s.idempotency.release(ctx, claim)
+		}
+		if err != nil {
+			log.Printf("idempotency: failed to record key %q for connection %d: %v", key, connectionID, err)
+		}
+	})
+}
+
+// idempotencyRequestHash identifies the request a key was first used for
+func idempotencyRequestHash(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replayIdempotent answers a request whose key is already taken
+func (s *Server) replayIdempotent(w http.ResponseWriter, connectionID int64, key string, userID *int64, requestHash string) {
+	stored, err := s.idempotency.lookup(context.Background(), connectionID, key)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if stored == nil {
+		// The first request failed and released the key between our claim and this read
+		respondError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	sameUser := (stored.userID == nil && userID == nil) ||
+		(stored.userID != nil && userID != nil && *stored.userID == *userID)
+	if !sameUser || stored.requestHash != requestHash {
+		respondError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+		return
+	}
+	if stored.status == nil {
+		respondError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	if stored.contentType != nil {
+		w.Header().Set("Content-Type", *stored.contentType)
+	}
+	w.Header().Set(replayedHeader, "true")
+	w.WriteHeader(*stored.status)
+	w.Write(stored.body)
+}
+
+// pgIdempotencyStore keeps keys in the idempotency_keys table
+type pgIdempotencyStore struct {
+	db *db.DB
+}
+
+func (p pgIdempotencyStore) connectionPlatform(ctx context.Context, connectionID int64) (models.PlatformType, error) {
+	var platformType string
+	err := p.db.Pool().QueryRow(ctx, `
+		SELECT platform_type FROM platform_connections WHERE id = $1
+	`, connectionID).Scan(&platformType)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errConnectionNotFound
+	}
+	return models.PlatformType(platformType), err
+}
+
+func (p pgIdempotencyStore) claim(ctx context.Context, connectionID int64, key string, userID *int64, requestHash string) (idempotencyClaim, bool, error) {
+	// Forget expired keys, then claim this one
+	if _, err := p.db.Pool().Exec(ctx, `
+		DELETE FROM idempotency_keys WHERE created_at < $1
+	`, time.Now().Add(-idempotencyKeyTTL)); err != nil {
+		return idempotencyClaim{}, false, err
+	}
+
+	var claim idempotencyClaim
+	err := p.db.Pool().QueryRow(ctx, `
+		INSERT INTO idempotency_keys (connection_id, idempotency_key, user_id, request_hash)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (connection_id, idempotency_key) DO UPDATE SET locked_at = NOW()
+		WHERE idempotency_keys.status_code IS NULL AND idempotency_keys.locked_at < $5
+		  AND idempotency_keys.request_hash = EXCLUDED.request_hash
+		  AND idempotency_keys.user_id IS NOT DISTINCT FROM EXCLUDED.user_id
+		RETURNING id, locked_at
+	`, connectionID, key, userID, requestHash, time.Now().Add(-idempotencyLease)).Scan(&claim.id, &claim.lockedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return idempotencyClaim{}, false, nil
+	}
+	if err != nil {
+		return idempotencyClaim{}, false, err
+	}
+	return claim, true, nil
+}
+
+func (p pgIdempotencyStore) lookup(ctx context.Context, connectionID int64, key string) (*idempotencyRecord, error) {
+	var stored idempotencyRecord
+	err := p.db.Pool().QueryRow(ctx, `
+		SELECT user_id, request_hash, status_code, content_type, response_body
+		FROM idempotency_keys WHERE connection_id = $1 AND idempotency_key = $2
+	`, connectionID, key).Scan(&stored.userID, &stored.requestHash, &stored.status, &stored.contentType, &stored.body)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (p pgIdempotencyStore) complete(ctx context.Context, claim idempotencyClaim, status int, contentType string, body []byte) error {
+	_, err := p.db.Pool().Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = NULLIF($4, ''), response_body = $5, completed_at = NOW()
+		WHERE id = $1 AND locked_at = $2
+	`, claim.id, claim.lockedAt, status, contentType, body)
+	return err
+}
+
+func (p pgIdempotencyStore) release(ctx context.Context, claim idempotencyClaim) error {
+	_, err := p.db.Pool().Exec(ctx, `
+		DELETE FROM idempotency_keys WHERE id = $1 AND locked_at = $2
+	`, claim.id, claim.lockedAt)
+	return err
+}
+
+// getStripeWriteClient returns the Stripe client for a write, sending the
+// request's idempotency key to Stripe
+func (s *Server) getStripeWriteClient(r *http.Request, connectionID int64) (*stripe.Client, error) {
+	client, err := s.getStripeClient(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if key := idempotencyKey(r); key != "" {
+		client = client.WithIdempotencyKey(key)
+	}
+	if observe := upstreamObserver(r); observe != nil {
+		client = client.WithResponseObserver(observe)
+	}
+	return client, nil
+}
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// memIdempotencyStore is an in-memory idempotencyStore
+type memIdempotencyStore struct {
+	mu        sync.Mutex
+	platforms map[int64]models.PlatformType
+	keys      map[string]*memIdempotencyKey
+	nextID    int64
+}
+
+type memIdempotencyKey struct {
+	idempotencyRecord
+	id       int64
+	lockedAt time.Time
+}
+
+func memKey(connectionID int64, key string) string {
+	return fmt.Sprintf("%d/%s", connectionID, key)
+}
+
+func newMemIdempotencyStore(platforms map[int64]models.PlatformType) *memIdempotencyStore {
+	return &memIdempotencyStore{platforms: platforms, keys: make(map[string]*memIdempotencyKey)}
+}
+
+func (m *memIdempotencyStore) connectionPlatform(ctx context.Context, connectionID int64) (models.PlatformType, error) {
+	platform, ok := m.platforms[connectionID]
+	if !ok {
+		return "", errConnectionNotFound
+	}
+	return platform, nil
+}
+
+func (m *memIdempotencyStore) claim(ctx context.Context, connectionID int64, key string, userID *int64, requestHash string) (idempotencyClaim, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mapKey := memKey(connectionID, key)
+	if stored, ok := m.keys[mapKey]; ok {
+		sameUser := (stored.userID == nil) == (userID == nil) && (userID == nil || *stored.userID == *userID)
+		if stored.status != nil || time.Since(stored.lockedAt) < idempotencyLease || stored.requestHash != requestHash || !sameUser {
+			return idempotencyClaim{}, false, nil
+		}
+		stored.lockedAt = time.Now()
+		return idempotencyClaim{id: stored.id, lockedAt: stored.lockedAt}, true, nil
+	}
+	m.nextID++
+	stored := &memIdempotencyKey{
+		idempotencyRecord: idempotencyRecord{userID: userID, requestHash: requestHash},
+		id:                m.nextID,
+		lockedAt:          time.Now(),
+	}
+	m.keys[mapKey] = stored
+	return idempotencyClaim{id: stored.id, lockedAt: stored.lockedAt}, true, nil
+}
+
+func (m *memIdempotencyStore) lookup(ctx context.Context, connectionID int64, key string) (*idempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.keys[memKey(connectionID, key)]
+	if !ok {
+		return nil, nil
+	}
+	record := stored.idempotencyRecord
+	return &record, nil
+}
+
+func (m *memIdempotencyStore) find(claim idempotencyClaim) (string, *memIdempotencyKey) {
+	for mapKey, stored := range m.keys {
+		if stored.id == claim.id && stored.lockedAt.Equal(claim.lockedAt) {
+			return mapKey, stored
+		}
+	}
+	return "", nil
+}
+
+func (m *memIdempotencyStore) complete(ctx context.Context, claim idempotencyClaim, status int, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, stored := m.find(claim); stored != nil {
+		stored.status, stored.contentType, stored.body = &status, &contentType, body
+	}
+	return nil
+}
+
+func (m *memIdempotencyStore) release(ctx context.Context, claim idempotencyClaim) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mapKey, stored := m.find(claim); stored != nil {
+		delete(m.keys, mapKey)
+	}
+	return nil
+}
+
+const (
+	stripeConnection = 1
+	maxioConnection  = 2
+)
+
+// newIdempotentHandler wraps next in the idempotency middleware for pattern,
+// backed by an in-memory store with a Stripe and a Maxio connection
+func newIdempotentHandler(pattern string, next http.HandlerFunc) http.Handler {
+	s := &Server{idempotency: newMemIdempotencyStore(map[int64]models.PlatformType{
+		stripeConnection: models.PlatformStripe,
+		maxioConnection:  models.PlatformMaxio,
+	})}
+	return s.idempotent(pattern, accessFor(pattern), next)
+}
+
+// idempotentRequest builds a request as the mux would route it to pattern
+func idempotentRequest(connectionID, key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/stripe/"+connectionID+"/subscriptions", strings.NewReader(body))
+	r.SetPathValue("connectionId", connectionID)
+	r.SetPathValue("id", connectionID)
+	if key != "" {
+		r.Header.Set(idempotencyHeader, key)
+	}
+	return r
+}
+
+func TestIsIdempotentRoute(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"POST /api/stripe/{connectionId}/subscriptions", true},
+		{"DELETE /api/stripe/{connectionId}/coupons/{couponId}", true},
+		{"POST /api/connections/{id}/customers", true},
+		{"GET /api/stripe/{connectionId}/subscriptions", false},
+		{"POST /api/stripe/{connectionId}/credit-notes/preview", false},
+		{"POST /api/connections/{id}/sync", false},
+		{"POST /api/connections/{id}/confirmations", false},
+		{"PUT /api/connections/{id}", false},
+	}
+	for _, tt := range tests {
+		if got := isIdempotentRoute(tt.pattern); got != tt.want {
+			t.Errorf("isIdempotentRoute(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestIdempotentReplaysCompletedRequest(t *testing.T) {
+	var calls atomic.Int32
+	handler := newIdempotentHandler("POST /api/stripe/{connectionId}/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		if key := idempotencyKey(r); key != "key-1" {
+			t.Errorf("handler saw idempotency key %q, want key-1", key)
+		}
+		calls.Add(1)
+		respondJSON(w, http.StatusCreated, map[string]string{"id": "sub_1"})
+	})
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("1", "key-1", `{"price":"price_1"}`))
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("1", "key-1", `{"price":"price_1"}`))
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", retry.Code, retry.Body.String(), first.Code, first.Body.String())
+	}
+	if retry.Header().Get(replayedHeader) != "true" || first.Header().Get(replayedHeader) != "" {
+		t.Errorf("%s = %q on the replay and %q on the original", replayedHeader,
+			retry.Header().Get(replayedHeader), first.Header().Get(replayedHeader))
+	}
+	if retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("replayed Content-Type = %q", retry.Header().Get("Content-Type"))
+	}
+}
+
+func TestIdempotentRejectsDifferentRequestWithSameKey(t *testing.T) {
+	var calls atomic.Int32
+	handler := newIdempotentHandler("POST /api/stripe/{connectionId}/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		respondJSON(w, http.StatusCreated, map[string]string{"id": "sub_1"})
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("1", "key-1", `{"price":"price_1"}`))
+	reused := httptest.NewRecorder()
+	handler.ServeHTTP(reused, idempotentRequest("1", "key-1", `{"price":"price_2"}`))
+
+	if reused.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body with the same key = %d, want 422", reused.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+}
+
+func TestIdempotentReleasesFailedRequest(t *testing.T) {
+	var calls atomic.Int32
+	handler := newIdempotentHandler("POST /api/stripe/{connectionId}/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			respondError(w, http.StatusBadGateway, "Stripe timed out")
+			return
+		}
+		respondJSON(w, http.StatusCreated, map[string]string{"id": "sub_1"})
+	})
+
+	failed := httptest.NewRecorder()
+	handler.ServeHTTP(failed, idempotentRequest("1", "key-1", `{}`))
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("1", "key-1", `{}`))
+
+	if failed.Code != http.StatusBadGateway || retry.Code != http.StatusCreated || calls.Load() != 2 {
+		t.Errorf("failure then retry = %d, %d with %d calls; want 502, 201 with 2", failed.Code, retry.Code, calls.Load())
+	}
+}
+
+func TestIdempotentRejectsConcurrentRequest(t *testing.T) {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	var calls atomic.Int32
+	handler := newIdempotentHandler("POST /api/stripe/{connectionId}/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		close(started)
+		<-finish
+		respondJSON(w, http.StatusCreated, map[string]string{"id": "sub_1"})
+	})
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(first, idempotentRequest("1", "key-1", `{}`))
+		close(done)
+	}()
+	<-started
+
+	// Retries while the first request runs are turned away
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, idempotentRequest("1", "key-1", `{}`))
+			codes[i] = rec.Code
+		}()
+	}
+	wg.Wait()
+	for _, code := range codes {
+		if code != http.StatusConflict {
+			t.Errorf("concurrent retry = %d, want 409", code)
+		}
+	}
+
+	close(finish)
+	<-done
+	after := httptest.NewRecorder()
+	handler.ServeHTTP(after, idempotentRequest("1", "key-1", `{}`))
+
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+	if first.Code != http.StatusCreated || after.Code != http.StatusCreated || after.Header().Get(replayedHeader) != "true" {
+		t.Errorf("original = %d, retry after it = %d (replayed %q)", first.Code, after.Code, after.Header().Get(replayedHeader))
+	}
+}
+
+func TestIdempotentTakesOverExpiredLease(t *testing.T) {
+	store := newMemIdempotencyStore(map[int64]models.PlatformType{stripeConnection: models.PlatformStripe})
+	var calls atomic.Int32
+	s := &Server{idempotency: store}
+	pattern := "POST /api/stripe/{connectionId}/subscriptions"
+	handler := s.idempotent(pattern, accessFor(pattern), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		respondJSON(w, http.StatusCreated, map[string]string{"id": "sub_1"})
+	}))
+
+	// A request that claimed the key and never finished
+	hash := idempotencyRequestHash(http.MethodPost, "/api/stripe/1/subscriptions", []byte(`{}`))
+	abandoned, _, _ := store.claim(context.Background(), stripeConnection, "key-1", nil, hash)
+	store.keys[memKey(stripeConnection, "key-1")].lockedAt = time.Now().Add(-idempotencyLease - time.Second)
+
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("1", "key-1", `{}`))
+	if retry.Code != http.StatusCreated || calls.Load() != 1 {
+		t.Fatalf("retry after the lease = %d with %d calls, want 201 with 1", retry.Code, calls.Load())
+	}
+
+	// The abandoned request can no longer release the key it lost
+	store.release(context.Background(), abandoned)
+	replay := httptest.NewRecorder()
+	handler.ServeHTTP(replay, idempotentRequest("1", "key-1", `{}`))
+	if replay.Header().Get(replayedHeader) != "true" || calls.Load() != 1 {
+		t.Errorf("key released by the request that lost it: replayed %q with %d calls", replay.Header().Get(replayedHeader), calls.Load())
+	}
+}
+
+func TestIdempotentGeneratesKeyPerCall(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	handler := newIdempotentHandler("POST /api/connections/{id}/customers", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, idempotencyKey(r))
+		mu.Unlock()
+		respondJSON(w, http.StatusCreated, map[string]string{"id": "cus_1"})
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("1", "", `{"email":"jo@example.com"}`))
+		if rec.Code != http.StatusCreated || rec.Header().Get(replayedHeader) != "" {
+			t.Errorf("call %d without a key = %d (replayed %q)", i, rec.Code, rec.Header().Get(replayedHeader))
+		}
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[1] == "" || keys[0] == keys[1] {
+		t.Errorf("generated keys = %q, want two distinct keys", keys)
+	}
+}
+
+func TestIdempotentSkipsOtherPlatforms(t *testing.T) {
+	var calls atomic.Int32
+	handler := newIdempotentHandler("POST /api/connections/{id}/customers", func(w http.ResponseWriter, r *http.Request) {
+		if key := idempotencyKey(r); key != "" {
+			t.Errorf("non-Stripe write got idempotency key %q", key)
+		}
+		calls.Add(1)
+		respondJSON(w, http.StatusCreated, map[string]string{"id": "c_1"})
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("2", "key-1", `{}`))
+		if rec.Header().Get(replayedHeader) != "" {
+			t.Error("non-Stripe write was replayed")
+		}
+	}
+	// Unknown connections are left to the handler to report
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("99", "key-1", `{}`))
+	if calls.Load() != 3 {
+		t.Errorf("handler ran %d times, want 3", calls.Load())
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxPreferenceSize caps the stored JSON for any one preference
+const maxPreferenceSize = 64 << 10
+
+// preferenceSchemas lists the preference keys that may be stored, with a
+// check for each key's value
+var preferenceSchemas = map[string]func(json.RawMessage) error{
+	// IDs of the tree nodes the user has expanded
+	"expanded_nodes": func(raw json.RawMessage) error {
+		var nodes []string
+		if err := json.Unmarshal(raw, &nodes); err != nil {
+			return errors.New("must be an array of node IDs")
+		}
+		if len(nodes) > 1000 {
+			return errors.New("must have at most 1000 node IDs")
+		}
+		for _, node := range nodes {
+			if len(node) > 200 {
+				return errors.New("node IDs must be at most 200 characters")
+			}
+		}
+		return nil
+	},
+	// Platform type last selected in the tree, or null
+	"selected_platform": func(raw json.RawMessage) error {
+		var platform *string
+		if err := json.Unmarshal(raw, &platform); err != nil {
+			return errors.New("must be a platform type or null")
+		}
+		if platform != nil && len(*platform) > 50 {
+			return errors.New("must be at most 50 characters")
+		}
+		return nil
+	},
+	// Width of the tree panel in pixels
+	"tree_panel_width": func(raw json.RawMessage) error {
+		var width int
+		if err := json.Unmarshal(raw, &width); err != nil {
+			return errors.New("must be a whole number of pixels")
+		}
+		if width < 120 || width > 1200 {
+			return errors.New("must be between 120 and 1200")
+		}
+		return nil
+	},
+}
+
+// validatePreference checks a preference key is allowed and its value fits the key's schema
+func validatePreference(key string, value json.RawMessage) error {
+	validate, ok := preferenceSchemas[key]
+	if !ok {
+		return fmt.Errorf("unknown preference %q", key)
+	}
+	if len(value) > maxPreferenceSize {
+		return fmt.Errorf("%s is too large", key)
+	}
+	if err := validate(value); err != nil {
+		return fmt.Errorf("%s %w", key, err)
+	}
+	return nil
+}
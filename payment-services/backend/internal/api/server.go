@@ -1,39 +1,123 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sync/atomic"
+	"time"
 
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/auth"
 	"github.com/davealexenglish/payment-billing-hub/backend/internal/db"
-	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/maxio"
-	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/stripe"
-	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/zuora"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/secrets"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/syncer"
 )
 
 // Server holds the API server state
 type Server struct {
-	db            *db.DB
-	maxioClients  map[int64]*maxio.Client  // connection_id -> client
-	stripeClients map[int64]*stripe.Client // connection_id -> client
-	zuoraClients  map[int64]*zuora.Client  // connection_id -> client
+	db        *db.DB
+	providers *platforms.ProviderCache // connection_id + config_version -> provider
+	syncer    *syncer.Engine
+	cacheTTL  time.Duration     // how long synced data may be served from the cache
+	keyring   *secrets.Keyring  // encrypts stored credentials; nil stores them in plaintext
+	resolver  *secrets.Resolver // resolves credentials stored by reference; never nil
+	auth      *auth.Store
+
+	idempotency idempotencyStore // Idempotency-Keys of Stripe writes and their responses
+
+	allowedOrigins []string // origins allowed to make CORS requests
+
+	draining atomic.Bool // set once shutdown starts; readiness then fails
 }
 
+// DefaultAllowedOrigins allows the Vite dev server used during development
+var DefaultAllowedOrigins = []string{"http://localhost:5173"}
+
 // NewServer creates a new API server
 func NewServer(database *db.DB) *Server {
 	return &Server{
-		db:            database,
-		maxioClients:  make(map[int64]*maxio.Client),
-		stripeClients: make(map[int64]*stripe.Client),
-		zuoraClients:  make(map[int64]*zuora.Client),
+		db:        database,
+		providers: platforms.NewProviderCache(),
+		syncer:    syncer.NewEngine(database),
+		cacheTTL:  DefaultCacheTTL,
+		resolver:  secrets.NewResolver(),
+		auth:      auth.NewStore(database),
+
+		idempotency:    pgIdempotencyStore{db: database},
+		allowedOrigins: DefaultAllowedOrigins,
+	}
+}
+
+// SetCacheTTL sets how long after a sync list endpoints may be served from the
+// cache. A zero or negative TTL disables the cache.
+func (s *Server) SetCacheTTL(ttl time.Duration) {
+	s.cacheTTL = ttl
+}
+
+// SetKeyring sets the keyring used to encrypt credentials when they are
+// saved and to decrypt them when a provider is created. Without one,
+// credentials are stored in plaintext.
+func (s *Server) SetKeyring(keyring *secrets.Keyring) {
+	s.keyring = keyring
+}
+
+// SetSecretResolver sets the resolver for credentials that connections store
+// as secret references. Until it is called, or if resolver is nil, the
+// server has a resolver with no backends and references are rejected.
+func (s *Server) SetSecretResolver(resolver *secrets.Resolver) {
+	if resolver == nil {
+		resolver = secrets.NewResolver()
 	}
+	s.resolver = resolver
+}
+
+// SetAllowedOrigins sets the origins allowed to make CORS requests, such as
+// "https://billing.example.com". Origins must match exactly.
+func (s *Server) SetAllowedOrigins(origins []string) {
+	s.allowedOrigins = origins
+}
+
+// BeginShutdown marks the server as shutting down. Readiness checks fail from
+// then on so load balancers stop sending new requests while in-flight ones
+// drain.
+func (s *Server) BeginShutdown() {
+	s.draining.Store(true)
+}
+
+// StartSyncScheduler syncs every connection in the background whenever its
+// last sync is older than interval, until ctx is cancelled or StopSyncs is
+// called
+func (s *Server) StartSyncScheduler(ctx context.Context, interval time.Duration) {
+	go s.syncer.RunScheduler(ctx, interval, s.getProvider)
+}
+
+// StopSyncs cancels the syncs in progress and waits for them to record their
+// outcome, or for ctx to expire
+func (s *Server) StopSyncs(ctx context.Context) error {
+	return s.syncer.Stop(ctx)
+}
+
+// Auth returns the user and session store
+func (s *Server) Auth() *auth.Store {
+	return s.auth
 }
 
 // Router returns the HTTP router with all routes configured
 func (s *Server) Router() http.Handler {
-	mux := http.NewServeMux()
+	// Every route is registered behind the role check and audit log for its pattern
+	mux := routeMux{ServeMux: http.NewServeMux(), s: s}
 
-	// Health check
+	// Health checks: /health/live reports the process is up, /health/ready
+	// that it can serve requests
 	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /health/live", s.handleHealth)
+	mux.HandleFunc("GET /health/ready", s.handleReady)
+
+	// Authentication
+	mux.HandleFunc("POST /api/auth/login", s.handleLogin)
+	mux.HandleFunc("POST /api/auth/logout", s.handleLogout)
+	mux.HandleFunc("GET /api/auth/me", s.handleGetCurrentUser)
 
 	// Platform Connections
 	mux.HandleFunc("GET /api/connections", s.handleListConnections)
@@ -42,10 +126,32 @@ func (s *Server) Router() http.Handler {
 	mux.HandleFunc("PUT /api/connections/{id}", s.handleUpdateConnection)
 	mux.HandleFunc("DELETE /api/connections/{id}", s.handleDeleteConnection)
 	mux.HandleFunc("POST /api/connections/{id}/test", s.handleTestConnection)
+	mux.HandleFunc("POST /api/connections/{id}/sync", s.handleStartSync)
+	mux.HandleFunc("GET /api/connections/{id}/sync", s.handleGetSyncStatus)
+	mux.HandleFunc("POST /api/connections/{id}/confirmations", s.handleCreateConfirmation)
+	mux.HandleFunc("GET /api/connections/{id}/roles", s.handleListConnectionRoles)
+	mux.HandleFunc("PUT /api/connections/{id}/roles/{userId}", s.handleGrantConnectionRole)
+	mux.HandleFunc("DELETE /api/connections/{id}/roles/{userId}", s.handleRevokeConnectionRole)
+
+	// Platform-agnostic endpoints returning normalized models
+	mux.HandleFunc("GET /api/connections/{id}/customers", s.handleListCustomers)
+	mux.HandleFunc("POST /api/connections/{id}/customers", s.handleCreateCustomer)
+	mux.HandleFunc("GET /api/connections/{id}/customers/{customerId}", s.handleGetCustomer)
+	mux.HandleFunc("GET /api/connections/{id}/subscriptions", s.handleListSubscriptions)
+	mux.HandleFunc("GET /api/connections/{id}/subscriptions/{subscriptionId}", s.handleGetSubscription)
+	mux.HandleFunc("GET /api/connections/{id}/invoices", s.handleListInvoices)
+	mux.HandleFunc("GET /api/connections/{id}/payments", s.handleListPayments)
+	mux.HandleFunc("GET /api/connections/{id}/products", s.handleListProducts)
+
+	// Audit log
+	mux.HandleFunc("GET /api/audit", s.handleListAuditEvents)
 
 	// Tree structure
 	mux.HandleFunc("GET /api/tree", s.handleGetTree)
 
+	// Registered billing platforms
+	mux.HandleFunc("GET /api/platforms", s.handleListPlatforms)
+
 	// Maxio-specific endpoints
 	mux.HandleFunc("GET /api/maxio/{connectionId}/customers", s.handleMaxioListCustomers)
 	mux.HandleFunc("POST /api/maxio/{connectionId}/customers", s.handleMaxioCreateCustomer)
@@ -80,6 +186,14 @@ func (s *Server) Router() http.Handler {
 	mux.HandleFunc("POST /api/stripe/{connectionId}/customers", s.handleStripeCreateCustomer)
 	mux.HandleFunc("GET /api/stripe/{connectionId}/customers/{customerId}", s.handleStripeGetCustomer)
 	mux.HandleFunc("PUT /api/stripe/{connectionId}/customers/{customerId}", s.handleStripeUpdateCustomer)
+	mux.HandleFunc("GET /api/stripe/{connectionId}/customers/{customerId}/payment-methods", s.handleStripeListPaymentMethods)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/customers/{customerId}/payment-methods", s.handleStripeAttachPaymentMethod)
+	mux.HandleFunc("DELETE /api/stripe/{connectionId}/customers/{customerId}/payment-methods/{paymentMethodId}", s.handleStripeDetachPaymentMethod)
+	mux.HandleFunc("PUT /api/stripe/{connectionId}/customers/{customerId}/default-payment-method", s.handleStripeSetDefaultPaymentMethod)
+	mux.HandleFunc("GET /api/stripe/{connectionId}/customers/{customerId}/setup-intents", s.handleStripeListSetupIntents)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/customers/{customerId}/setup-intents", s.handleStripeCreateSetupIntent)
+	mux.HandleFunc("GET /api/stripe/{connectionId}/setup-intents/{setupIntentId}", s.handleStripeGetSetupIntent)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/setup-intents/{setupIntentId}/cancel", s.handleStripeCancelSetupIntent)
 	mux.HandleFunc("GET /api/stripe/{connectionId}/subscriptions", s.handleStripeListSubscriptions)
 	mux.HandleFunc("POST /api/stripe/{connectionId}/subscriptions", s.handleStripeCreateSubscription)
 	mux.HandleFunc("GET /api/stripe/{connectionId}/subscriptions/{subscriptionId}", s.handleStripeGetSubscription)
@@ -94,27 +208,70 @@ func (s *Server) Router() http.Handler {
 	mux.HandleFunc("POST /api/stripe/{connectionId}/prices/{priceId}/archive", s.handleStripeArchivePrice)
 	mux.HandleFunc("GET /api/stripe/{connectionId}/invoices", s.handleStripeListInvoices)
 	mux.HandleFunc("GET /api/stripe/{connectionId}/invoices/{invoiceId}", s.handleStripeGetInvoice)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/invoices", s.handleStripeCreateInvoice)
+	mux.HandleFunc("DELETE /api/stripe/{connectionId}/invoices/{invoiceId}", s.handleStripeDeleteInvoice)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/invoices/{invoiceId}/items", s.handleStripeCreateInvoiceItem)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/invoices/{invoiceId}/finalize", s.handleStripeFinalizeInvoice)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/invoices/{invoiceId}/pay", s.handleStripePayInvoice)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/invoices/{invoiceId}/send", s.handleStripeSendInvoice)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/invoices/{invoiceId}/void", s.handleStripeVoidInvoice)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/invoices/{invoiceId}/mark-uncollectible", s.handleStripeMarkInvoiceUncollectible)
 	mux.HandleFunc("GET /api/stripe/{connectionId}/payments", s.handleStripeListPayments)
 	mux.HandleFunc("GET /api/stripe/{connectionId}/coupons", s.handleStripeListCoupons)
 	mux.HandleFunc("POST /api/stripe/{connectionId}/coupons", s.handleStripeCreateCoupon)
 	mux.HandleFunc("GET /api/stripe/{connectionId}/coupons/{couponId}", s.handleStripeGetCoupon)
 	mux.HandleFunc("PUT /api/stripe/{connectionId}/coupons/{couponId}", s.handleStripeUpdateCoupon)
 	mux.HandleFunc("DELETE /api/stripe/{connectionId}/coupons/{couponId}", s.handleStripeDeleteCoupon)
+	mux.HandleFunc("GET /api/stripe/{connectionId}/refunds", s.handleStripeListRefunds)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/refunds", s.handleStripeCreateRefund)
+	mux.HandleFunc("GET /api/stripe/{connectionId}/refunds/{refundId}", s.handleStripeGetRefund)
+	mux.HandleFunc("GET /api/stripe/{connectionId}/credit-notes", s.handleStripeListCreditNotes)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/credit-notes", s.handleStripeCreateCreditNote)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/credit-notes/preview", s.handleStripePreviewCreditNote)
+	mux.HandleFunc("GET /api/stripe/{connectionId}/credit-notes/{creditNoteId}", s.handleStripeGetCreditNote)
+	mux.HandleFunc("POST /api/stripe/{connectionId}/credit-notes/{creditNoteId}/void", s.handleStripeVoidCreditNote)
+
+	// Stripe webhooks carry no session and are authenticated by their
+	// signature, so they bypass the role check, write guard and audit log
+	mux.ServeMux.HandleFunc("POST /api/stripe/{connectionId}/webhooks", s.handleStripeWebhook)
+
+	// Recurly-specific endpoints
+	mux.HandleFunc("GET /api/recurly/{connectionId}/accounts", s.handleRecurlyListAccounts)
+	mux.HandleFunc("POST /api/recurly/{connectionId}/accounts", s.handleRecurlyCreateAccount)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/accounts/{accountId}", s.handleRecurlyGetAccount)
+	mux.HandleFunc("POST /api/recurly/{connectionId}/accounts/{accountId}/invoices", s.handleRecurlyCreateInvoice)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/subscriptions", s.handleRecurlyListSubscriptions)
+	mux.HandleFunc("POST /api/recurly/{connectionId}/subscriptions", s.handleRecurlyCreateSubscription)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/subscriptions/{subscriptionId}", s.handleRecurlyGetSubscription)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/plans", s.handleRecurlyListPlans)
+	mux.HandleFunc("POST /api/recurly/{connectionId}/plans", s.handleRecurlyCreatePlan)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/plans/{planId}", s.handleRecurlyGetPlan)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/invoices", s.handleRecurlyListInvoices)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/invoices/{invoiceId}", s.handleRecurlyGetInvoice)
+	mux.HandleFunc("POST /api/recurly/{connectionId}/invoices/{invoiceId}/transactions", s.handleRecurlyCreateTransaction)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/transactions", s.handleRecurlyListTransactions)
+	mux.HandleFunc("GET /api/recurly/{connectionId}/transactions/{transactionId}", s.handleRecurlyGetTransaction)
 
 	// User preferences
 	mux.HandleFunc("GET /api/preferences/{key}", s.handleGetPreference)
 	mux.HandleFunc("PUT /api/preferences/{key}", s.handleUpdatePreference)
+	mux.HandleFunc("DELETE /api/preferences/{key}", s.handleResetPreference)
 
-	// Wrap with CORS middleware
-	return corsMiddleware(mux)
+	// Wrap with auth, then CORS so preflight and 401 responses carry CORS headers
+	return s.corsMiddleware(s.authMiddleware(mux))
 }
 
-// corsMiddleware adds CORS headers for development
-func corsMiddleware(next http.Handler) http.Handler {
+// corsMiddleware adds CORS headers for the allowed origins
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" && s.originAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Confirmation-Token, Idempotency-Key")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Next-Cursor, X-Cache, X-Cache-Age, X-Cache-Synced-At, Idempotent-Replayed")
+		}
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -140,3 +297,23 @@ func respondError(w http.ResponseWriter, status int, message string) {
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
+
+// readyTimeout bounds the database ping made by the readiness check
+const readyTimeout = 2 * time.Second
+
+// Readiness check handler: fails while shutting down or when the database
+// cannot be reached
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		respondJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+	if err := s.db.Pool().Ping(ctx); err != nil {
+		respondJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": "database: " + err.Error()})
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
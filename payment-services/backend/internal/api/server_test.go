@@ -0,0 +1,32 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+func TestServerAlwaysHasASecretResolver(t *testing.T) {
+	def := platforms.Definition{
+		DisplayName: "Stripe",
+		Credentials: []platforms.CredentialField{{Type: "api_key"}},
+	}
+
+	s := NewServer(nil)
+	if s.resolver == nil {
+		t.Fatal("NewServer left the resolver nil")
+	}
+	err := s.validateCredentialRef(def, "api_key", "env://STRIPE_KEY")
+	if err == nil || !strings.Contains(err.Error(), "not enabled") {
+		t.Errorf("validateCredentialRef with no backends = %v, want a not-enabled error", err)
+	}
+
+	s.SetSecretResolver(nil)
+	if s.resolver == nil {
+		t.Fatal("SetSecretResolver(nil) left the resolver nil")
+	}
+	if err := s.validateCredentialRef(def, "api_key", "env://STRIPE_KEY"); err == nil {
+		t.Error("validateCredentialRef accepted a reference with no backends")
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms/stripe"
+)
+
+// maxWebhookBody caps the size of a webhook delivery
+const maxWebhookBody = 1 << 20
+
+// isWebhookRequest reports whether a request is a webhook delivery. Billing
+// platforms call these routes without a session; each delivery is
+// authenticated by its signature instead.
+func isWebhookRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost &&
+		strings.HasPrefix(r.URL.Path, "/api/stripe/") && strings.HasSuffix(r.URL.Path, "/webhooks")
+}
+
+// handleStripeWebhook receives Stripe events for a connection. Deliveries
+// must be signed with the connection's webhook_secret credential. Each event
+// is stored once; a redelivery of an event that was already handled is
+// acknowledged without handling it again, while one that failed is retried.
+func (s *Server) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
+	connectionID, err := strconv.ParseInt(r.PathValue("connectionId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	ctx := context.Background()
+
+	// The caller is not authenticated yet, so every reason a delivery cannot
+	// be checked gets the same answer as a bad signature; only the log says which
+	isSandbox, secret, err := s.webhookSecret(ctx, connectionID)
+	if err != nil {
+		log.Printf("webhooks: rejected delivery for connection %d: %v", connectionID, err)
+		respondError(w, http.StatusBadRequest, "Invalid signature")
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		respondError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+
+	event, err := stripe.ConstructEvent(payload, r.Header.Get(stripe.SignatureHeader), secret, stripe.DefaultWebhookTolerance)
+	if errors.Is(err, stripe.ErrInvalidSignature) {
+		log.Printf("webhooks: rejected delivery for connection %d: %v", connectionID, err)
+		respondError(w, http.StatusBadRequest, "Invalid signature")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if event.Livemode == isSandbox {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Received a %s mode event for a %s connection",
+			keyModeName(event.Livemode), environmentName(isSandbox)))
+		return
+	}
+
+	objectID, _ := event.ObjectID()
+
+	// Record the delivery, counting redeliveries of events already seen
+	var eventRowID int64
+	var status models.WebhookStatus
+	err = s.db.Pool().QueryRow(ctx, `
+		INSERT INTO webhook_events (connection_id, event_id, event_type, object_id, livemode, payload, event_created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)
+		ON CONFLICT (connection_id, event_id) DO UPDATE SET attempts = webhook_events.attempts + 1
+		RETURNING id, status
+	`, connectionID, event.ID, event.Type, objectID, event.Livemode, json.RawMessage(payload),
+		platforms.UnixTime(event.Created)).Scan(&eventRowID, &status)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if status == models.WebhookProcessed || status == models.WebhookIgnored {
+		respondJSON(w, http.StatusOK, map[string]interface{}{"received": true, "duplicate": true})
+		return
+	}
+
+	handled, handleErr := s.handleStripeEvent(ctx, connectionID, event, objectID)
+	status = models.WebhookProcessed
+	errorMessage := ""
+	switch {
+	case handleErr != nil:
+		status = models.WebhookFailed
+		errorMessage = handleErr.Error()
+	case !handled:
+		status = models.WebhookIgnored
+	}
+
+	_, err = s.db.Pool().Exec(ctx, `
+		UPDATE webhook_events SET status = $2, error_message = NULLIF($3, ''), processed_at = NOW()
+		WHERE id = $1
+	`, eventRowID, status, errorMessage)
+	if err != nil {
+		log.Printf("webhooks: failed to record outcome of %s: %v", event.ID, err)
+	}
+
+	// A failure is reported so Stripe delivers the event again later
+	if handleErr != nil {
+		log.Printf("webhooks: failed to handle %s %s for connection %d: %v", event.Type, event.ID, connectionID, handleErr)
+		respondError(w, http.StatusInternalServerError, "Failed to handle event")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"received": true})
+}
+
+// webhookSecret returns whether a Stripe connection is a sandbox and its
+// webhook signing secret. Only that credential is loaded, so an unsigned
+// delivery cannot make the hub decrypt or resolve the connection's API key.
+func (s *Server) webhookSecret(ctx context.Context, connectionID int64) (isSandbox bool, secret string, err error) {
+	var platformType string
+	err = s.db.Pool().QueryRow(ctx, `
+		SELECT platform_type, is_sandbox FROM platform_connections WHERE id = $1
+	`, connectionID).Scan(&platformType, &isSandbox)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, "", errConnectionNotFound
+	}
+	if err != nil {
+		return false, "", err
+	}
+	if models.PlatformType(platformType) != models.PlatformStripe {
+		return false, "", fmt.Errorf("connection is a %s connection", platformType)
+	}
+
+	secret, err = s.db.LoadCredential(ctx, s.keyring, s.resolver, connectionID, "webhook_secret")
+	if err != nil {
+		return false, "", err
+	}
+	if secret == "" {
+		return false, "", fmt.Errorf("no webhook signing secret is configured")
+	}
+	return isSandbox, secret, nil
+}
+
+// handleStripeEvent brings the cached copy of an event's object up to date.
+// The object is fetched again rather than taken from the payload, because
+// Stripe does not deliver events in order and an older event could
+// otherwise overwrite newer data. It reports false for event types that
+// need no handling.
+func (s *Server) handleStripeEvent(ctx context.Context, connectionID int64, event *stripe.Event, objectID string) (bool, error) {
+	var fetch func(p *stripe.Provider) error
+	switch {
+	case strings.HasPrefix(event.Type, "customer.subscription."):
+		fetch = func(p *stripe.Provider) error {
+			subscription, err := p.GetSubscription(objectID)
+			if err != nil {
+				return err
+			}
+			return s.syncer.StoreSubscription(ctx, connectionID, *subscription)
+		}
+
+	case event.Type == "invoice.deleted":
+		// Only drafts can be deleted, and they can no longer be fetched
+		return true, s.syncer.DeleteInvoice(ctx, connectionID, objectID)
+
+	case strings.HasPrefix(event.Type, "invoice.") && event.Type != "invoice.upcoming":
+		fetch = func(p *stripe.Provider) error {
+			invoice, err := p.GetInvoice(objectID)
+			if err != nil {
+				return err
+			}
+			return s.syncer.StoreInvoice(ctx, connectionID, *invoice)
+		}
+
+	default:
+		return false, nil
+	}
+
+	if objectID == "" {
+		return true, fmt.Errorf("%s event has no object ID", event.Type)
+	}
+	provider, err := s.getProvider(connectionID)
+	if err != nil {
+		return true, err
+	}
+	p, ok := provider.(*stripe.Provider)
+	if !ok {
+		return true, fmt.Errorf("connection %d is not a Stripe connection", connectionID)
+	}
+	return true, fetch(p)
+}
+
+func keyModeName(live bool) string {
+	if live {
+		return "live"
+	}
+	return "test"
+}
+
+func environmentName(sandbox bool) string {
+	if sandbox {
+		return "sandbox"
+	}
+	return "live"
+}
@@ -0,0 +1,261 @@
+// Package auth manages hub user accounts and login sessions.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/db"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// DefaultSessionTTL is how long a login session lasts
+const DefaultSessionTTL = 12 * time.Hour
+
+// MinPasswordLength is the shortest password accepted for an account
+const MinPasswordLength = 12
+
+var (
+	// ErrInvalidCredentials is returned for an unknown user, wrong password or disabled account
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidSession is returned for an unknown or expired session token
+	ErrInvalidSession = errors.New("invalid or expired session")
+)
+
+// dummyHash is compared against when a username does not exist, so a failed
+// login takes as long whether or not the account exists
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("payment-billing-hub"), bcrypt.DefaultCost)
+
+// Store reads and writes users and sessions
+type Store struct {
+	db         *db.DB
+	sessionTTL time.Duration
+}
+
+// NewStore creates a store whose sessions last DefaultSessionTTL
+func NewStore(database *db.DB) *Store {
+	return &Store{db: database, sessionTTL: DefaultSessionTTL}
+}
+
+// SetSessionTTL sets how long new sessions last
+func (s *Store) SetSessionTTL(ttl time.Duration) {
+	s.sessionTTL = ttl
+}
+
+// Session is a newly created login session. Token is only available here;
+// the database keeps its hash.
+type Session struct {
+	Token     string      `json:"token"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	User      models.User `json:"user"`
+}
+
+const userColumns = `id, username, COALESCE(display_name, ''), is_active, is_admin, last_login_at, created_at`
+
+func scanUser(row pgx.Row) (models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Username, &u.DisplayName, &u.IsActive, &u.IsAdmin, &u.LastLoginAt, &u.CreatedAt)
+	return u, err
+}
+
+// CreateUser adds an active account
+func (s *Store) CreateUser(ctx context.Context, username, displayName, password string) (models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return models.User{}, errors.New("username is required")
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return scanUser(s.db.Pool().QueryRow(ctx, `
+		INSERT INTO users (username, display_name, password_hash)
+		VALUES ($1, NULLIF($2, ''), $3)
+		RETURNING `+userColumns, username, displayName, hash))
+}
+
+// SetPassword replaces a user's password and ends all of their sessions
+func (s *Store) SetPassword(ctx context.Context, username, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, s.db.Pool(), func(tx pgx.Tx) error {
+		var userID int64
+		err := tx.QueryRow(ctx, `
+			UPDATE users SET password_hash = $2, updated_at = NOW()
+			WHERE username = $1
+			RETURNING id
+		`, username, hash).Scan(&userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user %q not found", username)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM sessions WHERE user_id = $1", userID)
+		return err
+	})
+}
+
+// SetActive enables or disables an account; disabling ends its sessions
+func (s *Store) SetActive(ctx context.Context, username string, active bool) error {
+	return pgx.BeginFunc(ctx, s.db.Pool(), func(tx pgx.Tx) error {
+		var userID int64
+		err := tx.QueryRow(ctx, `
+			UPDATE users SET is_active = $2, updated_at = NOW()
+			WHERE username = $1
+			RETURNING id
+		`, username, active).Scan(&userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user %q not found", username)
+		}
+		if err != nil || active {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM sessions WHERE user_id = $1", userID)
+		return err
+	})
+}
+
+// ListUsers returns every account ordered by username
+func (s *Store) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := s.db.Pool().Query(ctx, "SELECT "+userColumns+" FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.User, error) {
+		return scanUser(row)
+	})
+}
+
+// Login checks a username and password and starts a new session
+func (s *Store) Login(ctx context.Context, username, password, userAgent string) (*Session, error) {
+	var user models.User
+	var hash string
+	err := s.db.Pool().QueryRow(ctx, `
+		SELECT `+userColumns+`, password_hash FROM users WHERE username = $1
+	`, strings.TrimSpace(username)).Scan(
+		&user.ID, &user.Username, &user.DisplayName, &user.IsActive, &user.IsAdmin, &user.LastLoginAt, &user.CreatedAt, &hash,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil || !user.IsActive {
+		return nil, ErrInvalidCredentials
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(s.sessionTTL)
+
+	err = pgx.BeginFunc(ctx, s.db.Pool(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO sessions (user_id, token_hash, user_agent, expires_at)
+			VALUES ($1, $2, NULLIF($3, ''), $4)
+		`, user.ID, hashToken(token), userAgent, expiresAt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "UPDATE users SET last_login_at = NOW() WHERE id = $1", user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	return &Session{Token: token, ExpiresAt: expiresAt, User: user}, nil
+}
+
+// Authenticate returns the active user owning an unexpired session token
+func (s *Store) Authenticate(ctx context.Context, token string) (models.User, error) {
+	if token == "" {
+		return models.User{}, ErrInvalidSession
+	}
+
+	user, err := scanUser(s.db.Pool().QueryRow(ctx, `
+		SELECT u.id, u.username, COALESCE(u.display_name, ''), u.is_active, u.is_admin, u.last_login_at, u.created_at
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = $1 AND s.expires_at > NOW() AND u.is_active
+	`, hashToken(token)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, ErrInvalidSession
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// Record activity at most once a minute to avoid a write per request
+	s.db.Pool().Exec(ctx, `
+		UPDATE sessions SET last_used_at = NOW()
+		WHERE token_hash = $1 AND last_used_at < NOW() - INTERVAL '1 minute'
+	`, hashToken(token))
+
+	return user, nil
+}
+
+// Logout ends the session for token
+func (s *Store) Logout(ctx context.Context, token string) error {
+	_, err := s.db.Pool().Exec(ctx, "DELETE FROM sessions WHERE token_hash = $1", hashToken(token))
+	return err
+}
+
+// DeleteExpiredSessions removes sessions past their expiry
+func (s *Store) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	tag, err := s.db.Pool().Exec(ctx, "DELETE FROM sessions WHERE expires_at <= NOW()")
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	// bcrypt ignores everything past 72 bytes
+	if len(password) > 72 {
+		return "", errors.New("password must be at most 72 bytes")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// newToken returns a random URL-safe session token
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a token, as stored in sessions.token_hash
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// ConfirmationTTL is how long a write confirmation token stays usable
+const ConfirmationTTL = 5 * time.Minute
+
+// Confirmation approves one write to a connection
+type Confirmation struct {
+	Token        string    `json:"token"`
+	ConnectionID int64     `json:"connection_id"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// CreateConfirmation issues a single-use token approving user's write of
+// method to path on a connection
+func (s *Store) CreateConfirmation(ctx context.Context, user models.User, connectionID int64, method, path string) (*Confirmation, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(ConfirmationTTL)
+
+	_, err = s.db.Pool().Exec(ctx, `
+		INSERT INTO write_confirmations (token_hash, user_id, connection_id, method, path, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, hashToken(token), user.ID, connectionID, method, path, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired tokens are only kept long enough to be useless
+	s.db.Pool().Exec(ctx, "DELETE FROM write_confirmations WHERE expires_at < NOW() - INTERVAL '1 day'")
+
+	return &Confirmation{
+		Token:        token,
+		ConnectionID: connectionID,
+		Method:       method,
+		Path:         path,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// UseConfirmation spends a token, reporting whether it was issued to user
+// for exactly this write and had not expired or been used
+func (s *Store) UseConfirmation(ctx context.Context, token string, user models.User, connectionID int64, method, path string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	var id int64
+	err := s.db.Pool().QueryRow(ctx, `
+		UPDATE write_confirmations SET used_at = NOW()
+		WHERE token_hash = $1 AND user_id = $2 AND connection_id = $3 AND method = $4 AND path = $5
+		  AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id
+	`, hashToken(token), user.ID, connectionID, method, path).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
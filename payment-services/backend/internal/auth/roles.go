@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// ConnectionRole returns the user's role on a connection, or "" if they have none
+func (s *Store) ConnectionRole(ctx context.Context, user models.User, connectionID int64) (models.Role, error) {
+	if user.IsAdmin {
+		return models.RoleAdmin, nil
+	}
+
+	var role models.Role
+	err := s.db.Pool().QueryRow(ctx, `
+		SELECT role FROM connection_roles WHERE user_id = $1 AND connection_id = $2
+	`, user.ID, connectionID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+// ListConnectionRoles returns every role granted on a connection
+func (s *Store) ListConnectionRoles(ctx context.Context, connectionID int64) ([]models.ConnectionRole, error) {
+	rows, err := s.db.Pool().Query(ctx, `
+		SELECT cr.user_id, u.username, cr.connection_id, cr.role, cr.updated_at
+		FROM connection_roles cr
+		JOIN users u ON u.id = cr.user_id
+		WHERE cr.connection_id = $1
+		ORDER BY u.username
+	`, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.ConnectionRole, error) {
+		var cr models.ConnectionRole
+		err := row.Scan(&cr.UserID, &cr.Username, &cr.ConnectionID, &cr.Role, &cr.UpdatedAt)
+		return cr, err
+	})
+}
+
+// GrantRole gives a user a role on a connection, replacing any existing one
+func (s *Store) GrantRole(ctx context.Context, userID, connectionID int64, role models.Role) error {
+	if !role.Valid() {
+		return fmt.Errorf("invalid role %q", role)
+	}
+	_, err := s.db.Pool().Exec(ctx, `
+		INSERT INTO connection_roles (user_id, connection_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, connection_id) DO UPDATE SET role = $3, updated_at = NOW()
+	`, userID, connectionID, role)
+	return err
+}
+
+// RevokeRole removes a user's role on a connection
+func (s *Store) RevokeRole(ctx context.Context, userID, connectionID int64) error {
+	_, err := s.db.Pool().Exec(ctx, `
+		DELETE FROM connection_roles WHERE user_id = $1 AND connection_id = $2
+	`, userID, connectionID)
+	return err
+}
+
+// UserID looks up a user's ID by username
+func (s *Store) UserID(ctx context.Context, username string) (int64, error) {
+	var id int64
+	err := s.db.Pool().QueryRow(ctx, "SELECT id FROM users WHERE username = $1", username).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("user %q not found", username)
+	}
+	return id, err
+}
+
+// SetAdmin grants or removes global administrator rights
+func (s *Store) SetAdmin(ctx context.Context, username string, admin bool) error {
+	tag, err := s.db.Pool().Exec(ctx, `
+		UPDATE users SET is_admin = $2, updated_at = NOW() WHERE username = $1
+	`, username, admin)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user %q not found", username)
+	}
+	return nil
+}
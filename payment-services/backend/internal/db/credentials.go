@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/secrets"
+)
+
+// Execer is satisfied by both the pool and a transaction
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// credentialAAD binds an encrypted credential to its connection and type
+func credentialAAD(connectionID int64, credentialType string) []byte {
+	return []byte(strconv.FormatInt(connectionID, 10) + "/" + credentialType)
+}
+
+// SaveCredential stores a credential, encrypted under the keyring's current
+// key. With a nil keyring the value is stored in plaintext.
+func SaveCredential(ctx context.Context, q Execer, keyring *secrets.Keyring, connectionID int64, credentialType, value string) error {
+	var keyID, dataKey *string
+	if keyring != nil {
+		sealed, err := keyring.Seal(value, credentialAAD(connectionID, credentialType))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", credentialType, err)
+		}
+		value, keyID, dataKey = sealed.Value, &sealed.KeyID, &sealed.DataKey
+	}
+
+	_, err := q.Exec(ctx, `
+		INSERT INTO platform_credentials (connection_id, credential_type, credential_value, key_id, data_key)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (connection_id, credential_type)
+		DO UPDATE SET credential_value = $3, key_id = $4, data_key = $5, secret_ref = NULL
+	`, connectionID, credentialType, value, keyID, dataKey)
+	return err
+}
+
+// SaveCredentialRef stores a reference to a secret held outside the database
+// in place of the credential's value
+func SaveCredentialRef(ctx context.Context, q Execer, connectionID int64, credentialType, ref string) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO platform_credentials (connection_id, credential_type, credential_value, secret_ref)
+		VALUES ($1, $2, '', $3)
+		ON CONFLICT (connection_id, credential_type)
+		DO UPDATE SET credential_value = '', key_id = NULL, data_key = NULL, secret_ref = $3
+	`, connectionID, credentialType, ref)
+	return err
+}
+
+// LoadCredentials returns a connection's credentials keyed by type, decrypting
+// stored values with keyring and looking up secret references with resolver
+func (db *DB) LoadCredentials(ctx context.Context, keyring *secrets.Keyring, resolver *secrets.Resolver, connectionID int64) (map[string]string, error) {
+	return db.loadCredentials(ctx, keyring, resolver, connectionID, "")
+}
+
+// LoadCredential returns one of a connection's credentials, or "" if it has
+// none of that type. Only that credential is decrypted or resolved.
+func (db *DB) LoadCredential(ctx context.Context, keyring *secrets.Keyring, resolver *secrets.Resolver, connectionID int64, credentialType string) (string, error) {
+	credentials, err := db.loadCredentials(ctx, keyring, resolver, connectionID, credentialType)
+	if err != nil {
+		return "", err
+	}
+	return credentials[credentialType], nil
+}
+
+// loadCredentials loads a connection's credentials of credentialType, or all
+// of them when credentialType is empty
+func (db *DB) loadCredentials(ctx context.Context, keyring *secrets.Keyring, resolver *secrets.Resolver, connectionID int64, credentialType string) (map[string]string, error) {
+	type credential struct {
+		credentialType string
+		value          string
+		keyID, dataKey *string
+		secretRef      *string
+	}
+
+	rows, err := db.pool.Query(ctx, `
+		SELECT credential_type, credential_value, key_id, data_key, secret_ref
+		FROM platform_credentials
+		WHERE connection_id = $1 AND ($2 = '' OR credential_type = $2)
+	`, connectionID, credentialType)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (credential, error) {
+		var c credential
+		err := row.Scan(&c.credentialType, &c.value, &c.keyID, &c.dataKey, &c.secretRef)
+		return c, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve after the rows are closed so a slow secret backend does not hold a connection
+	credentials := make(map[string]string, len(stored))
+	for _, c := range stored {
+		if c.secretRef != nil {
+			value, err := resolver.Resolve(ctx, *c.secretRef)
+			if err != nil {
+				return nil, fmt.Errorf("%s for connection %d: %w", c.credentialType, connectionID, err)
+			}
+			credentials[c.credentialType] = value
+			continue
+		}
+
+		plaintext, err := openCredential(keyring, connectionID, c.credentialType, c.value, c.keyID, c.dataKey)
+		if err != nil {
+			return nil, err
+		}
+		credentials[c.credentialType] = plaintext
+	}
+	return credentials, nil
+}
+
+// openCredential decrypts a stored value; rows without a key ID are plaintext
+func openCredential(keyring *secrets.Keyring, connectionID int64, credentialType, value string, keyID, dataKey *string) (string, error) {
+	if keyID == nil {
+		return value, nil
+	}
+	if keyring == nil {
+		return "", secrets.ErrNoKeyring
+	}
+	if dataKey == nil {
+		return "", fmt.Errorf("%s for connection %d has a key ID but no data key", credentialType, connectionID)
+	}
+	sealed := secrets.Sealed{Value: value, DataKey: *dataKey, KeyID: *keyID}
+	plaintext, err := keyring.Open(sealed, credentialAAD(connectionID, credentialType))
+	if err != nil {
+		return "", fmt.Errorf("%s for connection %d: %w", credentialType, connectionID, err)
+	}
+	return plaintext, nil
+}
+
+// keepCredential reports whether RewriteCredentials can leave a row sealed
+// under keyID (nil for plaintext) as it is
+func keepCredential(keyring *secrets.Keyring, keyID *string, encrypt, force bool) bool {
+	if !encrypt {
+		return keyID == nil
+	}
+	return !force && keyID != nil && *keyID == keyring.CurrentKeyID()
+}
+
+// RewriteResult counts the credentials visited by RewriteCredentials
+type RewriteResult struct {
+	Rewritten int
+	Unchanged int
+}
+
+// RewriteCredentials re-encrypts every stored credential in one transaction.
+// Credentials held as secret references have no stored value and are skipped.
+// When encrypt is true, plaintext rows and rows sealed under a retired key are
+// sealed under the keyring's current key (all rows when force is set, which
+// also replaces their data keys). When encrypt is false, every row is
+// decrypted back to plaintext.
+func (db *DB) RewriteCredentials(keyring *secrets.Keyring, encrypt, force bool) (RewriteResult, error) {
+	var result RewriteResult
+	if keyring == nil {
+		return result, secrets.ErrNoKeyring
+	}
+
+	ctx := context.Background()
+	err := pgx.BeginFunc(ctx, db.pool, func(tx pgx.Tx) error {
+		type credential struct {
+			connectionID   int64
+			credentialType string
+			value          string
+			keyID, dataKey *string
+		}
+
+		// Lock the rows so a concurrent update cannot be overwritten
+		rows, err := tx.Query(ctx, `
+			SELECT connection_id, credential_type, credential_value, key_id, data_key
+			FROM platform_credentials
+			WHERE secret_ref IS NULL
+			ORDER BY id
+			FOR UPDATE
+		`)
+		if err != nil {
+			return err
+		}
+		credentials, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (credential, error) {
+			var c credential
+			err := row.Scan(&c.connectionID, &c.credentialType, &c.value, &c.keyID, &c.dataKey)
+			return c, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range credentials {
+			if keepCredential(keyring, c.keyID, encrypt, force) {
+				result.Unchanged++
+				continue
+			}
+
+			plaintext, err := openCredential(keyring, c.connectionID, c.credentialType, c.value, c.keyID, c.dataKey)
+			if err != nil {
+				return err
+			}
+			target := keyring
+			if !encrypt {
+				target = nil
+			}
+			if err := SaveCredential(ctx, tx, target, c.connectionID, c.credentialType, plaintext); err != nil {
+				return err
+			}
+			result.Rewritten++
+		}
+		return nil
+	})
+	if err != nil {
+		return RewriteResult{}, fmt.Errorf("failed to rewrite credentials: %w", err)
+	}
+	return result, nil
+}
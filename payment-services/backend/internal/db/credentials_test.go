@@ -0,0 +1,78 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/secrets"
+)
+
+func TestKeepCredential(t *testing.T) {
+	keyring, err := secrets.NewKeyring("k2", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, secrets.KeySize),
+		"k2": bytes.Repeat([]byte{2}, secrets.KeySize),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, retired := "k2", "k1"
+
+	tests := []struct {
+		name    string
+		keyID   *string
+		encrypt bool
+		force   bool
+		want    bool
+	}{
+		{name: "encrypt seals plaintext", keyID: nil, encrypt: true, want: false},
+		{name: "encrypt moves rows off a retired key", keyID: &retired, encrypt: true, want: false},
+		{name: "encrypt keeps rows under the current key", keyID: &current, encrypt: true, want: true},
+		{name: "force reseals rows under the current key", keyID: &current, encrypt: true, force: true, want: false},
+		{name: "force reseals plaintext", keyID: nil, encrypt: true, force: true, want: false},
+		{name: "decrypt opens rows under the current key", keyID: &current, want: false},
+		{name: "decrypt opens rows under a retired key", keyID: &retired, want: false},
+		{name: "decrypt keeps plaintext", keyID: nil, want: true},
+		{name: "force has no effect on decrypt", keyID: nil, force: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keepCredential(keyring, tt.keyID, tt.encrypt, tt.force); got != tt.want {
+				t.Errorf("keepCredential = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenCredential(t *testing.T) {
+	keyring, err := secrets.NewKeyring("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, secrets.KeySize)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := keyring.Seal("sk_test_abc", credentialAAD(7, "api_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := openCredential(keyring, 7, "api_key", sealed.Value, &sealed.KeyID, &sealed.DataKey)
+	if err != nil || got != "sk_test_abc" {
+		t.Errorf("openCredential = %q, %v, want sk_test_abc", got, err)
+	}
+	if got, err := openCredential(nil, 7, "api_key", "plain", nil, nil); err != nil || got != "plain" {
+		t.Errorf("openCredential of plaintext = %q, %v, want plain", got, err)
+	}
+
+	// A sealed value copied to another connection or credential type does not open
+	if _, err := openCredential(keyring, 8, "api_key", sealed.Value, &sealed.KeyID, &sealed.DataKey); err == nil {
+		t.Error("credential opened for another connection")
+	}
+	if _, err := openCredential(keyring, 7, "webhook_secret", sealed.Value, &sealed.KeyID, &sealed.DataKey); err == nil {
+		t.Error("credential opened as another credential type")
+	}
+	if _, err := openCredential(nil, 7, "api_key", sealed.Value, &sealed.KeyID, &sealed.DataKey); !errors.Is(err, secrets.ErrNoKeyring) {
+		t.Errorf("openCredential without a keyring = %v, want ErrNoKeyring", err)
+	}
+	if _, err := openCredential(keyring, 7, "api_key", sealed.Value, &sealed.KeyID, nil); err == nil {
+		t.Error("credential with no data key opened")
+	}
+}
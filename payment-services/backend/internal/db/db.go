@@ -40,25 +40,3 @@ func (db *DB) Close() {
 func (db *DB) Pool() *pgxpool.Pool {
 	return db.pool
 }
-
-// Migrate runs database migrations
-func (db *DB) Migrate() error {
-	migrations := []string{
-		"migrations/001_initial_schema.sql",
-		"migrations/002_add_base_url.sql",
-	}
-
-	for _, migrationPath := range migrations {
-		migration, err := migrationsFS.ReadFile(migrationPath)
-		if err != nil {
-			return fmt.Errorf("failed to read migration %s: %w", migrationPath, err)
-		}
-
-		_, err = db.pool.Exec(context.Background(), string(migration))
-		if err != nil {
-			return fmt.Errorf("failed to execute migration %s: %w", migrationPath, err)
-		}
-	}
-
-	return nil
-}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/002_add_orders.sql":         {Data: []byte("CREATE TABLE orders ();")},
+		"migrations/002_add_orders.down.sql":    {Data: []byte("DROP TABLE orders;")},
+		"migrations/001_initial.sql":            {Data: []byte("CREATE TABLE users ();")},
+		"migrations/010_backfill_names.sql":     {Data: []byte("UPDATE users SET name = '';")},
+		"migrations/README.md":                  {Data: []byte("not a migration")},
+		"migrations/archive/003_old.sql":        {Data: []byte("ignored")},
+		"migrations/003_split.tables_again.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "initial", Up: "CREATE TABLE users ();"},
+		{Version: 2, Name: "add_orders", Up: "CREATE TABLE orders ();", Down: "DROP TABLE orders;"},
+		{Version: 3, Name: "split.tables_again", Up: "SELECT 1;"},
+		{Version: 10, Name: "backfill_names", Up: "UPDATE users SET name = '';"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("loaded %d migrations, want %d: %+v", len(migrations), len(want), migrations)
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		wantErr string
+	}{
+		{
+			name:    "duplicate version",
+			files:   []string{"004_add_index.sql", "004_add_column.sql"},
+			wantErr: "migration version 4 is used by both",
+		},
+		{
+			name:    "down file for another name",
+			files:   []string{"004_add_index.sql", "004_add_column.down.sql"},
+			wantErr: "migration version 4 is used by both",
+		},
+		{
+			name:    "down file without up file",
+			files:   []string{"001_initial.sql", "005_drop_table.down.sql"},
+			wantErr: "migration 5_drop_table has a down file but no up file",
+		},
+		{
+			name:    "no version number",
+			files:   []string{"initial.sql"},
+			wantErr: "migration initial.sql does not start with a version number",
+		},
+		{
+			name:    "no migrations directory",
+			wantErr: "failed to read migrations",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, name := range tt.files {
+				fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+			_, err := loadMigrations(fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadMigrations = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationsFS)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s is at position %d; versions should run 1, 2, 3, ... without gaps", m.Version, m.Name, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_preferences;
+DROP TABLE IF EXISTS cached_subscriptions;
+DROP TABLE IF EXISTS cached_customers;
+DROP TABLE IF EXISTS platform_credentials;
+DROP TABLE IF EXISTS platform_connections;
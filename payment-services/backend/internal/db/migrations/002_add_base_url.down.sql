@@ -0,0 +1 @@
+ALTER TABLE platform_connections DROP COLUMN IF EXISTS base_url;
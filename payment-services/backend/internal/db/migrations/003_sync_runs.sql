@@ -0,0 +1,18 @@
+-- Sync Runs - One row per attempt to copy a connection's data into the cache tables
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id                    BIGSERIAL PRIMARY KEY,
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    status                VARCHAR(20) NOT NULL DEFAULT 'running', -- running, succeeded, failed
+    customers_synced      INTEGER NOT NULL DEFAULT 0,
+    subscriptions_synced  INTEGER NOT NULL DEFAULT 0,
+    error_message         TEXT,
+    started_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at           TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_connection ON sync_runs(connection_id, started_at DESC);
+
+-- Runs left in 'running' by a server that stopped mid-sync can never finish
+UPDATE sync_runs
+SET status = 'failed', error_message = 'interrupted by server restart', finished_at = NOW()
+WHERE status = 'running';
@@ -0,0 +1,2 @@
+ALTER TABLE sync_runs DROP COLUMN IF EXISTS mode;
+DROP TABLE IF EXISTS sync_cursors;
@@ -0,0 +1,11 @@
+-- Sync Cursors - Per-entity high-water mark so later runs only fetch changed records
+CREATE TABLE IF NOT EXISTS sync_cursors (
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    entity_type           VARCHAR(50) NOT NULL,  -- 'customers', 'subscriptions'
+    high_water_mark       TIMESTAMPTZ NOT NULL,  -- start of the last run that synced this entity
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (connection_id, entity_type)
+);
+
+-- Record whether each run fetched everything or only changes since the cursors
+ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS mode VARCHAR(20) NOT NULL DEFAULT 'full'; -- full, incremental
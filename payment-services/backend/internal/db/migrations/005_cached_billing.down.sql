@@ -0,0 +1,6 @@
+ALTER TABLE sync_runs DROP COLUMN IF EXISTS products_synced;
+ALTER TABLE sync_runs DROP COLUMN IF EXISTS payments_synced;
+ALTER TABLE sync_runs DROP COLUMN IF EXISTS invoices_synced;
+DROP TABLE IF EXISTS cached_products;
+DROP TABLE IF EXISTS cached_payments;
+DROP TABLE IF EXISTS cached_invoices;
@@ -0,0 +1,60 @@
+-- Cached Invoices - Local cache of invoice data
+CREATE TABLE IF NOT EXISTS cached_invoices (
+    id                    BIGSERIAL PRIMARY KEY,
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    external_id           VARCHAR(100) NOT NULL,
+    number                VARCHAR(100),
+    customer_external_id  VARCHAR(100),
+    status                VARCHAR(50),
+    total                 NUMERIC,
+    currency              VARCHAR(10),
+    due_date              TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ,           -- when the platform created the invoice
+    raw_data              JSONB,
+    synced_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(connection_id, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cached_invoices_connection ON cached_invoices(connection_id);
+CREATE INDEX IF NOT EXISTS idx_cached_invoices_customer ON cached_invoices(customer_external_id);
+CREATE INDEX IF NOT EXISTS idx_cached_invoices_status ON cached_invoices(status);
+
+-- Cached Payments - Local cache of payment data
+CREATE TABLE IF NOT EXISTS cached_payments (
+    id                    BIGSERIAL PRIMARY KEY,
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    external_id           VARCHAR(100) NOT NULL,
+    amount                NUMERIC,
+    currency              VARCHAR(10),
+    status                VARCHAR(50),
+    created_at            TIMESTAMPTZ,           -- when the platform recorded the payment
+    raw_data              JSONB,
+    synced_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(connection_id, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cached_payments_connection ON cached_payments(connection_id);
+CREATE INDEX IF NOT EXISTS idx_cached_payments_created ON cached_payments(created_at);
+
+-- Cached Products - Local cache of product data
+CREATE TABLE IF NOT EXISTS cached_products (
+    id                    BIGSERIAL PRIMARY KEY,
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    external_id           VARCHAR(100) NOT NULL,
+    name                  VARCHAR(255),
+    handle                VARCHAR(255),
+    description           TEXT,
+    price                 NUMERIC,
+    billing_interval      VARCHAR(50),           -- models.Product.Interval
+    created_at            TIMESTAMPTZ,
+    raw_data              JSONB,
+    synced_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(connection_id, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cached_products_connection ON cached_products(connection_id);
+
+-- Per-entity counts for the new cache tables
+ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS invoices_synced INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS payments_synced INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS products_synced INTEGER NOT NULL DEFAULT 0;
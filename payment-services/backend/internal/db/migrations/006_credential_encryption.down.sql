@@ -0,0 +1,11 @@
+-- Dropping these columns would leave encrypted values unreadable, so refuse
+-- until `hubctl credentials decrypt` has restored them to plaintext.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM platform_credentials WHERE key_id IS NOT NULL) THEN
+        RAISE EXCEPTION 'platform_credentials still holds encrypted values; run hubctl credentials decrypt first';
+    END IF;
+END $$;
+
+ALTER TABLE platform_credentials DROP COLUMN IF EXISTS data_key;
+ALTER TABLE platform_credentials DROP COLUMN IF EXISTS key_id;
@@ -0,0 +1,5 @@
+-- Envelope encryption for platform credentials.
+-- Rows with a NULL key_id still hold a plaintext credential_value; run
+-- `hubctl credentials rotate` to encrypt them under the current key.
+ALTER TABLE platform_credentials ADD COLUMN IF NOT EXISTS key_id VARCHAR(64);
+ALTER TABLE platform_credentials ADD COLUMN IF NOT EXISTS data_key TEXT;
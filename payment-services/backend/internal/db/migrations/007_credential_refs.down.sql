@@ -0,0 +1,3 @@
+-- Rows that only reference an external secret have no value to fall back to
+DELETE FROM platform_credentials WHERE secret_ref IS NOT NULL;
+ALTER TABLE platform_credentials DROP COLUMN IF EXISTS secret_ref;
@@ -0,0 +1,3 @@
+-- A credential may reference a secret held outside the database
+-- (env://, file://, vault://). credential_value is empty for such rows.
+ALTER TABLE platform_credentials ADD COLUMN IF NOT EXISTS secret_ref TEXT;
@@ -0,0 +1,26 @@
+-- Users - Accounts allowed to sign in to the hub
+CREATE TABLE IF NOT EXISTS users (
+    id                    BIGSERIAL PRIMARY KEY,
+    username              VARCHAR(100) NOT NULL UNIQUE,
+    password_hash         VARCHAR(255) NOT NULL,  -- bcrypt
+    display_name          VARCHAR(255),
+    is_active             BOOLEAN NOT NULL DEFAULT true,
+    last_login_at         TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Sessions - Login sessions, presented as a cookie or bearer token.
+-- Only a SHA-256 hash of the token is stored.
+CREATE TABLE IF NOT EXISTS sessions (
+    id                    BIGSERIAL PRIMARY KEY,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash            CHAR(64) NOT NULL UNIQUE,
+    user_agent            TEXT,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at            TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS connection_roles;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,15 @@
+-- Global administrators manage connections, users and roles everywhere
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- Connection Roles - What each user may do on a connection.
+-- A user with no row for a connection cannot see it.
+CREATE TABLE IF NOT EXISTS connection_roles (
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    role                  VARCHAR(20) NOT NULL CHECK (role IN ('viewer', 'operator', 'admin')),
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, connection_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_connection_roles_connection ON connection_roles(connection_id);
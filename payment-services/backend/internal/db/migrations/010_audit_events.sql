@@ -0,0 +1,23 @@
+-- Audit Events - Every mutating call made through the hub.
+-- Actor and connection are copied rather than referenced so events outlive
+-- the users and connections they mention.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id                    BIGSERIAL PRIMARY KEY,
+    occurred_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_user_id         BIGINT,
+    actor_username        VARCHAR(100),
+    connection_id         BIGINT,
+    platform_type         VARCHAR(20),
+    method                VARCHAR(10) NOT NULL,
+    route                 VARCHAR(255) NOT NULL,  -- registered pattern, e.g. DELETE /api/stripe/{connectionId}/coupons/{couponId}
+    path                  TEXT NOT NULL,
+    target_id             VARCHAR(255),
+    request_body          JSONB,                  -- secrets redacted
+    status_code           INTEGER NOT NULL,       -- status the hub returned
+    upstream_status       INTEGER,                -- status the billing platform returned when it rejected the call
+    remote_addr           VARCHAR(100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred ON audit_events(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_connection ON audit_events(connection_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_user_id, occurred_at);
@@ -0,0 +1,5 @@
+DELETE FROM user_preferences WHERE user_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_user_preferences_user;
+DROP INDEX IF EXISTS idx_user_preferences_default;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS user_id;
+ALTER TABLE user_preferences ADD CONSTRAINT user_preferences_preference_key_key UNIQUE (preference_key);
@@ -0,0 +1,8 @@
+-- Preferences belong to a user; rows without a user are the global defaults
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE user_preferences DROP CONSTRAINT IF EXISTS user_preferences_preference_key_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_preferences_default
+    ON user_preferences(preference_key) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_preferences_user
+    ON user_preferences(user_id, preference_key) WHERE user_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS write_confirmations;
+ALTER TABLE platform_connections DROP COLUMN IF EXISTS mode;
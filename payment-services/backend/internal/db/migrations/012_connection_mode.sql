@@ -0,0 +1,23 @@
+-- Connection Mode - Which writes the hub lets through to the platform.
+-- read_only rejects every write; confirm_required (and any live connection)
+-- needs a single-use confirmation token per write.
+ALTER TABLE platform_connections ADD COLUMN IF NOT EXISTS mode VARCHAR(20) NOT NULL DEFAULT 'read_write'
+    CHECK (mode IN ('read_only', 'read_write', 'confirm_required'));
+
+UPDATE platform_connections SET mode = 'confirm_required' WHERE NOT is_sandbox;
+
+-- Write Confirmations - Tokens that approve one write on a connection.
+-- Only a SHA-256 hash of the token is stored.
+CREATE TABLE IF NOT EXISTS write_confirmations (
+    id                    BIGSERIAL PRIMARY KEY,
+    token_hash            CHAR(64) NOT NULL UNIQUE,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    method                VARCHAR(10) NOT NULL,
+    path                  TEXT NOT NULL,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at            TIMESTAMPTZ NOT NULL,
+    used_at               TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_write_confirmations_expires ON write_confirmations(expires_at);
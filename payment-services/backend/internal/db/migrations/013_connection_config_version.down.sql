@@ -0,0 +1 @@
+ALTER TABLE platform_connections DROP COLUMN IF EXISTS config_version;
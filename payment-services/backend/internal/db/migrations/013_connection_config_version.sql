@@ -0,0 +1,3 @@
+-- Bumped whenever a connection's settings or credentials change, so cached
+-- clients built from an older version are rebuilt
+ALTER TABLE platform_connections ADD COLUMN IF NOT EXISTS config_version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,22 @@
+-- Webhook Events - Deliveries received from billing platforms.
+-- Events are unique per connection; a redelivery of a processed event is
+-- acknowledged without being handled again.
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id                    BIGSERIAL PRIMARY KEY,
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    event_id              VARCHAR(255) NOT NULL,
+    event_type            VARCHAR(100) NOT NULL,
+    object_id             VARCHAR(255),
+    livemode              BOOLEAN NOT NULL DEFAULT FALSE,
+    payload               JSONB NOT NULL,
+    status                VARCHAR(20) NOT NULL DEFAULT 'received',  -- received, processed, ignored, failed
+    error_message         TEXT,
+    attempts              INTEGER NOT NULL DEFAULT 1,
+    event_created_at      TIMESTAMPTZ,           -- when the platform created the event
+    received_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at          TIMESTAMPTZ,
+    UNIQUE(connection_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_events_connection ON webhook_events(connection_id, received_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_status ON webhook_events(status);
@@ -0,0 +1,18 @@
+-- Idempotency Keys - Responses to writes made with an Idempotency-Key header.
+-- A retry with the same key gets the stored response instead of repeating
+-- the write. status_code is NULL while the first request is in progress.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id                    BIGSERIAL PRIMARY KEY,
+    connection_id         BIGINT NOT NULL REFERENCES platform_connections(id) ON DELETE CASCADE,
+    idempotency_key       VARCHAR(255) NOT NULL,
+    user_id               BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    request_hash          VARCHAR(64) NOT NULL,  -- SHA-256 of method, path and body
+    status_code           INTEGER,
+    content_type          VARCHAR(100),
+    response_body         BYTEA,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at          TIMESTAMPTZ,
+    UNIQUE(connection_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created ON idempotency_keys(created_at);
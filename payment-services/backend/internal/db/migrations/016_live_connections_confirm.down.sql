@@ -0,0 +1,4 @@
+-- Deliberately irreversible: 016 does not record which live connections it
+-- moved from read_write to confirm_required, so a revert could only guess.
+-- The modes are left as they are; change them per connection if needed.
+SELECT 1;
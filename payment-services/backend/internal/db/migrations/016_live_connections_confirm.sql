@@ -0,0 +1,5 @@
+-- Live Connection Mode - Writes to a live connection used to need a
+-- confirmation token whatever its mode; now only confirm_required does.
+-- Keep that protection for live connections still on read_write; an admin
+-- can set read_write again to allow unconfirmed live writes.
+UPDATE platform_connections SET mode = 'confirm_required' WHERE NOT is_sandbox AND mode = 'read_write';
@@ -0,0 +1,4 @@
+-- Idempotency Lease - When a request last claimed its key. A key still in
+-- progress (status_code NULL) long after locked_at was left by a request
+-- that never finished, and a retry of the same request may take it over.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
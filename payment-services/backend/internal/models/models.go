@@ -1,14 +1,18 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // PlatformType represents supported payment platforms
 type PlatformType string
 
 const (
-	PlatformMaxio  PlatformType = "maxio"
-	PlatformZuora  PlatformType = "zuora"
-	PlatformStripe PlatformType = "stripe"
+	PlatformMaxio   PlatformType = "maxio"
+	PlatformZuora   PlatformType = "zuora"
+	PlatformStripe  PlatformType = "stripe"
+	PlatformRecurly PlatformType = "recurly"
 )
 
 // ConnectionStatus represents the state of a platform connection
@@ -20,6 +24,29 @@ const (
 	StatusError     ConnectionStatus = "error"
 )
 
+// ConnectionMode controls which writes the hub passes through to a platform
+type ConnectionMode string
+
+const (
+	ModeReadOnly        ConnectionMode = "read_only"        // reject every write
+	ModeReadWrite       ConnectionMode = "read_write"       // allow writes; live writes still need a token
+	ModeConfirmRequired ConnectionMode = "confirm_required" // every write needs a confirmation token
+)
+
+// Valid reports whether m is a known mode
+func (m ConnectionMode) Valid() bool {
+	return m == ModeReadOnly || m == ModeReadWrite || m == ModeConfirmRequired
+}
+
+// DefaultConnectionMode is the mode for a new connection that does not choose
+// one: live connections require confirmation, sandboxes do not
+func DefaultConnectionMode(isSandbox bool) ConnectionMode {
+	if isSandbox {
+		return ModeReadWrite
+	}
+	return ModeConfirmRequired
+}
+
 // PlatformConnection represents a connection to a payment platform
 type PlatformConnection struct {
 	ID           int64            `json:"id"`
@@ -28,6 +55,7 @@ type PlatformConnection struct {
 	Subdomain    string           `json:"subdomain,omitempty"`
 	BaseURL      string           `json:"base_url,omitempty"` // Used by Zuora for different data centers
 	IsSandbox    bool             `json:"is_sandbox"`
+	Mode         ConnectionMode   `json:"mode"`
 	Status       ConnectionStatus `json:"status"`
 	ErrorMessage string           `json:"error_message,omitempty"`
 	LastSyncAt   *time.Time       `json:"last_sync_at,omitempty"`
@@ -44,6 +72,48 @@ type PlatformCredential struct {
 	CreatedAt       time.Time `json:"created_at"`
 }
 
+// User represents an account that can sign in to the hub
+type User struct {
+	ID          int64      `json:"id"`
+	Username    string     `json:"username"`
+	DisplayName string     `json:"display_name,omitempty"`
+	IsActive    bool       `json:"is_active"`
+	IsAdmin     bool       `json:"is_admin"` // has the admin role on every connection
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Role is what a user may do on a connection
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // read data
+	RoleOperator Role = "operator" // also create and update records, test and sync
+	RoleAdmin    Role = "admin"    // also delete and cancel, change credentials and roles
+)
+
+// roleLevels orders roles so that each includes the ones below it
+var roleLevels = map[Role]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	return roleLevels[r] > 0
+}
+
+// Includes reports whether r grants everything required grants
+func (r Role) Includes(required Role) bool {
+	return r.Valid() && roleLevels[r] >= roleLevels[required]
+}
+
+// ConnectionRole grants a user a role on a connection
+type ConnectionRole struct {
+	UserID       int64     `json:"user_id"`
+	Username     string    `json:"username"`
+	ConnectionID int64     `json:"connection_id"`
+	Role         Role      `json:"role"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 // Customer represents a customer from any platform
 type Customer struct {
 	ID           string                 `json:"id"`
@@ -103,6 +173,67 @@ type Product struct {
 	RawData     map[string]interface{} `json:"raw_data,omitempty"`
 }
 
+// SyncStatus represents the state of a sync run
+type SyncStatus string
+
+const (
+	SyncRunning   SyncStatus = "running"
+	SyncSucceeded SyncStatus = "succeeded"
+	SyncFailed    SyncStatus = "failed"
+)
+
+// SyncMode says whether a sync run fetches everything or only changed records
+type SyncMode string
+
+const (
+	SyncFull        SyncMode = "full"
+	SyncIncremental SyncMode = "incremental"
+)
+
+// SyncRun records one attempt to copy a connection's data into the local cache
+type SyncRun struct {
+	ID                  int64      `json:"id"`
+	ConnectionID        int64      `json:"connection_id"`
+	Mode                SyncMode   `json:"mode"`
+	Status              SyncStatus `json:"status"`
+	CustomersSynced     int        `json:"customers_synced"`
+	SubscriptionsSynced int        `json:"subscriptions_synced"`
+	InvoicesSynced      int        `json:"invoices_synced"`
+	PaymentsSynced      int        `json:"payments_synced"`
+	ProductsSynced      int        `json:"products_synced"`
+	ErrorMessage        string     `json:"error_message,omitempty"`
+	StartedAt           time.Time  `json:"started_at"`
+	FinishedAt          *time.Time `json:"finished_at,omitempty"`
+}
+
+// WebhookStatus represents how a received webhook event was handled
+type WebhookStatus string
+
+const (
+	WebhookReceived  WebhookStatus = "received"
+	WebhookProcessed WebhookStatus = "processed"
+	WebhookIgnored   WebhookStatus = "ignored" // no handler for the event type
+	WebhookFailed    WebhookStatus = "failed"
+)
+
+// AuditEvent records one mutating call made through the hub
+type AuditEvent struct {
+	ID             int64           `json:"id"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	ActorUserID    *int64          `json:"actor_user_id,omitempty"`
+	ActorUsername  string          `json:"actor_username,omitempty"`
+	ConnectionID   *int64          `json:"connection_id,omitempty"`
+	PlatformType   string          `json:"platform_type,omitempty"`
+	Method         string          `json:"method"`
+	Route          string          `json:"route"`
+	Path           string          `json:"path"`
+	TargetID       string          `json:"target_id,omitempty"`
+	RequestBody    json.RawMessage `json:"request_body,omitempty"`
+	StatusCode     int             `json:"status_code"`
+	UpstreamStatus *int            `json:"upstream_status,omitempty"`
+	RemoteAddr     string          `json:"remote_addr,omitempty"`
+}
+
 // TreeNode represents a node in the UI tree
 type TreeNode struct {
 	ID           string      `json:"id"`
@@ -125,6 +256,64 @@ type CreateConnectionRequest struct {
 	ClientID     string       `json:"client_id,omitempty"`     // Used by Zuora
 	ClientSecret string       `json:"client_secret,omitempty"` // Used by Zuora
 	IsSandbox    bool         `json:"is_sandbox"`
+
+	// Mode defaults to DefaultConnectionMode(IsSandbox)
+	Mode ConnectionMode `json:"mode,omitempty"`
+
+	// Credentials holds credential values keyed by credential type, for
+	// platforms whose credentials are not covered by the fields above
+	Credentials map[string]string `json:"credentials,omitempty"`
+
+	// CredentialRefs holds secret references (env://NAME, file:///path,
+	// vault://mount/path#field) keyed by credential type. A referenced
+	// credential is resolved when the connection is used and never stored.
+	CredentialRefs map[string]string `json:"credential_refs,omitempty"`
+}
+
+// CredentialValues returns all supplied credentials keyed by credential type
+func (r CreateConnectionRequest) CredentialValues() map[string]string {
+	return credentialValues(r.Credentials, r.APIKey, r.ClientID, r.ClientSecret)
+}
+
+// UpdateConnectionRequest is the request body for updating a connection.
+// Credentials that are not supplied keep their stored values.
+type UpdateConnectionRequest struct {
+	Name         string         `json:"name"`
+	Subdomain    string         `json:"subdomain"`
+	BaseURL      string         `json:"base_url"`
+	IsSandbox    bool           `json:"is_sandbox"`
+	Mode         ConnectionMode `json:"mode,omitempty"`          // unchanged when empty
+	APIKey       string         `json:"api_key,omitempty"`       // Used by Maxio, Stripe
+	ClientID     string         `json:"client_id,omitempty"`     // Used by Zuora
+	ClientSecret string         `json:"client_secret,omitempty"` // Used by Zuora
+
+	Credentials    map[string]string `json:"credentials,omitempty"`
+	CredentialRefs map[string]string `json:"credential_refs,omitempty"`
+}
+
+// CredentialValues returns all supplied credentials keyed by credential type
+func (r UpdateConnectionRequest) CredentialValues() map[string]string {
+	return credentialValues(r.Credentials, r.APIKey, r.ClientID, r.ClientSecret)
+}
+
+// credentialValues merges the named credential fields into the credentials map
+func credentialValues(credentials map[string]string, apiKey, clientID, clientSecret string) map[string]string {
+	values := make(map[string]string, len(credentials)+3)
+	for k, v := range credentials {
+		if v != "" {
+			values[k] = v
+		}
+	}
+	if apiKey != "" {
+		values["api_key"] = apiKey
+	}
+	if clientID != "" {
+		values["client_id"] = clientID
+	}
+	if clientSecret != "" {
+		values["client_secret"] = clientSecret
+	}
+	return values
 }
 
 // CreateCustomerRequest is the request body for creating a customer
@@ -0,0 +1,93 @@
+package platforms
+
+import (
+	"sync"
+	"time"
+)
+
+// ProviderCache holds built providers keyed by connection ID and the
+// connection's config version. A provider is reused only while the stored
+// version matches, so any change to a connection's settings or credentials
+// causes the next lookup to build a new one. Safe for concurrent use.
+type ProviderCache struct {
+	mu      sync.Mutex
+	entries map[int64]*cacheEntry
+}
+
+type cacheEntry struct {
+	version   int64
+	expiresAt time.Time // zero means no expiry
+	provider  BillingProvider
+
+	// ready is closed once provider or err is set; callers for the same
+	// connection and version wait on it instead of building again
+	ready chan struct{}
+	err   error
+}
+
+// NewProviderCache creates an empty cache
+func NewProviderCache() *ProviderCache {
+	return &ProviderCache{entries: make(map[int64]*cacheEntry)}
+}
+
+// Get returns the provider cached for a connection at version, building it
+// with build if there is none, it was built for another version or it has
+// expired. Concurrent callers for the same connection and version share one
+// build, and its error. build returns how long the provider may be reused;
+// zero means until the version changes. Failed builds are not cached.
+func (c *ProviderCache) Get(connectionID, version int64, build func() (BillingProvider, time.Duration, error)) (BillingProvider, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[connectionID]; ok && entry.version == version &&
+		(entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt)) {
+		c.mu.Unlock()
+		<-entry.ready
+		return entry.provider, entry.err
+	}
+
+	previous := c.entries[connectionID]
+	entry := &cacheEntry{version: version, ready: make(chan struct{})}
+	c.entries[connectionID] = entry
+	c.mu.Unlock()
+	previous.discard()
+
+	provider, ttl, err := build()
+
+	c.mu.Lock()
+	entry.provider, entry.err = provider, err
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	if err != nil && c.entries[connectionID] == entry {
+		delete(c.entries, connectionID)
+	}
+	c.mu.Unlock()
+	close(entry.ready)
+
+	return provider, err
+}
+
+// Invalidate drops the provider cached for a connection and resets any
+// credential state it holds
+func (c *ProviderCache) Invalidate(connectionID int64) {
+	c.mu.Lock()
+	entry := c.entries[connectionID]
+	delete(c.entries, connectionID)
+	c.mu.Unlock()
+	entry.discard()
+}
+
+// discard resets the credential state of a replaced entry's provider. An
+// entry still being built is left alone; its provider is never cached.
+func (e *cacheEntry) discard() {
+	if e == nil {
+		return
+	}
+	select {
+	case <-e.ready:
+	default:
+		return
+	}
+	if resetter, ok := e.provider.(CredentialResetter); ok {
+		resetter.ResetCredentials()
+	}
+}
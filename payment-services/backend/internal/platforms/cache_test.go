@@ -0,0 +1,199 @@
+package platforms
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProvider counts credential resets; the embedded interface is nil, so
+// calling any other method panics
+type fakeProvider struct {
+	BillingProvider
+	id     int
+	resets atomic.Int32
+}
+
+func (p *fakeProvider) ResetCredentials() {
+	p.resets.Add(1)
+}
+
+// builder returns a build function that makes a new fakeProvider each call
+// and reuses it for ttl
+func builder(builds *atomic.Int32, ttl time.Duration) func() (BillingProvider, time.Duration, error) {
+	return func() (BillingProvider, time.Duration, error) {
+		return &fakeProvider{id: int(builds.Add(1))}, ttl, nil
+	}
+}
+
+func TestProviderCacheTTLExpiry(t *testing.T) {
+	cache := NewProviderCache()
+	var builds atomic.Int32
+	const ttl = 20 * time.Millisecond
+
+	first, err := cache.Get(1, 1, builder(&builds, ttl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := cache.Get(1, 1, builder(&builds, ttl))
+	if again != first || builds.Load() != 1 {
+		t.Fatalf("provider rebuilt before its TTL: %d builds", builds.Load())
+	}
+
+	time.Sleep(2 * ttl)
+	expired, _ := cache.Get(1, 1, builder(&builds, ttl))
+	if expired == first || builds.Load() != 2 {
+		t.Fatalf("provider reused after its TTL: %d builds", builds.Load())
+	}
+	if resets := first.(*fakeProvider).resets.Load(); resets != 1 {
+		t.Errorf("expired provider reset %d times, want 1", resets)
+	}
+}
+
+func TestProviderCacheWithoutTTLNeverExpires(t *testing.T) {
+	cache := NewProviderCache()
+	var builds atomic.Int32
+
+	first, _ := cache.Get(1, 1, builder(&builds, 0))
+	time.Sleep(10 * time.Millisecond)
+	again, _ := cache.Get(1, 1, builder(&builds, 0))
+	if again != first || builds.Load() != 1 {
+		t.Errorf("provider without a TTL rebuilt: %d builds", builds.Load())
+	}
+}
+
+func TestProviderCacheDiscardResetsReplacedProvider(t *testing.T) {
+	cache := NewProviderCache()
+	var builds atomic.Int32
+
+	v1, _ := cache.Get(1, 1, builder(&builds, 0))
+	v2, _ := cache.Get(1, 2, builder(&builds, 0))
+	if v2 == v1 {
+		t.Fatal("provider reused across config versions")
+	}
+	if resets := v1.(*fakeProvider).resets.Load(); resets != 1 {
+		t.Errorf("provider replaced by a version bump reset %d times, want 1", resets)
+	}
+
+	cache.Invalidate(1)
+	if resets := v2.(*fakeProvider).resets.Load(); resets != 1 {
+		t.Errorf("invalidated provider reset %d times, want 1", resets)
+	}
+	cache.Invalidate(1)
+	if resets := v2.(*fakeProvider).resets.Load(); resets != 1 {
+		t.Errorf("second Invalidate reset the provider again: %d resets", resets)
+	}
+
+	v3, _ := cache.Get(1, 2, builder(&builds, 0))
+	if v3 == v2 {
+		t.Error("provider reused after Invalidate")
+	}
+}
+
+func TestProviderCacheSharesConcurrentBuilds(t *testing.T) {
+	cache := NewProviderCache()
+	var builds atomic.Int32
+	release := make(chan struct{})
+	build := func() (BillingProvider, time.Duration, error) {
+		<-release
+		return &fakeProvider{id: int(builds.Add(1))}, 0, nil
+	}
+
+	var wg sync.WaitGroup
+	providers := make([]BillingProvider, 20)
+	for i := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			providers[i], _ = cache.Get(1, 1, build)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if builds.Load() != 1 {
+		t.Errorf("%d builds for one connection and version, want 1", builds.Load())
+	}
+	for _, p := range providers {
+		if p != providers[0] {
+			t.Fatal("concurrent callers got different providers")
+		}
+	}
+}
+
+func TestProviderCacheFailedBuildNotCached(t *testing.T) {
+	cache := NewProviderCache()
+	fail := errors.New("bad credentials")
+
+	if _, err := cache.Get(1, 1, func() (BillingProvider, time.Duration, error) { return nil, 0, fail }); err != fail {
+		t.Fatalf("Get = %v, want the build error", err)
+	}
+	var builds atomic.Int32
+	if p, err := cache.Get(1, 1, builder(&builds, 0)); err != nil || p == nil || builds.Load() != 1 {
+		t.Errorf("Get after a failed build = %v, %v with %d builds; want a fresh build", p, err, builds.Load())
+	}
+}
+
+// TestProviderCacheConcurrentUse is meant for go test -race: it mixes Get,
+// Invalidate and config version bumps across a few connections. A provider
+// replaced while still being built is never cached and so never reset; every
+// other one the cache lets go of is reset exactly once.
+func TestProviderCacheConcurrentUse(t *testing.T) {
+	cache := NewProviderCache()
+
+	var mu sync.Mutex
+	var built []*fakeProvider
+	build := func() (BillingProvider, time.Duration, error) {
+		p := &fakeProvider{}
+		mu.Lock()
+		built = append(built, p)
+		mu.Unlock()
+		return p, 0, nil
+	}
+
+	const connections = 4
+	var versions [connections]atomic.Int64
+	var wg sync.WaitGroup
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				id := int64((worker + i) % connections)
+				switch i % 10 {
+				case 0:
+					versions[id].Add(1)
+				case 1:
+					cache.Invalidate(id)
+				default:
+					p, err := cache.Get(id, versions[id].Load(), build)
+					if err != nil || p == nil {
+						t.Errorf("Get(%d) = %v, %v", id, p, err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	for i, p := range built {
+		if resets := p.resets.Load(); resets > 1 {
+			t.Errorf("provider %d of %d reset %d times, want at most 1", i, len(built), resets)
+		}
+	}
+	mu.Unlock()
+
+	// What is still cached is reset when it is dropped
+	for id := int64(0); id < connections; id++ {
+		p, _ := cache.Get(id, versions[id].Load(), build)
+		cache.Invalidate(id)
+		if resets := p.(*fakeProvider).resets.Load(); resets != 1 {
+			t.Errorf("provider dropped from connection %d reset %d times, want 1", id, resets)
+		}
+	}
+}
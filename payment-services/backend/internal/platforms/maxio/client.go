@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -25,11 +26,15 @@ func NewAPIError(statusCode int, message string) *APIError {
 	return &APIError{StatusCode: statusCode, Message: message}
 }
 
+// DefaultPageSize is the per_page used when a list call does not give one
+const DefaultPageSize = 50
+
 // Client is the Maxio API client
 type Client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	observe    func(status int) // see WithResponseObserver
 }
 
 // NewClient creates a new Maxio API client
@@ -41,6 +46,14 @@ func NewClient(subdomain, apiKey string) *Client {
 	}
 }
 
+// WithResponseObserver returns a copy of the client that calls observe with
+// the status of every response it receives from Maxio
+func (c *Client) WithResponseObserver(observe func(status int)) *Client {
+	clone := *c
+	clone.observe = observe
+	return &clone
+}
+
 // doRequest performs an HTTP request to the Maxio API
 func (c *Client) doRequest(method, path string, body interface{}) (*http.Response, error) {
 	var bodyReader io.Reader
@@ -63,7 +76,11 @@ func (c *Client) doRequest(method, path string, body interface{}) (*http.Respons
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	return c.httpClient.Do(req)
+	resp, err := c.httpClient.Do(req)
+	if err == nil && c.observe != nil {
+		c.observe(resp.StatusCode)
+	}
+	return resp, err
 }
 
 // TestConnection tests the API connection
@@ -86,16 +103,27 @@ func (c *Client) TestConnection() error {
 	return nil
 }
 
-// ListCustomers returns a list of customers
-func (c *Client) ListCustomers(page, perPage int) ([]Customer, error) {
+// updatedSinceParams builds the query string that filters a list on updated_at
+func updatedSinceParams(updatedSince *time.Time) string {
+	if updatedSince == nil {
+		return ""
+	}
+	params := url.Values{}
+	params.Set("date_field", "updated_at")
+	params.Set("start_datetime", updatedSince.UTC().Format(time.RFC3339))
+	return "&" + params.Encode()
+}
+
+// ListCustomers returns a list of customers, optionally only those updated since the given time
+func (c *Client) ListCustomers(page, perPage int, updatedSince *time.Time) ([]Customer, error) {
 	if perPage <= 0 {
-		perPage = 50
+		perPage = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
 	}
 
-	path := fmt.Sprintf("/customers.json?page=%d&per_page=%d", page, perPage)
+	path := fmt.Sprintf("/customers.json?page=%d&per_page=%d", page, perPage) + updatedSinceParams(updatedSince)
 	resp, err := c.doRequest("GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -193,16 +221,16 @@ func (c *Client) UpdateCustomer(id int64, input CustomerInput) (*Customer, error
 	return &wrapper.Customer, nil
 }
 
-// ListSubscriptions returns a list of subscriptions
-func (c *Client) ListSubscriptions(page, perPage int) ([]Subscription, error) {
+// ListSubscriptions returns a list of subscriptions, optionally only those updated since the given time
+func (c *Client) ListSubscriptions(page, perPage int, updatedSince *time.Time) ([]Subscription, error) {
 	if perPage <= 0 {
-		perPage = 50
+		perPage = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
 	}
 
-	path := fmt.Sprintf("/subscriptions.json?page=%d&per_page=%d", page, perPage)
+	path := fmt.Sprintf("/subscriptions.json?page=%d&per_page=%d", page, perPage) + updatedSinceParams(updatedSince)
 	resp, err := c.doRequest("GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -279,7 +307,7 @@ func (c *Client) CreateSubscription(input SubscriptionInput) (*Subscription, err
 // ListProducts returns a list of products
 func (c *Client) ListProducts(page, perPage int) ([]Product, error) {
 	if perPage <= 0 {
-		perPage = 50
+		perPage = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
@@ -313,7 +341,7 @@ func (c *Client) ListProducts(page, perPage int) ([]Product, error) {
 // ListProductFamilies returns a list of product families
 func (c *Client) ListProductFamilies(page, perPage int) ([]ProductFamily, error) {
 	if perPage <= 0 {
-		perPage = 50
+		perPage = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
@@ -471,7 +499,7 @@ func (c *Client) GetProduct(id string) (*Product, error) {
 // ListInvoices returns a list of invoices
 func (c *Client) ListInvoices(page, perPage int) ([]Invoice, error) {
 	if perPage <= 0 {
-		perPage = 50
+		perPage = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
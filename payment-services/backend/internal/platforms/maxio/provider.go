@@ -0,0 +1,265 @@
+package maxio
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// Provider adapts the Maxio client to the platforms.BillingProvider interface
+type Provider struct {
+	client *Client
+}
+
+var (
+	_ platforms.BillingProvider    = (*Provider)(nil)
+	_ platforms.ResponseObservable = (*Provider)(nil)
+)
+
+// NewProvider wraps a Maxio client as a BillingProvider
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+// Client returns the underlying Maxio client for platform-specific calls
+func (p *Provider) Client() *Client {
+	return p.client
+}
+
+// WithResponseObserver returns a provider whose calls report the status of
+// every Maxio response to observe
+func (p *Provider) WithResponseObserver(observe func(status int)) platforms.BillingProvider {
+	return NewProvider(p.client.WithResponseObserver(observe))
+}
+
+// Platform returns the platform type
+func (p *Provider) Platform() models.PlatformType {
+	return models.PlatformMaxio
+}
+
+// TestConnection tests the API connection
+func (p *Provider) TestConnection() error {
+	return p.client.TestConnection()
+}
+
+// pageParams converts a cursor into Maxio page/per_page values
+func pageParams(params platforms.ListParams) (int, int) {
+	page, _ := strconv.Atoi(params.Cursor)
+	if page <= 0 {
+		page = 1
+	}
+	perPage := params.Limit
+	if perPage <= 0 {
+		perPage = 50
+	}
+	return page, perPage
+}
+
+// nextPage returns the cursor for the following page when the current one was full
+func nextPage(page, perPage, count int) string {
+	if count < perPage {
+		return ""
+	}
+	return strconv.Itoa(page + 1)
+}
+
+// ListCustomers returns a page of normalized customers
+func (p *Provider) ListCustomers(params platforms.ListParams) ([]models.Customer, string, error) {
+	page, perPage := pageParams(params)
+	customers, err := p.client.ListCustomers(page, perPage, params.UpdatedSince)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Customer, len(customers))
+	for i, customer := range customers {
+		result[i] = customerToModel(customer)
+	}
+	return result, nextPage(page, perPage, len(customers)), nil
+}
+
+// GetCustomer returns a single normalized customer
+func (p *Provider) GetCustomer(id string) (*models.Customer, error) {
+	customer, err := p.client.GetCustomer(id)
+	if err != nil {
+		return nil, err
+	}
+	result := customerToModel(*customer)
+	return &result, nil
+}
+
+// CreateCustomer creates a customer from the platform-agnostic request
+func (p *Provider) CreateCustomer(input models.CreateCustomerRequest) (*models.Customer, error) {
+	customer, err := p.client.CreateCustomer(CustomerInput{
+		FirstName:    input.FirstName,
+		LastName:     input.LastName,
+		Email:        input.Email,
+		Organization: input.Organization,
+		Reference:    input.Reference,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := customerToModel(*customer)
+	return &result, nil
+}
+
+// ListSubscriptions returns a page of normalized subscriptions
+func (p *Provider) ListSubscriptions(params platforms.ListParams) ([]models.Subscription, string, error) {
+	page, perPage := pageParams(params)
+	subscriptions, err := p.client.ListSubscriptions(page, perPage, params.UpdatedSince)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Subscription, len(subscriptions))
+	for i, sub := range subscriptions {
+		result[i] = subscriptionToModel(sub)
+	}
+	return result, nextPage(page, perPage, len(subscriptions)), nil
+}
+
+// GetSubscription returns a single normalized subscription
+func (p *Provider) GetSubscription(id string) (*models.Subscription, error) {
+	subscription, err := p.client.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	result := subscriptionToModel(*subscription)
+	return &result, nil
+}
+
+// ListInvoices returns a page of normalized invoices
+func (p *Provider) ListInvoices(params platforms.ListParams) ([]models.Invoice, string, error) {
+	page, perPage := pageParams(params)
+	invoices, err := p.client.ListInvoices(page, perPage)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Invoice, len(invoices))
+	for i, invoice := range invoices {
+		result[i] = invoiceToModel(invoice)
+	}
+	return result, nextPage(page, perPage, len(invoices)), nil
+}
+
+// ListPayments returns the payments recorded against a page of invoices.
+// Maxio has no top-level payments listing, so payments are read from invoices.
+func (p *Provider) ListPayments(params platforms.ListParams) ([]models.Payment, string, error) {
+	page, perPage := pageParams(params)
+	invoices, err := p.client.ListInvoices(page, perPage)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var result []models.Payment
+	for _, invoice := range invoices {
+		for _, payment := range invoicePayments(invoice) {
+			result = append(result, paymentToModel(payment, invoice.Currency))
+		}
+	}
+	return result, nextPage(page, perPage, len(invoices)), nil
+}
+
+// ListProducts returns a page of normalized products
+func (p *Provider) ListProducts(params platforms.ListParams) ([]models.Product, string, error) {
+	page, perPage := pageParams(params)
+	products, err := p.client.ListProducts(page, perPage)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Product, len(products))
+	for i, product := range products {
+		result[i] = productToModel(product)
+	}
+	return result, nextPage(page, perPage, len(products)), nil
+}
+
+func customerToModel(customer Customer) models.Customer {
+	return models.Customer{
+		ID:           strconv.FormatInt(customer.ID, 10),
+		Reference:    customer.Reference,
+		FirstName:    customer.FirstName,
+		LastName:     customer.LastName,
+		Email:        customer.Email,
+		Organization: customer.Organization,
+		CreatedAt:    customer.CreatedAt,
+		RawData:      platforms.RawData(customer),
+	}
+}
+
+func subscriptionToModel(sub Subscription) models.Subscription {
+	result := models.Subscription{
+		ID:                 strconv.FormatInt(sub.ID, 10),
+		State:              sub.State,
+		CurrentPeriodStart: sub.CurrentPeriodStartedAt,
+		CurrentPeriodEnd:   sub.CurrentPeriodEndsAt,
+		CreatedAt:          sub.CreatedAt,
+		RawData:            platforms.RawData(sub),
+	}
+	if sub.Customer != nil {
+		result.CustomerID = strconv.FormatInt(sub.Customer.ID, 10)
+	}
+	if sub.Product != nil {
+		result.ProductName = sub.Product.Name
+	}
+	return result
+}
+
+func invoiceToModel(invoice Invoice) models.Invoice {
+	return models.Invoice{
+		ID:         invoice.UID,
+		Number:     invoice.Number,
+		CustomerID: strconv.FormatInt(invoice.CustomerID, 10),
+		Status:     invoice.Status,
+		Total:      invoice.TotalAmount,
+		Currency:   invoice.Currency,
+		DueDate:    platforms.ParseDate(invoice.DueDate),
+		CreatedAt:  invoice.CreatedAt,
+		RawData:    platforms.RawData(invoice),
+	}
+}
+
+// invoicePayments decodes the loosely typed payments attached to an invoice
+func invoicePayments(invoice Invoice) []Payment {
+	if invoice.Payments == nil {
+		return nil
+	}
+	data, err := json.Marshal(invoice.Payments)
+	if err != nil {
+		return nil
+	}
+	var payments []Payment
+	if err := json.Unmarshal(data, &payments); err != nil {
+		return nil
+	}
+	return payments
+}
+
+func paymentToModel(payment Payment, currency string) models.Payment {
+	return models.Payment{
+		ID:        strconv.FormatInt(payment.TransactionID, 10),
+		Amount:    payment.AppliedAmount,
+		Currency:  currency,
+		Status:    payment.TransactionType,
+		CreatedAt: payment.TransactionTime,
+		RawData:   platforms.RawData(payment),
+	}
+}
+
+func productToModel(product Product) models.Product {
+	return models.Product{
+		ID:          strconv.FormatInt(product.ID, 10),
+		Name:        product.Name,
+		Handle:      product.Handle,
+		Description: product.Description,
+		Price:       platforms.FormatCents(product.PriceInCents),
+		Interval:    strconv.Itoa(product.Interval) + " " + product.IntervalUnit,
+		CreatedAt:   product.CreatedAt,
+		RawData:     platforms.RawData(product),
+	}
+}
@@ -0,0 +1,31 @@
+package maxio
+
+import (
+	"fmt"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+func init() {
+	platforms.Register(platforms.Definition{
+		Type:        models.PlatformMaxio,
+		DisplayName: "Maxio (Chargify)",
+		Credentials: []platforms.CredentialField{
+			{Type: "api_key", Label: "API key", Required: true},
+		},
+		TreeContainers: []platforms.TreeContainer{
+			{Type: "customers", Name: "Customers"},
+			{Type: "subscriptions", Name: "Subscriptions"},
+			{Type: "product-families", Name: "Product Families"},
+			{Type: "invoices", Name: "Invoices"},
+			{Type: "payments", Name: "Payments"},
+		},
+		NewProvider: func(cfg platforms.ConnectionConfig) (platforms.BillingProvider, error) {
+			if cfg.Subdomain == "" {
+				return nil, fmt.Errorf("subdomain is required for Maxio")
+			}
+			return NewProvider(NewClient(cfg.Subdomain, cfg.Credentials["api_key"])), nil
+		},
+	})
+}
@@ -0,0 +1,163 @@
+package platforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// ErrNotSupported is returned when a platform has no equivalent for an operation
+var ErrNotSupported = errors.New("operation not supported by this platform")
+
+// ListParams controls pagination for provider list calls
+type ListParams struct {
+	// Cursor is the opaque value returned as NextCursor by a previous call.
+	// Page-based platforms encode a page number; cursor-based platforms pass
+	// through the vendor cursor.
+	Cursor string
+	Limit  int
+
+	// UpdatedSince, when set, restricts the list to records changed after
+	// that time. Platforms that can only filter on creation time (Stripe)
+	// return records created after it instead.
+	UpdatedSince *time.Time
+}
+
+// BillingProvider is the platform-agnostic view of a billing platform.
+// Each platform package wraps its Client in an adapter implementing this
+// interface and returns the normalized models types. List methods return
+// the cursor for the next page, or an empty string when there are no more.
+type BillingProvider interface {
+	Platform() models.PlatformType
+	TestConnection() error
+
+	ListCustomers(params ListParams) ([]models.Customer, string, error)
+	GetCustomer(id string) (*models.Customer, error)
+	CreateCustomer(input models.CreateCustomerRequest) (*models.Customer, error)
+
+	ListSubscriptions(params ListParams) ([]models.Subscription, string, error)
+	GetSubscription(id string) (*models.Subscription, error)
+
+	ListInvoices(params ListParams) ([]models.Invoice, string, error)
+	ListPayments(params ListParams) ([]models.Payment, string, error)
+	ListProducts(params ListParams) ([]models.Product, string, error)
+}
+
+// ResponseObservable is implemented by providers that can report the HTTP
+// status of each response their platform returns, e.g. for an audit log.
+// WithResponseObserver returns a copy; the original is unchanged.
+type ResponseObservable interface {
+	WithResponseObserver(observe func(status int)) BillingProvider
+}
+
+// EnvironmentReport says whether a connection's credentials reach a sandbox
+// or a live account, as observed from the platform itself
+type EnvironmentReport struct {
+	IsSandbox bool   `json:"is_sandbox"`
+	Source    string `json:"source"` // what the answer is based on, e.g. "livemode" or "base_url"
+
+	// Restricted is set for keys limited to some resources; Permissions then
+	// maps each probed resource to "read" or "none"
+	Restricted  bool              `json:"restricted,omitempty"`
+	Permissions map[string]string `json:"permissions,omitempty"`
+}
+
+// EnvironmentDetector is implemented by providers that can tell which
+// environment their credentials belong to. DetectEnvironment returns nil
+// when it cannot tell.
+type EnvironmentDetector interface {
+	DetectEnvironment() (*EnvironmentReport, error)
+}
+
+// CredentialResetter is implemented by providers that keep state derived
+// from their credentials, such as an OAuth access token. ResetCredentials
+// discards it so nothing obtained with old credentials is reused.
+type CredentialResetter interface {
+	ResetCredentials()
+}
+
+// RawData converts a vendor payload into the generic map stored in the
+// RawData field of the normalized models
+func RawData(v interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	return raw
+}
+
+// UnixTime converts a Unix timestamp to a time pointer, treating zero as unset
+func UnixTime(ts int64) *time.Time {
+	if ts == 0 {
+		return nil
+	}
+	t := time.Unix(ts, 0).UTC()
+	return &t
+}
+
+// ParseDate parses a date-only (2006-01-02) or RFC 3339 string, returning nil if it is empty or invalid
+func ParseDate(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// FormatCents formats an amount in cents as a decimal string with two places
+func FormatCents(cents int64) string {
+	return formatMinorUnits(cents, 2)
+}
+
+// minorUnitDigits lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major unit; every other currency has two decimal places
+var minorUnitDigits = map[string]int{
+	// Zero-decimal
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	// Three-decimal
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyDigits returns the number of decimal places in a currency's minor
+// unit. The code is case-insensitive, since Stripe reports it in lower case.
+func CurrencyDigits(currency string) int {
+	if digits, ok := minorUnitDigits[strings.ToUpper(currency)]; ok {
+		return digits
+	}
+	return 2
+}
+
+// FormatAmount formats an amount in a currency's minor units as a decimal
+// string, e.g. 1050 USD as "10.50", 1050 JPY as "1050" and 1050 KWD as "1.050"
+func FormatAmount(amount int64, currency string) string {
+	return formatMinorUnits(amount, CurrencyDigits(currency))
+}
+
+func formatMinorUnits(amount int64, digits int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if digits == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+	scale := int64(1)
+	for i := 0; i < digits; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, digits, amount%scale)
+}
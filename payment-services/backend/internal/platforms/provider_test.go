@@ -0,0 +1,37 @@
+package platforms
+
+import "testing"
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{1050, "usd", "10.50"},
+		{1050, "USD", "10.50"},
+		{5, "eur", "0.05"},
+		{-1050, "gbp", "-10.50"},
+		{0, "usd", "0.00"},
+		{1050, "", "10.50"},
+		{1050, "jpy", "1050"},
+		{-500, "krw", "-500"},
+		{0, "jpy", "0"},
+		{1050, "kwd", "1.050"},
+		{5, "bhd", "0.005"},
+		{-12345, "OMR", "-12.345"},
+	}
+	for _, tt := range tests {
+		if got := FormatAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("FormatAmount(%d, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCents(t *testing.T) {
+	for cents, want := range map[int64]string{1050: "10.50", 7: "0.07", -199: "-1.99", 0: "0.00"} {
+		if got := FormatCents(cents); got != want {
+			t.Errorf("FormatCents(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package recurly
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the Recurly v3 API endpoint for US-hosted sites
+	DefaultBaseURL = "https://v3.recurly.com"
+
+	// EUBaseURL is the Recurly v3 API endpoint for EU-hosted sites
+	EUBaseURL = "https://v3.eu.recurly.com"
+
+	// apiVersion pins the API version so response shapes stay stable
+	apiVersion = "application/vnd.recurly.v2021-02-25+json"
+
+	// DefaultListLimit is the limit used when a list call does not give one
+	DefaultListLimit = 200
+)
+
+// Client is the Recurly API client
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	observe    func(status int) // see WithResponseObserver
+}
+
+// NewClient creates a new Recurly API client. An empty baseURL selects the
+// US endpoint; tests can point it at an httptest server instead.
+func NewClient(baseURL, apiKey string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithResponseObserver returns a copy of the client that calls observe with
+// the status of every response it receives from Recurly
+func (c *Client) WithResponseObserver(observe func(status int)) *Client {
+	clone := *c
+	clone.observe = observe
+	return &clone
+}
+
+// doRequest performs an HTTP request to the Recurly API
+func (c *Client) doRequest(method, path string, body interface{}) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		bodyReader = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Basic auth with the API key as username and an empty password
+	req.SetBasicAuth(c.apiKey, "")
+	req.Header.Set("Accept", apiVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err == nil && c.observe != nil {
+		c.observe(resp.StatusCode)
+	}
+	return resp, err
+}
+
+// parseError parses an error response from Recurly
+func (c *Client) parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error.Message == "" {
+		return NewAPIError(resp.StatusCode, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(body)))
+	}
+
+	errResp.Error.StatusCode = resp.StatusCode
+	return &errResp.Error
+}
+
+// call performs a request and decodes a successful response into out
+func (c *Client) call(method, path string, body interface{}, out interface{}) error {
+	resp, err := c.doRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return c.parseError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// listPath builds a list endpoint path with limit and cursor parameters.
+// When updatedSince is set the list is sorted by updated_at and starts there.
+func listPath(path string, limit int, cursor string, updatedSince *time.Time) string {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	params.Set("order", "desc")
+	params.Set("sort", "created_at")
+	if updatedSince != nil {
+		params.Set("sort", "updated_at")
+		params.Set("begin_time", updatedSince.UTC().Format(time.RFC3339))
+	}
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	return path + "?" + params.Encode()
+}
+
+// TestConnection tests the API connection
+func (c *Client) TestConnection() error {
+	resp, err := c.doRequest("GET", "/accounts?limit=1", nil)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 {
+		return fmt.Errorf("authentication failed: invalid API key")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+
+	return nil
+}
+
+// ListAccounts returns a page of accounts, optionally only those updated since the given time
+func (c *Client) ListAccounts(limit int, cursor string, updatedSince *time.Time) (*AccountList, error) {
+	var result AccountList
+	if err := c.call("GET", listPath("/accounts", limit, cursor, updatedSince), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAccount returns a single account by ID, or by code when prefixed with "code-"
+func (c *Client) GetAccount(id string) (*Account, error) {
+	var account Account
+	if err := c.call("GET", "/accounts/"+url.PathEscape(id), nil, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// CreateAccount creates a new account
+func (c *Client) CreateAccount(input AccountInput) (*Account, error) {
+	var account Account
+	if err := c.call("POST", "/accounts", input, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListSubscriptions returns a page of subscriptions, optionally only those updated since the given time
+func (c *Client) ListSubscriptions(limit int, cursor string, updatedSince *time.Time) (*SubscriptionList, error) {
+	var result SubscriptionList
+	if err := c.call("GET", listPath("/subscriptions", limit, cursor, updatedSince), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetSubscription returns a single subscription by ID
+func (c *Client) GetSubscription(id string) (*Subscription, error) {
+	var subscription Subscription
+	if err := c.call("GET", "/subscriptions/"+url.PathEscape(id), nil, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// CreateSubscription creates a new subscription
+func (c *Client) CreateSubscription(input SubscriptionInput) (*Subscription, error) {
+	var subscription Subscription
+	if err := c.call("POST", "/subscriptions", input, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ListPlans returns a page of plans
+func (c *Client) ListPlans(limit int, cursor string) (*PlanList, error) {
+	var result PlanList
+	if err := c.call("GET", listPath("/plans", limit, cursor, nil), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetPlan returns a single plan by ID
+func (c *Client) GetPlan(id string) (*Plan, error) {
+	var plan Plan
+	if err := c.call("GET", "/plans/"+url.PathEscape(id), nil, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// CreatePlan creates a new plan
+func (c *Client) CreatePlan(input PlanInput) (*Plan, error) {
+	var plan Plan
+	if err := c.call("POST", "/plans", input, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ListInvoices returns a page of invoices
+func (c *Client) ListInvoices(limit int, cursor string) (*InvoiceList, error) {
+	var result InvoiceList
+	if err := c.call("GET", listPath("/invoices", limit, cursor, nil), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetInvoice returns a single invoice by ID
+func (c *Client) GetInvoice(id string) (*Invoice, error) {
+	var invoice Invoice
+	if err := c.call("GET", "/invoices/"+url.PathEscape(id), nil, &invoice); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// CreateInvoice invoices an account's pending line items
+func (c *Client) CreateInvoice(accountID string, input InvoiceInput) (*InvoiceCollection, error) {
+	var collection InvoiceCollection
+	path := "/accounts/" + url.PathEscape(accountID) + "/invoices"
+	if err := c.call("POST", path, input, &collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// ListTransactions returns a page of transactions
+func (c *Client) ListTransactions(limit int, cursor string) (*TransactionList, error) {
+	var result TransactionList
+	if err := c.call("GET", listPath("/transactions", limit, cursor, nil), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTransaction returns a single transaction by ID
+func (c *Client) GetTransaction(id string) (*Transaction, error) {
+	var transaction Transaction
+	if err := c.call("GET", "/transactions/"+url.PathEscape(id), nil, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// CreateTransaction records an external payment against a manual invoice
+func (c *Client) CreateTransaction(invoiceID string, input ExternalTransactionInput) (*Transaction, error) {
+	var transaction Transaction
+	path := "/invoices/" + url.PathEscape(invoiceID) + "/transactions"
+	if err := c.call("POST", path, input, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
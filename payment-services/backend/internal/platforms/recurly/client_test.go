@@ -0,0 +1,250 @@
+package recurly
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// newTestClient returns a client pointed at a test server running handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/", "test-key")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestClientSendsAuthAndVersionHeaders(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "test-key" || pass != "" {
+			t.Errorf("basic auth = %q, %q, %v; want test-key with empty password", user, pass, ok)
+		}
+		if got := r.Header.Get("Accept"); got != apiVersion {
+			t.Errorf("Accept = %q, want %q", got, apiVersion)
+		}
+		if r.URL.Path != "/accounts/code-acme" {
+			t.Errorf("path = %q, want /accounts/code-acme", r.URL.Path)
+		}
+		writeJSON(w, http.StatusOK, Account{ID: "a1", Code: "acme"})
+	})
+
+	account, err := client.GetAccount("code-acme")
+	if err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if account.ID != "a1" || account.Code != "acme" {
+		t.Errorf("account = %+v", account)
+	}
+}
+
+func TestListAccountsPaging(t *testing.T) {
+	pages := map[string]AccountList{
+		"": {
+			Object:  "list",
+			HasMore: true,
+			Next:    "/accounts?cursor=page2&limit=2",
+			Data:    []Account{{ID: "a1"}, {ID: "a2"}},
+		},
+		"page2": {
+			Object: "list",
+			Data:   []Account{{ID: "a3"}},
+		},
+	}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("limit") != "2" {
+			t.Errorf("limit = %q, want 2", query.Get("limit"))
+		}
+		if query.Get("sort") != "created_at" || query.Get("order") != "desc" {
+			t.Errorf("sort = %q, order = %q", query.Get("sort"), query.Get("order"))
+		}
+		page, ok := pages[query.Get("cursor")]
+		if !ok {
+			t.Errorf("unexpected cursor %q", query.Get("cursor"))
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{})
+			return
+		}
+		writeJSON(w, http.StatusOK, page)
+	})
+
+	var ids []string
+	cursor := ""
+	for i := 0; i < 3; i++ {
+		list, err := client.ListAccounts(2, cursor, nil)
+		if err != nil {
+			t.Fatalf("ListAccounts(cursor=%q): %v", cursor, err)
+		}
+		for _, account := range list.Data {
+			ids = append(ids, account.ID)
+		}
+		if cursor = list.NextCursor(); cursor == "" {
+			break
+		}
+	}
+
+	if len(ids) != 3 || ids[0] != "a1" || ids[2] != "a3" {
+		t.Errorf("paged ids = %v, want [a1 a2 a3]", ids)
+	}
+}
+
+func TestListAccountsUpdatedSince(t *testing.T) {
+	since := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("sort") != "updated_at" {
+			t.Errorf("sort = %q, want updated_at", query.Get("sort"))
+		}
+		if query.Get("begin_time") != "2024-03-01T17:00:00Z" {
+			t.Errorf("begin_time = %q, want 2024-03-01T17:00:00Z", query.Get("begin_time"))
+		}
+		writeJSON(w, http.StatusOK, AccountList{Object: "list"})
+	})
+
+	if _, err := client.ListAccounts(0, "", &since); err != nil {
+		t.Fatalf("ListAccounts: %v", err)
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	tests := []struct {
+		name string
+		list AccountList
+		want string
+	}{
+		{"no more pages", AccountList{HasMore: false, Next: "/accounts?cursor=x"}, ""},
+		{"more pages", AccountList{HasMore: true, Next: "/accounts?cursor=abc&limit=200"}, "abc"},
+		{"missing next link", AccountList{HasMore: true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.list.NextCursor(); got != tt.want {
+				t.Errorf("NextCursor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantType    string
+		wantMessage string
+	}{
+		{
+			name:        "recurly error body",
+			status:      http.StatusNotFound,
+			body:        `{"error":{"type":"not_found","message":"Couldn't find Account with code = nope"}}`,
+			wantType:    "not_found",
+			wantMessage: "Couldn't find Account with code = nope",
+		},
+		{
+			name:        "validation error",
+			status:      http.StatusUnprocessableEntity,
+			body:        `{"error":{"type":"validation","message":"Code has already been taken"}}`,
+			wantType:    "validation",
+			wantMessage: "Code has already been taken",
+		},
+		{
+			name:        "non-JSON body",
+			status:      http.StatusBadGateway,
+			body:        "upstream unavailable",
+			wantMessage: "API error (status 502): upstream unavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := client.GetAccount("code-nope")
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v (%T), want *APIError", err, err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.status)
+			}
+			if apiErr.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", apiErr.Type, tt.wantType)
+			}
+			if apiErr.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", apiErr.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestTestConnectionRejectsBadKey(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	if err := client.TestConnection(); err == nil {
+		t.Fatal("TestConnection succeeded with a rejected key")
+	}
+}
+
+func TestCreateAccountSendsJSON(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/accounts" {
+			t.Errorf("request = %s %s, want POST /accounts", r.Method, r.URL.Path)
+		}
+		var input AccountInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		writeJSON(w, http.StatusCreated, Account{ID: "a9", Code: input.Code, Email: input.Email})
+	})
+
+	customer, err := NewProvider(client).CreateCustomer(models.CreateCustomerRequest{Email: "jo@example.com"})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	// Without a reference the email becomes the account code
+	if customer.ID != "a9" || customer.Reference != "jo@example.com" {
+		t.Errorf("customer = %+v", customer)
+	}
+}
+
+func TestResponseObserver(t *testing.T) {
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			writeJSON(w, http.StatusCreated, Account{ID: "a1", Code: "acme"})
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"error": map[string]string{"type": "not_found", "message": "Couldn't find Account"},
+		})
+	})
+
+	var statuses []int
+	client := base.WithResponseObserver(func(status int) { statuses = append(statuses, status) })
+	if _, err := client.CreateAccount(AccountInput{Code: "acme"}); err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	if _, err := client.GetAccount("code-missing"); err == nil {
+		t.Fatal("GetAccount of a missing account succeeded")
+	}
+	if _, err := base.GetAccount("code-missing"); err == nil {
+		t.Fatal("GetAccount of a missing account succeeded")
+	}
+	if len(statuses) != 2 || statuses[0] != http.StatusCreated || statuses[1] != http.StatusNotFound {
+		t.Errorf("observed %v, want [201 404] from the observed client only", statuses)
+	}
+}
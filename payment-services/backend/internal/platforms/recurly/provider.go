@@ -0,0 +1,238 @@
+package recurly
+
+import (
+	"strconv"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// Provider adapts the Recurly client to the platforms.BillingProvider interface
+type Provider struct {
+	client *Client
+}
+
+var (
+	_ platforms.BillingProvider    = (*Provider)(nil)
+	_ platforms.ResponseObservable = (*Provider)(nil)
+)
+
+// NewProvider wraps a Recurly client as a BillingProvider
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+// Client returns the underlying Recurly client for platform-specific calls
+func (p *Provider) Client() *Client {
+	return p.client
+}
+
+// WithResponseObserver returns a provider whose calls report the status of
+// every Recurly response to observe
+func (p *Provider) WithResponseObserver(observe func(status int)) platforms.BillingProvider {
+	return NewProvider(p.client.WithResponseObserver(observe))
+}
+
+// Platform returns the platform type
+func (p *Provider) Platform() models.PlatformType {
+	return models.PlatformRecurly
+}
+
+// TestConnection tests the API connection
+func (p *Provider) TestConnection() error {
+	return p.client.TestConnection()
+}
+
+// ListCustomers returns a page of accounts as normalized customers
+func (p *Provider) ListCustomers(params platforms.ListParams) ([]models.Customer, string, error) {
+	list, err := p.client.ListAccounts(params.Limit, params.Cursor, params.UpdatedSince)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Customer, len(list.Data))
+	for i, account := range list.Data {
+		result[i] = accountToModel(account)
+	}
+	return result, list.NextCursor(), nil
+}
+
+// GetCustomer returns a single account as a normalized customer
+func (p *Provider) GetCustomer(id string) (*models.Customer, error) {
+	account, err := p.client.GetAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	result := accountToModel(*account)
+	return &result, nil
+}
+
+// CreateCustomer creates an account from the platform-agnostic request
+func (p *Provider) CreateCustomer(input models.CreateCustomerRequest) (*models.Customer, error) {
+	// Recurly requires an account code; fall back to the email when no reference is given
+	code := input.Reference
+	if code == "" {
+		code = input.Email
+	}
+
+	account, err := p.client.CreateAccount(AccountInput{
+		Code:      code,
+		Email:     input.Email,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		Company:   input.Organization,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := accountToModel(*account)
+	return &result, nil
+}
+
+// ListSubscriptions returns a page of normalized subscriptions
+func (p *Provider) ListSubscriptions(params platforms.ListParams) ([]models.Subscription, string, error) {
+	list, err := p.client.ListSubscriptions(params.Limit, params.Cursor, params.UpdatedSince)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Subscription, len(list.Data))
+	for i, sub := range list.Data {
+		result[i] = subscriptionToModel(sub)
+	}
+	return result, list.NextCursor(), nil
+}
+
+// GetSubscription returns a single normalized subscription
+func (p *Provider) GetSubscription(id string) (*models.Subscription, error) {
+	subscription, err := p.client.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	result := subscriptionToModel(*subscription)
+	return &result, nil
+}
+
+// ListInvoices returns a page of normalized invoices
+func (p *Provider) ListInvoices(params platforms.ListParams) ([]models.Invoice, string, error) {
+	list, err := p.client.ListInvoices(params.Limit, params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Invoice, len(list.Data))
+	for i, invoice := range list.Data {
+		result[i] = invoiceToModel(invoice)
+	}
+	return result, list.NextCursor(), nil
+}
+
+// ListPayments returns a page of transactions as normalized payments
+func (p *Provider) ListPayments(params platforms.ListParams) ([]models.Payment, string, error) {
+	list, err := p.client.ListTransactions(params.Limit, params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Payment, len(list.Data))
+	for i, transaction := range list.Data {
+		result[i] = transactionToModel(transaction)
+	}
+	return result, list.NextCursor(), nil
+}
+
+// ListProducts returns a page of plans as normalized products
+func (p *Provider) ListProducts(params platforms.ListParams) ([]models.Product, string, error) {
+	list, err := p.client.ListPlans(params.Limit, params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Product, len(list.Data))
+	for i, plan := range list.Data {
+		result[i] = planToModel(plan)
+	}
+	return result, list.NextCursor(), nil
+}
+
+// formatAmount formats a Recurly decimal amount with two places
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func accountToModel(account Account) models.Customer {
+	return models.Customer{
+		ID:           account.ID,
+		Reference:    account.Code,
+		FirstName:    account.FirstName,
+		LastName:     account.LastName,
+		Email:        account.Email,
+		Organization: account.Company,
+		CreatedAt:    account.CreatedAt,
+		RawData:      platforms.RawData(account),
+	}
+}
+
+func subscriptionToModel(sub Subscription) models.Subscription {
+	result := models.Subscription{
+		ID:                 sub.ID,
+		State:              sub.State,
+		CurrentPeriodStart: sub.CurrentPeriodStartedAt,
+		CurrentPeriodEnd:   sub.CurrentPeriodEndsAt,
+		CreatedAt:          sub.CreatedAt,
+		RawData:            platforms.RawData(sub),
+	}
+	if sub.Account != nil {
+		result.CustomerID = sub.Account.ID
+	}
+	if sub.Plan != nil {
+		result.ProductName = sub.Plan.Name
+	}
+	return result
+}
+
+func invoiceToModel(invoice Invoice) models.Invoice {
+	result := models.Invoice{
+		ID:        invoice.ID,
+		Number:    invoice.Number,
+		Status:    invoice.State,
+		Total:     formatAmount(invoice.Total),
+		Currency:  invoice.Currency,
+		DueDate:   invoice.DueAt,
+		CreatedAt: invoice.CreatedAt,
+		RawData:   platforms.RawData(invoice),
+	}
+	if invoice.Account != nil {
+		result.CustomerID = invoice.Account.ID
+	}
+	return result
+}
+
+func transactionToModel(transaction Transaction) models.Payment {
+	return models.Payment{
+		ID:        transaction.ID,
+		Amount:    formatAmount(transaction.Amount),
+		Currency:  transaction.Currency,
+		Status:    transaction.Status,
+		CreatedAt: transaction.CreatedAt,
+		RawData:   platforms.RawData(transaction),
+	}
+}
+
+func planToModel(plan Plan) models.Product {
+	result := models.Product{
+		ID:          plan.ID,
+		Name:        plan.Name,
+		Handle:      plan.Code,
+		Description: plan.Description,
+		CreatedAt:   plan.CreatedAt,
+		RawData:     platforms.RawData(plan),
+	}
+	if len(plan.Currencies) > 0 {
+		result.Price = formatAmount(plan.Currencies[0].UnitAmount)
+	}
+	if plan.IntervalLength > 0 {
+		result.Interval = strconv.Itoa(plan.IntervalLength) + " " + plan.IntervalUnit
+	}
+	return result
+}
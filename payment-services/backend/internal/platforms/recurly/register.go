@@ -0,0 +1,27 @@
+package recurly
+
+import (
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+func init() {
+	platforms.Register(platforms.Definition{
+		Type:        models.PlatformRecurly,
+		DisplayName: "Recurly",
+		Credentials: []platforms.CredentialField{
+			{Type: "api_key", Label: "API key", Required: true},
+		},
+		TreeContainers: []platforms.TreeContainer{
+			{Type: "customers", Name: "Accounts"},
+			{Type: "subscriptions", Name: "Subscriptions"},
+			{Type: "plans", Name: "Plans"},
+			{Type: "invoices", Name: "Invoices"},
+			{Type: "payments", Name: "Transactions"},
+		},
+		NewProvider: func(cfg platforms.ConnectionConfig) (platforms.BillingProvider, error) {
+			// base_url selects the data center (e.g. EUBaseURL); empty means US
+			return NewProvider(NewClient(cfg.BaseURL, cfg.Credentials["api_key"])), nil
+		},
+	})
+}
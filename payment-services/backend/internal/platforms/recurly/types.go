@@ -0,0 +1,250 @@
+package recurly
+
+import (
+	"net/url"
+	"time"
+)
+
+// APIError represents an error from the Recurly API with status code
+type APIError struct {
+	StatusCode int
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// NewAPIError creates a new API error
+func NewAPIError(statusCode int, message string) *APIError {
+	return &APIError{StatusCode: statusCode, Message: message}
+}
+
+// ErrorResponse is the error response from Recurly API
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// List is the envelope Recurly uses for every list endpoint
+type List[T any] struct {
+	Object  string `json:"object"`
+	HasMore bool   `json:"has_more"`
+	Next    string `json:"next,omitempty"`
+	Data    []T    `json:"data"`
+}
+
+// NextCursor extracts the cursor for the next page from the Next link
+func (l *List[T]) NextCursor() string {
+	if !l.HasMore || l.Next == "" {
+		return ""
+	}
+	next, err := url.Parse(l.Next)
+	if err != nil {
+		return ""
+	}
+	return next.Query().Get("cursor")
+}
+
+// AccountList is the response for listing accounts
+type AccountList = List[Account]
+
+// SubscriptionList is the response for listing subscriptions
+type SubscriptionList = List[Subscription]
+
+// PlanList is the response for listing plans
+type PlanList = List[Plan]
+
+// InvoiceList is the response for listing invoices
+type InvoiceList = List[Invoice]
+
+// TransactionList is the response for listing transactions
+type TransactionList = List[Transaction]
+
+// Address represents a Recurly address
+type Address struct {
+	Phone      string `json:"phone,omitempty"`
+	Street1    string `json:"street1,omitempty"`
+	Street2    string `json:"street2,omitempty"`
+	City       string `json:"city,omitempty"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"`
+}
+
+// Account represents a Recurly account (maps to Customer in frontend)
+type Account struct {
+	ID        string     `json:"id"`
+	Object    string     `json:"object"`
+	Code      string     `json:"code"`
+	State     string     `json:"state,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	FirstName string     `json:"first_name,omitempty"`
+	LastName  string     `json:"last_name,omitempty"`
+	Company   string     `json:"company,omitempty"`
+	Address   *Address   `json:"address,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// AccountMini is the abbreviated account embedded in other objects
+type AccountMini struct {
+	ID    string `json:"id"`
+	Code  string `json:"code,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// AccountInput is the input for creating an account
+type AccountInput struct {
+	Code      string   `json:"code"`
+	Email     string   `json:"email,omitempty"`
+	FirstName string   `json:"first_name,omitempty"`
+	LastName  string   `json:"last_name,omitempty"`
+	Company   string   `json:"company,omitempty"`
+	Address   *Address `json:"address,omitempty"`
+}
+
+// PlanMini is the abbreviated plan embedded in subscriptions
+type PlanMini struct {
+	ID   string `json:"id"`
+	Code string `json:"code,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Subscription represents a Recurly subscription
+type Subscription struct {
+	ID                     string       `json:"id"`
+	Object                 string       `json:"object"`
+	UUID                   string       `json:"uuid,omitempty"`
+	Account                *AccountMini `json:"account,omitempty"`
+	Plan                   *PlanMini    `json:"plan,omitempty"`
+	State                  string       `json:"state"`
+	Currency               string       `json:"currency,omitempty"`
+	UnitAmount             float64      `json:"unit_amount,omitempty"`
+	Quantity               int          `json:"quantity,omitempty"`
+	Subtotal               float64      `json:"subtotal,omitempty"`
+	CollectionMethod       string       `json:"collection_method,omitempty"`
+	AutoRenew              bool         `json:"auto_renew"`
+	CurrentPeriodStartedAt *time.Time   `json:"current_period_started_at,omitempty"`
+	CurrentPeriodEndsAt    *time.Time   `json:"current_period_ends_at,omitempty"`
+	ActivatedAt            *time.Time   `json:"activated_at,omitempty"`
+	CanceledAt             *time.Time   `json:"canceled_at,omitempty"`
+	ExpiresAt              *time.Time   `json:"expires_at,omitempty"`
+	CreatedAt              *time.Time   `json:"created_at,omitempty"`
+	UpdatedAt              *time.Time   `json:"updated_at,omitempty"`
+}
+
+// SubscriptionAccountInput identifies the account for a new subscription
+type SubscriptionAccountInput struct {
+	Code string `json:"code"`
+}
+
+// SubscriptionInput is the input for creating a subscription
+type SubscriptionInput struct {
+	PlanCode         string                   `json:"plan_code"`
+	Currency         string                   `json:"currency"`
+	Account          SubscriptionAccountInput `json:"account"`
+	Quantity         int                      `json:"quantity,omitempty"`
+	UnitAmount       float64                  `json:"unit_amount,omitempty"`
+	CollectionMethod string                   `json:"collection_method,omitempty"` // automatic or manual
+	NetTerms         int                      `json:"net_terms,omitempty"`
+	CouponCodes      []string                 `json:"coupon_codes,omitempty"`
+	StartsAt         *time.Time               `json:"starts_at,omitempty"`
+}
+
+// PlanPricing is the price of a plan in one currency
+type PlanPricing struct {
+	Currency   string  `json:"currency"`
+	SetupFee   float64 `json:"setup_fee,omitempty"`
+	UnitAmount float64 `json:"unit_amount"`
+}
+
+// Plan represents a Recurly plan (maps to Product in frontend)
+type Plan struct {
+	ID             string        `json:"id"`
+	Object         string        `json:"object"`
+	Code           string        `json:"code"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description,omitempty"`
+	State          string        `json:"state,omitempty"`
+	IntervalUnit   string        `json:"interval_unit,omitempty"`
+	IntervalLength int           `json:"interval_length,omitempty"`
+	Currencies     []PlanPricing `json:"currencies,omitempty"`
+	CreatedAt      *time.Time    `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time    `json:"updated_at,omitempty"`
+}
+
+// PlanInput is the input for creating a plan
+type PlanInput struct {
+	Code           string        `json:"code"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description,omitempty"`
+	IntervalUnit   string        `json:"interval_unit,omitempty"` // days or months
+	IntervalLength int           `json:"interval_length,omitempty"`
+	Currencies     []PlanPricing `json:"currencies"`
+}
+
+// Invoice represents a Recurly invoice
+type Invoice struct {
+	ID              string       `json:"id"`
+	Object          string       `json:"object"`
+	Number          string       `json:"number,omitempty"`
+	Type            string       `json:"type,omitempty"` // charge, credit, legacy
+	Origin          string       `json:"origin,omitempty"`
+	State           string       `json:"state"`
+	Account         *AccountMini `json:"account,omitempty"`
+	SubscriptionIDs []string     `json:"subscription_ids,omitempty"`
+	Currency        string       `json:"currency,omitempty"`
+	Subtotal        float64      `json:"subtotal"`
+	Tax             float64      `json:"tax"`
+	Total           float64      `json:"total"`
+	Paid            float64      `json:"paid"`
+	Balance         float64      `json:"balance"`
+	NetTerms        int          `json:"net_terms,omitempty"`
+	DueAt           *time.Time   `json:"due_at,omitempty"`
+	ClosedAt        *time.Time   `json:"closed_at,omitempty"`
+	CreatedAt       *time.Time   `json:"created_at,omitempty"`
+	UpdatedAt       *time.Time   `json:"updated_at,omitempty"`
+}
+
+// InvoiceInput is the input for invoicing an account's pending line items
+type InvoiceInput struct {
+	Currency         string `json:"currency"`
+	CollectionMethod string `json:"collection_method,omitempty"` // automatic or manual
+	NetTerms         int    `json:"net_terms,omitempty"`
+	PONumber         string `json:"po_number,omitempty"`
+	CustomerNotes    string `json:"customer_notes,omitempty"`
+}
+
+// InvoiceCollection is the response when invoicing an account
+type InvoiceCollection struct {
+	Object         string    `json:"object"`
+	ChargeInvoice  *Invoice  `json:"charge_invoice,omitempty"`
+	CreditInvoices []Invoice `json:"credit_invoices,omitempty"`
+}
+
+// Transaction represents a Recurly transaction (maps to Payment in frontend)
+type Transaction struct {
+	ID            string       `json:"id"`
+	Object        string       `json:"object"`
+	UUID          string       `json:"uuid,omitempty"`
+	Type          string       `json:"type,omitempty"` // authorization, capture, purchase, refund, verify
+	Origin        string       `json:"origin,omitempty"`
+	Status        string       `json:"status"`
+	Success       bool         `json:"success"`
+	Account       *AccountMini `json:"account,omitempty"`
+	Currency      string       `json:"currency,omitempty"`
+	Amount        float64      `json:"amount"`
+	Description   string       `json:"description,omitempty"`
+	PaymentMethod interface{}  `json:"payment_method,omitempty"`
+	CollectedAt   *time.Time   `json:"collected_at,omitempty"`
+	CreatedAt     *time.Time   `json:"created_at,omitempty"`
+}
+
+// ExternalTransactionInput records a payment collected outside Recurly against a manual invoice
+type ExternalTransactionInput struct {
+	PaymentMethod string     `json:"payment_method"` // check, wire_transfer, cash, other, ...
+	Amount        float64    `json:"amount"`
+	Description   string     `json:"description,omitempty"`
+	CollectedAt   *time.Time `json:"collected_at,omitempty"`
+}
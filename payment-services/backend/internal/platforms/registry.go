@@ -0,0 +1,132 @@
+package platforms
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+)
+
+// CredentialField describes a credential a platform needs in order to connect.
+// Type is both the credential_type stored in platform_credentials and the key
+// used in the credentials object of a create-connection request.
+type CredentialField struct {
+	Type     string `json:"type"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+}
+
+// TreeContainer is an entity folder shown under each connection in the UI tree
+type TreeContainer struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// ConnectionConfig holds the stored settings and credentials for a connection
+type ConnectionConfig struct {
+	ID          int64
+	Subdomain   string
+	BaseURL     string
+	IsSandbox   bool
+	Credentials map[string]string
+}
+
+// Definition describes a billing platform to the server
+type Definition struct {
+	Type           models.PlatformType `json:"type"`
+	DisplayName    string              `json:"display_name"`
+	Credentials    []CredentialField   `json:"credentials"`
+	TreeContainers []TreeContainer     `json:"tree_containers"`
+
+	// NewProvider builds a provider from a connection's stored configuration
+	NewProvider func(cfg ConnectionConfig) (BillingProvider, error) `json:"-"`
+
+	// InferSandbox, if set, tells from the configuration alone whether a
+	// connection reaches a sandbox. known is false when the configuration
+	// does not say; an error means the configuration is unusable.
+	InferSandbox func(cfg ConnectionConfig) (sandbox, known bool, err error) `json:"-"`
+
+	// IncrementalByCreation is set when the platform's UpdatedSince filter
+	// matches creation time only, so an incremental sync never sees changes
+	// to existing customers and subscriptions. Only full syncs keep those
+	// cached entities current.
+	IncrementalByCreation bool `json:"-"`
+}
+
+// CheckSandbox returns an error if the configuration shows the connection
+// reaches a different environment than cfg.IsSandbox claims
+func (d Definition) CheckSandbox(cfg ConnectionConfig) error {
+	if d.InferSandbox == nil {
+		return nil
+	}
+	sandbox, known, err := d.InferSandbox(cfg)
+	if err != nil || !known || sandbox == cfg.IsSandbox {
+		return err
+	}
+	return fmt.Errorf("%s", SandboxMismatch(d.DisplayName, sandbox))
+}
+
+// SandboxMismatch describes a connection whose is_sandbox flag contradicts
+// the environment its credentials reach
+func SandboxMismatch(displayName string, actualSandbox bool) string {
+	if actualSandbox {
+		return displayName + " credentials are for a sandbox/test environment but the connection is marked live (is_sandbox is false)"
+	}
+	return displayName + " credentials are for a live environment but the connection is marked as a sandbox (is_sandbox is true)"
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[models.PlatformType]Definition)
+)
+
+// Register makes a platform available to the server. It is intended to be
+// called from the init function of each platform package and panics if the
+// same type is registered twice.
+func Register(def Definition) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if def.NewProvider == nil {
+		panic(fmt.Sprintf("platforms: Register %s with nil NewProvider", def.Type))
+	}
+	if _, dup := registry[def.Type]; dup {
+		panic(fmt.Sprintf("platforms: Register called twice for %s", def.Type))
+	}
+	registry[def.Type] = def
+}
+
+// Lookup returns the definition registered for a platform type
+func Lookup(platformType models.PlatformType) (Definition, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	def, ok := registry[platformType]
+	return def, ok
+}
+
+// All returns every registered platform, ordered by type
+func All() []Definition {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Type < defs[j].Type
+	})
+	return defs
+}
+
+// MissingCredential returns the first required credential absent from values, if any
+func (d Definition) MissingCredential(values map[string]string) (CredentialField, bool) {
+	for _, field := range d.Credentials {
+		if field.Required && values[field.Type] == "" {
+			return field, true
+		}
+	}
+	return CredentialField{}, false
+}
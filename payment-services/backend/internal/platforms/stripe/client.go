@@ -1,20 +1,40 @@
 package stripe
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultListLimit is the limit used when a list call does not give one
+const DefaultListLimit = 100
+
+// maxNetworkRetries is how many times a request that failed in transit or
+// that Stripe marks as safe to retry is sent again, with the same
+// Idempotency-Key, before its failure is returned
+const maxNetworkRetries = 2
+
 // Client is the Stripe API client
 type Client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+
+	// idempotencyKey, when set, is sent with this client's POST requests;
+	// writes counts them so each gets its own key. See WithIdempotencyKey.
+	idempotencyKey string
+	writes         *atomic.Int64
+
+	retryDelay time.Duration // wait before the first retry; doubles for each one after
+
+	observe func(status int) // see WithResponseObserver
 }
 
 // NewClient creates a new Stripe API client
@@ -23,26 +43,117 @@ func NewClient(apiKey string) *Client {
 		baseURL:    "https://api.stripe.com/v1",
 		apiKey:     apiKey,
 		httpClient: &http.Client{Timeout: 30 * time.Second},
+		retryDelay: 500 * time.Millisecond,
+	}
+}
+
+// NewIdempotencyKey returns a random idempotency key. It panics if the
+// system's random source fails, as a key that might repeat is never safe.
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("stripe: failed to read random bytes for an idempotency key: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithIdempotencyKey returns a copy of the client that sends key as the
+// Idempotency-Key of its POST requests, so retrying the same operation with
+// the same key cannot apply it twice. The first POST uses key itself and
+// later ones key-2, key-3 and so on, so an operation that makes several
+// writes gets the same key for each on every retry. Without a key, every
+// POST gets a random one, kept across the client's own retries of it.
+func (c *Client) WithIdempotencyKey(key string) *Client {
+	clone := *c
+	clone.idempotencyKey = key
+	clone.writes = new(atomic.Int64)
+	return &clone
+}
+
+// WithResponseObserver returns a copy of the client that calls observe with
+// the status of every response it receives from Stripe, retried attempts included
+func (c *Client) WithResponseObserver(observe func(status int)) *Client {
+	clone := *c
+	clone.observe = observe
+	return &clone
+}
+
+// nextIdempotencyKey returns the Idempotency-Key for the client's next POST
+func (c *Client) nextIdempotencyKey() string {
+	if c.idempotencyKey == "" {
+		return NewIdempotencyKey()
+	}
+	if n := c.writes.Add(1); n > 1 {
+		return fmt.Sprintf("%s-%d", c.idempotencyKey, n)
 	}
+	return c.idempotencyKey
 }
 
-// doRequest performs an HTTP request to the Stripe API
+// doRequest performs an HTTP request to the Stripe API. Requests that fail
+// in transit or that Stripe says may be retried are sent again, up to
+// maxNetworkRetries times; a POST keeps its Idempotency-Key on every attempt
+// so Stripe applies it at most once.
 func (c *Client) doRequest(method, path string, formData url.Values) (*http.Response, error) {
-	var bodyReader io.Reader
+	var body string
 	if formData != nil {
-		bodyReader = strings.NewReader(formData.Encode())
+		body = formData.Encode()
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Stripe only honours idempotency keys on POST
+	var idempotencyKey string
+	if method == http.MethodPost {
+		idempotencyKey = c.nextIdempotencyKey()
 	}
 
-	// Bearer token authentication
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	delay := c.retryDelay
+	for attempt := 0; ; attempt++ {
+		var bodyReader io.Reader
+		if formData != nil {
+			bodyReader = strings.NewReader(body)
+		}
+		req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		// Bearer token authentication
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err == nil && c.observe != nil {
+			c.observe(resp.StatusCode)
+		}
+		if attempt == maxNetworkRetries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
 
-	return c.httpClient.Do(req)
+// shouldRetry reports whether a request may be sent again: it failed in
+// transit, Stripe says so in Stripe-Should-Retry, or it hit a lock timeout,
+// the rate limit or a server error
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.Header.Get("Stripe-Should-Retry") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
 }
 
 // parseError parses an error response from Stripe
@@ -77,10 +188,10 @@ func (c *Client) TestConnection() error {
 	return nil
 }
 
-// ListCustomers returns a list of customers
-func (c *Client) ListCustomers(limit int, startingAfter string) (*CustomerList, error) {
+// ListCustomers returns a list of customers, optionally only those created after the given Unix time
+func (c *Client) ListCustomers(limit int, startingAfter string, createdAfter int64) (*CustomerList, error) {
 	if limit <= 0 {
-		limit = 100
+		limit = DefaultListLimit
 	}
 
 	params := url.Values{}
@@ -89,6 +200,10 @@ func (c *Client) ListCustomers(limit int, startingAfter string) (*CustomerList,
 		params.Set("starting_after", startingAfter)
 	}
 
+	if createdAfter > 0 {
+		params.Set("created[gt]", fmt.Sprintf("%d", createdAfter))
+	}
+
 	path := "/customers?" + params.Encode()
 	resp, err := c.doRequest("GET", path, nil)
 	if err != nil {
@@ -225,10 +340,10 @@ func (c *Client) UpdateCustomer(id string, input CustomerInput) (*Customer, erro
 	return &customer, nil
 }
 
-// ListSubscriptions returns a list of subscriptions
-func (c *Client) ListSubscriptions(limit int, startingAfter string) (*SubscriptionList, error) {
+// ListSubscriptions returns a list of subscriptions, optionally only those created after the given Unix time
+func (c *Client) ListSubscriptions(limit int, startingAfter string, createdAfter int64) (*SubscriptionList, error) {
 	if limit <= 0 {
-		limit = 100
+		limit = DefaultListLimit
 	}
 
 	params := url.Values{}
@@ -237,6 +352,10 @@ func (c *Client) ListSubscriptions(limit int, startingAfter string) (*Subscripti
 		params.Set("starting_after", startingAfter)
 	}
 
+	if createdAfter > 0 {
+		params.Set("created[gt]", fmt.Sprintf("%d", createdAfter))
+	}
+
 	path := "/subscriptions?" + params.Encode()
 	resp, err := c.doRequest("GET", path, nil)
 	if err != nil {
@@ -284,7 +403,7 @@ func (c *Client) GetSubscription(id string) (*Subscription, error) {
 // ListProducts returns a list of products
 func (c *Client) ListProducts(limit int, startingAfter string) (*ProductList, error) {
 	if limit <= 0 {
-		limit = 100
+		limit = DefaultListLimit
 	}
 
 	params := url.Values{}
@@ -341,7 +460,7 @@ func (c *Client) GetProduct(id string) (*Product, error) {
 // ListPrices returns a list of prices (optionally filtered by product)
 func (c *Client) ListPrices(productID string, limit int, startingAfter string) (*PriceList, error) {
 	if limit <= 0 {
-		limit = 100
+		limit = DefaultListLimit
 	}
 
 	params := url.Values{}
@@ -376,7 +495,7 @@ func (c *Client) ListPrices(productID string, limit int, startingAfter string) (
 // ListInvoices returns a list of invoices
 func (c *Client) ListInvoices(limit int, startingAfter string) (*InvoiceList, error) {
 	if limit <= 0 {
-		limit = 100
+		limit = DefaultListLimit
 	}
 
 	params := url.Values{}
@@ -432,7 +551,7 @@ func (c *Client) GetInvoice(id string) (*Invoice, error) {
 // ListCharges returns a list of charges (payments)
 func (c *Client) ListCharges(limit int, startingAfter string) (*ChargeList, error) {
 	if limit <= 0 {
-		limit = 100
+		limit = DefaultListLimit
 	}
 
 	params := url.Values{}
@@ -520,7 +639,7 @@ func (c *Client) CreatePrice(productID string, unitAmount int64, currency, inter
 // ListPaymentIntents returns a list of payment intents
 func (c *Client) ListPaymentIntents(limit int, startingAfter string) (*PaymentIntentList, error) {
 	if limit <= 0 {
-		limit = 100
+		limit = DefaultListLimit
 	}
 
 	params := url.Values{}
@@ -632,7 +751,7 @@ func (c *Client) CreateSubscription(input SubscriptionInput) (*Subscription, err
 // ListCoupons returns a list of coupons
 func (c *Client) ListCoupons(limit int, startingAfter string) (*CouponList, error) {
 	if limit <= 0 {
-		limit = 100
+		limit = DefaultListLimit
 	}
 
 	params := url.Values{}
@@ -937,3 +1056,689 @@ func (c *Client) CancelSubscription(id string, cancelAtPeriodEnd bool) (*Subscri
 
 	return &subscription, nil
 }
+
+// CreateRefund refunds a charge or PaymentIntent, in full or in part
+func (c *Client) CreateRefund(input RefundInput) (*Refund, error) {
+	formData := url.Values{}
+	if input.Charge != "" {
+		formData.Set("charge", input.Charge)
+	}
+	if input.PaymentIntent != "" {
+		formData.Set("payment_intent", input.PaymentIntent)
+	}
+
+	// Amount (default: everything not yet refunded)
+	if input.Amount > 0 {
+		formData.Set("amount", fmt.Sprintf("%d", input.Amount))
+	}
+	if input.Reason != "" {
+		formData.Set("reason", input.Reason)
+	}
+	for k, v := range input.Metadata {
+		formData.Set("metadata["+k+"]", v)
+	}
+
+	resp, err := c.doRequest("POST", "/refunds", formData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+
+	var refund Refund
+	if err := json.NewDecoder(resp.Body).Decode(&refund); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &refund, nil
+}
+
+// ListRefunds returns a list of refunds, optionally only those of one charge or PaymentIntent
+func (c *Client) ListRefunds(limit int, startingAfter, charge, paymentIntent string) (*RefundList, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if startingAfter != "" {
+		params.Set("starting_after", startingAfter)
+	}
+	if charge != "" {
+		params.Set("charge", charge)
+	}
+	if paymentIntent != "" {
+		params.Set("payment_intent", paymentIntent)
+	}
+
+	path := "/refunds?" + params.Encode()
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result RefundList
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetRefund returns a single refund by ID
+func (c *Client) GetRefund(id string) (*Refund, error) {
+	path := "/refunds/" + id
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, NewAPIError(404, "refund not found")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var refund Refund
+	if err := json.NewDecoder(resp.Body).Decode(&refund); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &refund, nil
+}
+
+// creditNoteParams encodes a credit note for the preview and create calls
+func creditNoteParams(input CreditNoteInput) url.Values {
+	params := url.Values{}
+	params.Set("invoice", input.Invoice)
+	if input.Amount > 0 {
+		params.Set("amount", fmt.Sprintf("%d", input.Amount))
+	}
+
+	for i, line := range input.Lines {
+		prefix := fmt.Sprintf("lines[%d]", i)
+		params.Set(prefix+"[type]", line.Type)
+		if line.InvoiceLineItem != "" {
+			params.Set(prefix+"[invoice_line_item]", line.InvoiceLineItem)
+		}
+		if line.Amount > 0 {
+			params.Set(prefix+"[amount]", fmt.Sprintf("%d", line.Amount))
+		}
+		if line.Quantity > 0 {
+			params.Set(prefix+"[quantity]", fmt.Sprintf("%d", line.Quantity))
+		}
+		if line.UnitAmount > 0 {
+			params.Set(prefix+"[unit_amount]", fmt.Sprintf("%d", line.UnitAmount))
+		}
+		if line.Description != "" {
+			params.Set(prefix+"[description]", line.Description)
+		}
+	}
+
+	if input.Reason != "" {
+		params.Set("reason", input.Reason)
+	}
+	if input.Memo != "" {
+		params.Set("memo", input.Memo)
+	}
+
+	// How the credit is settled
+	if input.RefundAmount > 0 {
+		params.Set("refund_amount", fmt.Sprintf("%d", input.RefundAmount))
+	}
+	if input.CreditAmount > 0 {
+		params.Set("credit_amount", fmt.Sprintf("%d", input.CreditAmount))
+	}
+	if input.OutOfBandAmount > 0 {
+		params.Set("out_of_band_amount", fmt.Sprintf("%d", input.OutOfBandAmount))
+	}
+
+	for k, v := range input.Metadata {
+		params.Set("metadata["+k+"]", v)
+	}
+	return params
+}
+
+// PreviewCreditNote returns the credit note that CreateCreditNote would
+// issue for input, without issuing it
+func (c *Client) PreviewCreditNote(input CreditNoteInput) (*CreditNote, error) {
+	path := "/credit_notes/preview?" + creditNoteParams(input).Encode()
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var creditNote CreditNote
+	if err := json.NewDecoder(resp.Body).Decode(&creditNote); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &creditNote, nil
+}
+
+// CreateCreditNote issues a credit note against a finalized invoice
+func (c *Client) CreateCreditNote(input CreditNoteInput) (*CreditNote, error) {
+	resp, err := c.doRequest("POST", "/credit_notes", creditNoteParams(input))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+
+	var creditNote CreditNote
+	if err := json.NewDecoder(resp.Body).Decode(&creditNote); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &creditNote, nil
+}
+
+// ListCreditNotes returns a list of credit notes, optionally only those of one invoice
+func (c *Client) ListCreditNotes(limit int, startingAfter, invoice string) (*CreditNoteList, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if startingAfter != "" {
+		params.Set("starting_after", startingAfter)
+	}
+	if invoice != "" {
+		params.Set("invoice", invoice)
+	}
+
+	path := "/credit_notes?" + params.Encode()
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result CreditNoteList
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetCreditNote returns a single credit note by ID
+func (c *Client) GetCreditNote(id string) (*CreditNote, error) {
+	path := "/credit_notes/" + id
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, NewAPIError(404, "credit note not found")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var creditNote CreditNote
+	if err := json.NewDecoder(resp.Body).Decode(&creditNote); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &creditNote, nil
+}
+
+// VoidCreditNote voids a credit note. Voiding cannot be undone.
+func (c *Client) VoidCreditNote(id string) (*CreditNote, error) {
+	path := "/credit_notes/" + id + "/void"
+	resp, err := c.doRequest("POST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var creditNote CreditNote
+	if err := json.NewDecoder(resp.Body).Decode(&creditNote); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &creditNote, nil
+}
+
+// CreateInvoice creates a draft invoice for a customer. Items are added with
+// CreateInvoiceItem before the invoice is finalized.
+func (c *Client) CreateInvoice(input InvoiceInput) (*Invoice, error) {
+	formData := url.Values{}
+	formData.Set("customer", input.Customer)
+
+	// Only items added to this invoice, not the customer's pending items
+	formData.Set("pending_invoice_items_behavior", "exclude")
+
+	if input.CollectionMethod != "" {
+		formData.Set("collection_method", input.CollectionMethod)
+	}
+	if input.DaysUntilDue > 0 {
+		formData.Set("days_until_due", fmt.Sprintf("%d", input.DaysUntilDue))
+	}
+	if input.Description != "" {
+		formData.Set("description", input.Description)
+	}
+	if input.AutoAdvance != nil {
+		formData.Set("auto_advance", fmt.Sprintf("%t", *input.AutoAdvance))
+	}
+	if input.Currency != "" {
+		formData.Set("currency", input.Currency)
+	}
+	for k, v := range input.Metadata {
+		formData.Set("metadata["+k+"]", v)
+	}
+
+	resp, err := c.doRequest("POST", "/invoices", formData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+
+	var invoice Invoice
+	if err := json.NewDecoder(resp.Body).Decode(&invoice); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &invoice, nil
+}
+
+// CreateInvoiceItem adds an item to a customer's draft invoice
+func (c *Client) CreateInvoiceItem(customerID, invoiceID string, input InvoiceItemInput) (*InvoiceItem, error) {
+	formData := url.Values{}
+	formData.Set("customer", customerID)
+	formData.Set("invoice", invoiceID)
+
+	if input.Price != "" {
+		formData.Set("price", input.Price)
+		if input.Quantity > 0 {
+			formData.Set("quantity", fmt.Sprintf("%d", input.Quantity))
+		}
+	} else {
+		formData.Set("amount", fmt.Sprintf("%d", input.Amount))
+		formData.Set("currency", input.Currency)
+	}
+	if input.Description != "" {
+		formData.Set("description", input.Description)
+	}
+	for k, v := range input.Metadata {
+		formData.Set("metadata["+k+"]", v)
+	}
+
+	resp, err := c.doRequest("POST", "/invoiceitems", formData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+
+	var item InvoiceItem
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &item, nil
+}
+
+// invoiceAction posts to one of the invoice transition endpoints
+func (c *Client) invoiceAction(id, action string, formData url.Values) (*Invoice, error) {
+	path := "/invoices/" + id + "/" + action
+	resp, err := c.doRequest("POST", path, formData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var invoice Invoice
+	if err := json.NewDecoder(resp.Body).Decode(&invoice); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &invoice, nil
+}
+
+// FinalizeInvoice moves a draft invoice to open. autoAdvance, when set,
+// controls whether Stripe then collects payment automatically.
+func (c *Client) FinalizeInvoice(id string, autoAdvance *bool) (*Invoice, error) {
+	formData := url.Values{}
+	if autoAdvance != nil {
+		formData.Set("auto_advance", fmt.Sprintf("%t", *autoAdvance))
+	}
+	return c.invoiceAction(id, "finalize", formData)
+}
+
+// PayInvoice attempts payment of an open or uncollectible invoice, with a
+// specific payment method if given. paidOutOfBand marks it paid without
+// charging, for payments made outside Stripe.
+func (c *Client) PayInvoice(id, paymentMethod string, paidOutOfBand bool) (*Invoice, error) {
+	formData := url.Values{}
+	if paymentMethod != "" {
+		formData.Set("payment_method", paymentMethod)
+	}
+	if paidOutOfBand {
+		formData.Set("paid_out_of_band", "true")
+	}
+	return c.invoiceAction(id, "pay", formData)
+}
+
+// SendInvoice emails an open send_invoice invoice to the customer
+func (c *Client) SendInvoice(id string) (*Invoice, error) {
+	return c.invoiceAction(id, "send", nil)
+}
+
+// VoidInvoice voids a finalized invoice. Voiding cannot be undone.
+func (c *Client) VoidInvoice(id string) (*Invoice, error) {
+	return c.invoiceAction(id, "void", nil)
+}
+
+// MarkInvoiceUncollectible marks an open invoice as unlikely to be paid
+func (c *Client) MarkInvoiceUncollectible(id string) (*Invoice, error) {
+	return c.invoiceAction(id, "mark_uncollectible", nil)
+}
+
+// DeleteInvoice permanently deletes a draft invoice
+func (c *Client) DeleteInvoice(id string) error {
+	path := "/invoices/" + id
+	resp, err := c.doRequest("DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+
+	return nil
+}
+
+// ListPaymentMethods returns a customer's payment methods, optionally only those of one type
+func (c *Client) ListPaymentMethods(customerID, paymentMethodType string, limit int, startingAfter string) (*PaymentMethodList, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if startingAfter != "" {
+		params.Set("starting_after", startingAfter)
+	}
+	if paymentMethodType != "" {
+		params.Set("type", paymentMethodType)
+	}
+
+	path := "/customers/" + customerID + "/payment_methods?" + params.Encode()
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result PaymentMethodList
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetPaymentMethod returns a single payment method by ID
+func (c *Client) GetPaymentMethod(id string) (*PaymentMethod, error) {
+	path := "/payment_methods/" + id
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, NewAPIError(404, "payment method not found")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var paymentMethod PaymentMethod
+	if err := json.NewDecoder(resp.Body).Decode(&paymentMethod); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &paymentMethod, nil
+}
+
+// AttachPaymentMethod attaches a payment method to a customer so it can be charged again
+func (c *Client) AttachPaymentMethod(id, customerID string) (*PaymentMethod, error) {
+	formData := url.Values{}
+	formData.Set("customer", customerID)
+
+	path := "/payment_methods/" + id + "/attach"
+	resp, err := c.doRequest("POST", path, formData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var paymentMethod PaymentMethod
+	if err := json.NewDecoder(resp.Body).Decode(&paymentMethod); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &paymentMethod, nil
+}
+
+// DetachPaymentMethod detaches a payment method from its customer. A
+// detached payment method cannot be used or attached again.
+func (c *Client) DetachPaymentMethod(id string) (*PaymentMethod, error) {
+	path := "/payment_methods/" + id + "/detach"
+	resp, err := c.doRequest("POST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var paymentMethod PaymentMethod
+	if err := json.NewDecoder(resp.Body).Decode(&paymentMethod); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &paymentMethod, nil
+}
+
+// SetDefaultPaymentMethod sets the payment method charged for a customer's
+// invoices and subscriptions (invoice_settings.default_payment_method). An
+// empty paymentMethodID clears it.
+func (c *Client) SetDefaultPaymentMethod(customerID, paymentMethodID string) (*Customer, error) {
+	formData := url.Values{}
+	formData.Set("invoice_settings[default_payment_method]", paymentMethodID)
+
+	path := "/customers/" + customerID
+	resp, err := c.doRequest("POST", path, formData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var customer Customer
+	if err := json.NewDecoder(resp.Body).Decode(&customer); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &customer, nil
+}
+
+// CreateSetupIntent creates a SetupIntent to collect a payment method for a customer
+func (c *Client) CreateSetupIntent(input SetupIntentInput) (*SetupIntent, error) {
+	formData := url.Values{}
+	formData.Set("customer", input.Customer)
+	for _, t := range input.PaymentMethodTypes {
+		formData.Add("payment_method_types[]", t)
+	}
+	if len(input.PaymentMethodTypes) == 0 {
+		formData.Set("automatic_payment_methods[enabled]", "true")
+	}
+	if input.Usage != "" {
+		formData.Set("usage", input.Usage)
+	}
+	if input.Description != "" {
+		formData.Set("description", input.Description)
+	}
+	for k, v := range input.Metadata {
+		formData.Set("metadata["+k+"]", v)
+	}
+
+	resp, err := c.doRequest("POST", "/setup_intents", formData)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+
+	var setupIntent SetupIntent
+	if err := json.NewDecoder(resp.Body).Decode(&setupIntent); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &setupIntent, nil
+}
+
+// ListSetupIntents returns a list of SetupIntents, optionally only those of one customer
+func (c *Client) ListSetupIntents(limit int, startingAfter, customerID string) (*SetupIntentList, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if startingAfter != "" {
+		params.Set("starting_after", startingAfter)
+	}
+	if customerID != "" {
+		params.Set("customer", customerID)
+	}
+
+	path := "/setup_intents?" + params.Encode()
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result SetupIntentList
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetSetupIntent returns a single SetupIntent by ID
+func (c *Client) GetSetupIntent(id string) (*SetupIntent, error) {
+	path := "/setup_intents/" + id
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, NewAPIError(404, "setup intent not found")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var setupIntent SetupIntent
+	if err := json.NewDecoder(resp.Body).Decode(&setupIntent); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &setupIntent, nil
+}
+
+// CancelSetupIntent cancels a SetupIntent that has not yet succeeded
+func (c *Client) CancelSetupIntent(id string) (*SetupIntent, error) {
+	path := "/setup_intents/" + id + "/cancel"
+	resp, err := c.doRequest("POST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var setupIntent SetupIntent
+	if err := json.NewDecoder(resp.Body).Decode(&setupIntent); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &setupIntent, nil
+}
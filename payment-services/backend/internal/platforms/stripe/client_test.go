@@ -0,0 +1,188 @@
+package stripe
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// recordingServer answers each request with the next of statuses (200 once
+// they run out) and records the Idempotency-Key and body of every attempt
+type recordingServer struct {
+	mu       sync.Mutex
+	statuses []int
+	headers  []http.Header // extra headers for each status
+	keys     []string
+	bodies   []string
+}
+
+func (s *recordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.ParseForm()
+	s.keys = append(s.keys, r.Header.Get("Idempotency-Key"))
+	s.bodies = append(s.bodies, r.PostForm.Encode())
+
+	status := http.StatusOK
+	if n := len(s.keys) - 1; n < len(s.statuses) {
+		status = s.statuses[n]
+		if n < len(s.headers) {
+			for name, values := range s.headers[n] {
+				w.Header()[name] = values
+			}
+		}
+	}
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		w.Write([]byte(`{"id":"cus_1","object":"customer"}`))
+		return
+	}
+	w.Write([]byte(`{"error":{"type":"api_error","message":"try again"}}`))
+}
+
+func newRecordingClient(t *testing.T, server *recordingServer) *Client {
+	t.Helper()
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client := NewClient("sk_test_abc")
+	client.baseURL = ts.URL
+	client.retryDelay = 0
+	return client
+}
+
+func post(t *testing.T, client *Client) int {
+	t.Helper()
+	resp, err := client.doRequest(http.MethodPost, "/customers", url.Values{"email": {"jo@example.com"}})
+	if err != nil {
+		t.Fatalf("doRequest: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestRetriesReuseIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyed    bool
+		statuses []int
+		headers  []http.Header
+		want     int // final status
+		attempts int
+	}{
+		{name: "server error then success", statuses: []int{500}, want: 200, attempts: 2},
+		{name: "rate limited twice", keyed: true, statuses: []int{429, 429}, want: 200, attempts: 3},
+		{name: "lock timeout", statuses: []int{409}, want: 200, attempts: 2},
+		{name: "gives up after the retries", statuses: []int{503, 503, 503, 503}, want: 503, attempts: 3},
+		{name: "client error not retried", keyed: true, statuses: []int{400}, want: 400, attempts: 1},
+		{
+			name:     "Stripe-Should-Retry false",
+			statuses: []int{500},
+			headers:  []http.Header{{"Stripe-Should-Retry": {"false"}}},
+			want:     500,
+			attempts: 1,
+		},
+		{
+			name:     "Stripe-Should-Retry true",
+			statuses: []int{402},
+			headers:  []http.Header{{"Stripe-Should-Retry": {"true"}}},
+			want:     200,
+			attempts: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &recordingServer{statuses: tt.statuses, headers: tt.headers}
+			client := newRecordingClient(t, server)
+			if tt.keyed {
+				client = client.WithIdempotencyKey("req-key")
+			}
+
+			if got := post(t, client); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+			if len(server.keys) != tt.attempts {
+				t.Fatalf("%d attempts, want %d", len(server.keys), tt.attempts)
+			}
+			for i, key := range server.keys {
+				if key == "" || key != server.keys[0] {
+					t.Errorf("attempt %d sent key %q, first sent %q", i+1, key, server.keys[0])
+				}
+				if server.bodies[i] != "email=jo%40example.com" {
+					t.Errorf("attempt %d sent body %q", i+1, server.bodies[i])
+				}
+			}
+			if tt.keyed && server.keys[0] != "req-key" {
+				t.Errorf("keyed client sent %q, want req-key", server.keys[0])
+			}
+		})
+	}
+}
+
+func TestWithIdempotencyKeyNumbersEachWrite(t *testing.T) {
+	server := &recordingServer{}
+	base := newRecordingClient(t, server)
+
+	client := base.WithIdempotencyKey("op-1")
+	post(t, client)
+	post(t, client)
+	post(t, client)
+
+	want := []string{"op-1", "op-1-2", "op-1-3"}
+	for i := range want {
+		if server.keys[i] != want[i] {
+			t.Errorf("write %d sent key %q, want %q", i+1, server.keys[i], want[i])
+		}
+	}
+
+	// The same operation retried gets the same keys
+	retried := base.WithIdempotencyKey("op-1")
+	post(t, retried)
+	if server.keys[3] != "op-1" {
+		t.Errorf("retried operation sent %q, want op-1", server.keys[3])
+	}
+
+	// The base client is unchanged: each of its writes gets a fresh key
+	post(t, base)
+	post(t, base)
+	if server.keys[4] == server.keys[5] || server.keys[4] == "" {
+		t.Errorf("unkeyed writes sent %q and %q, want two distinct keys", server.keys[4], server.keys[5])
+	}
+}
+
+func TestGetSendsNoIdempotencyKey(t *testing.T) {
+	server := &recordingServer{statuses: []int{500}}
+	client := newRecordingClient(t, server).WithIdempotencyKey("op-1")
+
+	resp, err := client.doRequest(http.MethodGet, "/customers/cus_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(server.keys) != 2 || server.keys[0] != "" || server.keys[1] != "" {
+		t.Errorf("GET attempts sent keys %q, want two attempts with none", server.keys)
+	}
+}
+
+func TestResponseObserverSeesEveryAttempt(t *testing.T) {
+	server := &recordingServer{statuses: []int{503, 409}}
+	base := newRecordingClient(t, server)
+
+	var statuses []int
+	client := base.WithResponseObserver(func(status int) { statuses = append(statuses, status) }).WithIdempotencyKey("op-1")
+	if got := post(t, client); got != http.StatusOK {
+		t.Fatalf("status = %d, want 200", got)
+	}
+	if len(statuses) != 3 || statuses[0] != 503 || statuses[1] != 409 || statuses[2] != 200 {
+		t.Errorf("observed %v, want [503 409 200]", statuses)
+	}
+	if server.keys[0] != "op-1" || server.keys[2] != "op-1" {
+		t.Errorf("keys %q, want op-1 on every attempt", server.keys)
+	}
+
+	post(t, base)
+	if len(statuses) != 3 {
+		t.Errorf("the original client reported to the copy's observer: %v", statuses)
+	}
+}
@@ -0,0 +1,192 @@
+package stripe
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCreditNoteParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		input CreditNoteInput
+		want  url.Values
+	}{
+		{
+			name:  "amount with a reason and memo",
+			input: CreditNoteInput{Invoice: "in_1", Amount: 500, Reason: "order_change", Memo: "Late delivery"},
+			want:  url.Values{"invoice": {"in_1"}, "amount": {"500"}, "reason": {"order_change"}, "memo": {"Late delivery"}},
+		},
+		{
+			name: "invoice and custom lines",
+			input: CreditNoteInput{
+				Invoice: "in_1",
+				Lines: []CreditNoteLineInput{
+					{Type: "invoice_line_item", InvoiceLineItem: "il_1", Amount: 300},
+					{Type: "invoice_line_item", InvoiceLineItem: "il_2", Quantity: 2},
+					{Type: "custom_line_item", Description: "Goodwill", Quantity: 1, UnitAmount: 250},
+				},
+			},
+			want: url.Values{
+				"invoice":                     {"in_1"},
+				"lines[0][type]":              {"invoice_line_item"},
+				"lines[0][invoice_line_item]": {"il_1"},
+				"lines[0][amount]":            {"300"},
+				"lines[1][type]":              {"invoice_line_item"},
+				"lines[1][invoice_line_item]": {"il_2"},
+				"lines[1][quantity]":          {"2"},
+				"lines[2][type]":              {"custom_line_item"},
+				"lines[2][description]":       {"Goodwill"},
+				"lines[2][quantity]":          {"1"},
+				"lines[2][unit_amount]":       {"250"},
+			},
+		},
+		{
+			name: "settlement split and metadata",
+			input: CreditNoteInput{
+				Invoice:         "in_1",
+				Amount:          1000,
+				RefundAmount:    600,
+				CreditAmount:    300,
+				OutOfBandAmount: 100,
+				Metadata:        map[string]string{"ticket": "T-9"},
+			},
+			want: url.Values{
+				"invoice":            {"in_1"},
+				"amount":             {"1000"},
+				"refund_amount":      {"600"},
+				"credit_amount":      {"300"},
+				"out_of_band_amount": {"100"},
+				"metadata[ticket]":   {"T-9"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := creditNoteParams(tt.input); got.Encode() != tt.want.Encode() {
+				t.Errorf("params = %s, want %s", got.Encode(), tt.want.Encode())
+			}
+		})
+	}
+}
+
+func TestCreditNoteRequests(t *testing.T) {
+	input := CreditNoteInput{
+		Invoice: "in_1",
+		Lines:   []CreditNoteLineInput{{Type: "invoice_line_item", InvoiceLineItem: "il_1", Amount: 300}},
+		Reason:  "duplicate",
+	}
+	tests := []struct {
+		name      string
+		call      func(*Client) (*CreditNote, error)
+		method    string
+		path      string
+		wantQuery string
+		wantForm  string
+	}{
+		{
+			name:      "preview sends the input as a query",
+			call:      func(c *Client) (*CreditNote, error) { return c.PreviewCreditNote(input) },
+			method:    http.MethodGet,
+			path:      "/credit_notes/preview",
+			wantQuery: creditNoteParams(input).Encode(),
+		},
+		{
+			name:     "create sends the input as a form",
+			call:     func(c *Client) (*CreditNote, error) { return c.CreateCreditNote(input) },
+			method:   http.MethodPost,
+			path:     "/credit_notes",
+			wantForm: creditNoteParams(input).Encode(),
+		},
+		{
+			name:   "void",
+			call:   func(c *Client) (*CreditNote, error) { return c.VoidCreditNote("cn_1") },
+			method: http.MethodPost,
+			path:   "/credit_notes/cn_1/void",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.method || r.URL.Path != tt.path {
+					t.Errorf("request = %s %s, want %s %s", r.Method, r.URL.Path, tt.method, tt.path)
+				}
+				if got := r.URL.Query().Encode(); got != tt.wantQuery {
+					t.Errorf("query = %s, want %s", got, tt.wantQuery)
+				}
+				r.ParseForm()
+				if got := r.PostForm.Encode(); got != tt.wantForm {
+					t.Errorf("form = %s, want %s", got, tt.wantForm)
+				}
+				writeJSON(w, http.StatusOK, CreditNote{ID: "cn_1", Invoice: "in_1", Amount: 300, Status: "issued"})
+			})
+
+			creditNote, err := tt.call(client)
+			if err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			if creditNote.ID != "cn_1" || creditNote.Amount != 300 {
+				t.Errorf("credit note = %+v", creditNote)
+			}
+		})
+	}
+}
+
+func TestCreditNoteErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		call        func(*Client) error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:   "invoice not finalized",
+			status: http.StatusBadRequest,
+			body:   `{"error":{"type":"invalid_request_error","code":"invoice_not_finalized","param":"invoice","message":"Invoice in_1 is not finalized."}}`,
+			call: func(c *Client) error {
+				_, err := c.CreateCreditNote(CreditNoteInput{Invoice: "in_1", Amount: 100})
+				return err
+			},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "invoice_not_finalized",
+			wantMessage: "Invoice in_1 is not finalized.",
+		},
+		{
+			name:        "void of a voided credit note",
+			status:      http.StatusBadRequest,
+			body:        `{"error":{"type":"invalid_request_error","message":"Credit note cn_1 is already void."}}`,
+			call:        func(c *Client) error { _, err := c.VoidCreditNote("cn_1"); return err },
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Credit note cn_1 is already void.",
+		},
+		{
+			name:        "missing credit note",
+			status:      http.StatusNotFound,
+			body:        `{"error":{"type":"invalid_request_error","message":"No such credit note"}}`,
+			call:        func(c *Client) error { _, err := c.GetCreditNote("cn_missing"); return err },
+			wantStatus:  http.StatusNotFound,
+			wantMessage: "credit note not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			err := tt.call(client)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.wantStatus || apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMessage {
+				t.Errorf("error = %+v, want status %d, code %q, message %q", apiErr, tt.wantStatus, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
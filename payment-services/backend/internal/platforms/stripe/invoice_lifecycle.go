@@ -0,0 +1,55 @@
+package stripe
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InvoiceAction is a transition of the invoice state machine
+// (docs/stripe/invoice-lifecycle)
+type InvoiceAction string
+
+const (
+	InvoiceFinalize          InvoiceAction = "finalize"           // draft -> open
+	InvoicePay               InvoiceAction = "pay"                // open, uncollectible -> paid
+	InvoiceSend              InvoiceAction = "send"               // emails an open invoice
+	InvoiceVoid              InvoiceAction = "void"               // open, uncollectible -> void
+	InvoiceMarkUncollectible InvoiceAction = "mark_uncollectible" // open -> uncollectible
+	InvoiceDelete            InvoiceAction = "delete"             // removes a draft
+)
+
+// invoiceActionStatuses lists the statuses each action may start from
+var invoiceActionStatuses = map[InvoiceAction][]string{
+	InvoiceFinalize:          {"draft"},
+	InvoicePay:               {"open", "uncollectible"},
+	InvoiceSend:              {"open"},
+	InvoiceVoid:              {"open", "uncollectible"},
+	InvoiceMarkUncollectible: {"open"},
+	InvoiceDelete:            {"draft"},
+}
+
+// ErrInvalidTransition is returned when an invoice's status does not allow an action
+var ErrInvalidTransition = errors.New("invalid invoice transition")
+
+// CheckInvoiceAction reports whether action can be applied to invoice in its
+// current status, returning an error wrapping ErrInvalidTransition if not
+func CheckInvoiceAction(invoice *Invoice, action InvoiceAction) error {
+	allowed, ok := invoiceActionStatuses[action]
+	if !ok {
+		return fmt.Errorf("unknown invoice action %q", action)
+	}
+	if !slices.Contains(allowed, invoice.Status) {
+		message := fmt.Sprintf("cannot %s a %s invoice; it must be %s",
+			strings.ReplaceAll(string(action), "_", " "), invoice.Status, strings.Join(allowed, " or "))
+		if action == InvoiceVoid && invoice.Status == "draft" {
+			message += "; delete the draft instead"
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidTransition, message)
+	}
+	if action == InvoiceSend && invoice.CollectionMethod != "send_invoice" {
+		return fmt.Errorf("%w: only invoices with collection_method send_invoice can be sent", ErrInvalidTransition)
+	}
+	return nil
+}
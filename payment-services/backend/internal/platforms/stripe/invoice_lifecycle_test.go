@@ -0,0 +1,75 @@
+package stripe
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckInvoiceAction(t *testing.T) {
+	statuses := []string{"draft", "open", "paid", "uncollectible", "void"}
+	actions := []InvoiceAction{
+		InvoiceFinalize, InvoicePay, InvoiceSend, InvoiceVoid, InvoiceMarkUncollectible, InvoiceDelete,
+	}
+
+	// allowed[action][status]; written out rather than read from
+	// invoiceActionStatuses so a change to that table has to be deliberate
+	allowed := map[InvoiceAction]map[string]bool{
+		InvoiceFinalize:          {"draft": true},
+		InvoicePay:               {"open": true, "uncollectible": true},
+		InvoiceSend:              {"open": true},
+		InvoiceVoid:              {"open": true, "uncollectible": true},
+		InvoiceMarkUncollectible: {"open": true},
+		InvoiceDelete:            {"draft": true},
+	}
+
+	for _, action := range actions {
+		for _, status := range statuses {
+			for _, collection := range []string{"send_invoice", "charge_automatically"} {
+				invoice := &Invoice{ID: "in_1", Status: status, CollectionMethod: collection}
+				want := allowed[action][status]
+				if action == InvoiceSend && collection != "send_invoice" {
+					want = false
+				}
+
+				err := CheckInvoiceAction(invoice, action)
+				if want {
+					if err != nil {
+						t.Errorf("%s on %s (%s) = %v, want allowed", action, status, collection, err)
+					}
+					continue
+				}
+				if !errors.Is(err, ErrInvalidTransition) {
+					t.Errorf("%s on %s (%s) = %v, want ErrInvalidTransition", action, status, collection, err)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckInvoiceActionMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		invoice Invoice
+		action  InvoiceAction
+		want    string
+	}{
+		{"lists allowed statuses", Invoice{Status: "paid"}, InvoicePay, "cannot pay a paid invoice; it must be open or uncollectible"},
+		{"spells out the action", Invoice{Status: "draft"}, InvoiceMarkUncollectible, "cannot mark uncollectible a draft invoice; it must be open"},
+		{"points drafts at delete", Invoice{Status: "draft"}, InvoiceVoid, "delete the draft instead"},
+		{"send needs send_invoice", Invoice{Status: "open", CollectionMethod: "charge_automatically"}, InvoiceSend, "collection_method send_invoice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckInvoiceAction(&tt.invoice, tt.action)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("CheckInvoiceAction = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	err := CheckInvoiceAction(&Invoice{Status: "open"}, "refund")
+	if err == nil || errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("unknown action = %v, want an error other than ErrInvalidTransition", err)
+	}
+}
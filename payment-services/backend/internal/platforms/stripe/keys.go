@@ -0,0 +1,135 @@
+package stripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// KeyType is the kind of Stripe API key
+type KeyType string
+
+const (
+	KeySecret     KeyType = "secret"     // sk_
+	KeyRestricted KeyType = "restricted" // rk_, limited to the permissions chosen in the Dashboard
+)
+
+// KeyInfo is what a key's prefix says about it
+type KeyInfo struct {
+	Type KeyType
+	Live bool
+}
+
+// ParseKey reads the type and mode from a key's prefix (sk_test_, sk_live_,
+// rk_test_, rk_live_). Publishable keys cannot call the API and are rejected.
+func ParseKey(apiKey string) (KeyInfo, error) {
+	prefix, rest, _ := strings.Cut(apiKey, "_")
+	mode, secret, ok := strings.Cut(rest, "_")
+	if !ok || secret == "" || (mode != "test" && mode != "live") {
+		return KeyInfo{}, fmt.Errorf("unrecognized Stripe API key: expected sk_test_, sk_live_, rk_test_ or rk_live_")
+	}
+
+	info := KeyInfo{Live: mode == "live"}
+	switch prefix {
+	case "sk":
+		info.Type = KeySecret
+	case "rk":
+		info.Type = KeyRestricted
+	case "pk":
+		return KeyInfo{}, fmt.Errorf("publishable keys (pk_) cannot be used; use a secret (sk_) or restricted (rk_) key")
+	default:
+		return KeyInfo{}, fmt.Errorf("unrecognized Stripe API key: expected sk_test_, sk_live_, rk_test_ or rk_live_")
+	}
+	return info, nil
+}
+
+// probedResources are the list endpoints checked when reporting a
+// restricted key's permissions. Only reads are probed; writes cannot be
+// tested without side effects.
+var probedResources = []string{
+	"customers", "subscriptions", "invoices", "charges", "products", "prices", "coupons", "balance",
+}
+
+// KeyReport describes a key as seen by the Stripe API
+type KeyReport struct {
+	KeyInfo
+	Livemode    *bool             // livemode from an API response, when one could be read
+	Permissions map[string]string // resource -> "read" or "none", for restricted keys
+}
+
+// InspectKey checks the key's mode against the livemode flag Stripe returns
+// and, for restricted keys, which resources it can read
+func (c *Client) InspectKey() (*KeyReport, error) {
+	info, err := ParseKey(c.apiKey)
+	if err != nil {
+		return nil, err
+	}
+	report := &KeyReport{KeyInfo: info}
+
+	resources := []string{"balance"}
+	if info.Type == KeyRestricted {
+		resources = probedResources
+		report.Permissions = make(map[string]string, len(resources))
+	}
+
+	for _, resource := range resources {
+		livemode, allowed, err := c.probe(resource)
+		if err != nil {
+			return nil, err
+		}
+		if report.Permissions != nil {
+			report.Permissions[resource] = "none"
+			if allowed {
+				report.Permissions[resource] = "read"
+			}
+		}
+		if report.Livemode == nil && livemode != nil {
+			report.Livemode = livemode
+		}
+	}
+	return report, nil
+}
+
+// probe reads one object of a resource, returning its livemode flag if the
+// response carried one and whether the key was allowed to read it
+func (c *Client) probe(resource string) (livemode *bool, allowed bool, err error) {
+	path := "/" + resource
+	if resource != "balance" {
+		path += "?limit=1"
+	}
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("connection failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, false, fmt.Errorf("authentication failed: invalid API key")
+	case resp.StatusCode == http.StatusForbidden:
+		io.Copy(io.Discard, resp.Body)
+		return nil, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, c.parseError(resp)
+	}
+
+	// The balance object carries livemode itself; lists carry it on each item
+	var body struct {
+		Livemode *bool `json:"livemode"`
+		Data     []struct {
+			Livemode *bool `json:"livemode"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, true, nil
+	}
+	if body.Livemode != nil {
+		return body.Livemode, true, nil
+	}
+	if len(body.Data) > 0 {
+		return body.Data[0].Livemode, true, nil
+	}
+	return nil, true, nil
+}
@@ -0,0 +1,155 @@
+package stripe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    KeyInfo
+		wantErr string
+	}{
+		{key: "sk_test_51Abc", want: KeyInfo{Type: KeySecret, Live: false}},
+		{key: "sk_live_51Abc", want: KeyInfo{Type: KeySecret, Live: true}},
+		{key: "rk_test_51Abc", want: KeyInfo{Type: KeyRestricted, Live: false}},
+		{key: "rk_live_51Abc", want: KeyInfo{Type: KeyRestricted, Live: true}},
+		{key: "sk_live_", wantErr: "unrecognized"},
+		{key: "pk_live_51Abc", wantErr: "publishable keys"},
+		{key: "pk_test_51Abc", wantErr: "publishable keys"},
+		{key: "sk_prod_51Abc", wantErr: "unrecognized"},
+		{key: "sk_LIVE_51Abc", wantErr: "unrecognized"},
+		{key: "xk_live_51Abc", wantErr: "unrecognized"},
+		{key: "sk_live", wantErr: "unrecognized"},
+		{key: "sklive51Abc", wantErr: "unrecognized"},
+		{key: "", wantErr: "unrecognized"},
+		{key: "whsec_live_abc", wantErr: "unrecognized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := ParseKey(tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseKey(%q) = %+v, %v; want error containing %q", tt.key, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseKey(%q) = %+v, %v; want %+v", tt.key, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSandboxAgainstKey(t *testing.T) {
+	def, ok := platforms.Lookup(models.PlatformStripe)
+	if !ok {
+		t.Fatal("stripe is not registered")
+	}
+
+	tests := []struct {
+		name      string
+		apiKey    string
+		isSandbox bool
+		wantErr   string
+	}{
+		{"test key on sandbox", "sk_test_abc", true, ""},
+		{"live key on live", "sk_live_abc", false, ""},
+		{"restricted live key on live", "rk_live_abc", false, ""},
+		{"live key marked sandbox", "sk_live_abc", true, "credentials are for a live environment"},
+		{"restricted live key marked sandbox", "rk_live_abc", true, "credentials are for a live environment"},
+		{"test key marked live", "sk_test_abc", false, "credentials are for a sandbox/test environment"},
+		{"secret reference is checked later", "", true, ""},
+		{"publishable key", "pk_live_abc", false, "publishable keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := platforms.ConnectionConfig{
+				IsSandbox:   tt.isSandbox,
+				Credentials: map[string]string{"api_key": tt.apiKey},
+			}
+			err := def.CheckSandbox(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckSandbox = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckSandbox = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInspectKey(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiKey          string
+		status          map[string]int // resource -> status; 200 when absent
+		livemode        bool
+		wantPermissions map[string]string
+	}{
+		{
+			name:     "live secret key",
+			apiKey:   "sk_live_abc",
+			livemode: true,
+		},
+		{
+			name:     "restricted test key",
+			apiKey:   "rk_test_abc",
+			status:   map[string]int{"invoices": http.StatusForbidden, "balance": http.StatusForbidden},
+			livemode: false,
+			wantPermissions: map[string]string{
+				"customers": "read", "subscriptions": "read", "invoices": "none", "charges": "read",
+				"products": "read", "prices": "read", "coupons": "read", "balance": "none",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resource := strings.TrimPrefix(r.URL.Path, "/")
+				if status, ok := tt.status[resource]; ok {
+					w.WriteHeader(status)
+					w.Write([]byte(`{"error":{"type":"invalid_request_error","message":"permission denied"}}`))
+					return
+				}
+				if resource == "balance" {
+					json.NewEncoder(w).Encode(map[string]interface{}{"object": "balance", "livemode": tt.livemode})
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"object": "list",
+					"data":   []map[string]interface{}{{"id": "x_1", "livemode": tt.livemode}},
+				})
+			}))
+			defer server.Close()
+
+			client := NewClient(tt.apiKey)
+			client.baseURL = server.URL
+			report, err := client.InspectKey()
+			if err != nil {
+				t.Fatalf("InspectKey: %v", err)
+			}
+			if report.Livemode == nil || *report.Livemode != tt.livemode {
+				t.Errorf("Livemode = %v, want %v", report.Livemode, tt.livemode)
+			}
+			if len(report.Permissions) != len(tt.wantPermissions) {
+				t.Fatalf("Permissions = %v, want %v", report.Permissions, tt.wantPermissions)
+			}
+			for resource, want := range tt.wantPermissions {
+				if got := report.Permissions[resource]; got != want {
+					t.Errorf("Permissions[%s] = %q, want %q", resource, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package stripe
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestPaymentMethodRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(*Client) error
+		path     string
+		wantForm url.Values
+	}{
+		{
+			name:     "attach",
+			call:     func(c *Client) error { _, err := c.AttachPaymentMethod("pm_1", "cus_1"); return err },
+			path:     "/payment_methods/pm_1/attach",
+			wantForm: url.Values{"customer": {"cus_1"}},
+		},
+		{
+			name:     "detach",
+			call:     func(c *Client) error { _, err := c.DetachPaymentMethod("pm_1"); return err },
+			path:     "/payment_methods/pm_1/detach",
+			wantForm: url.Values{},
+		},
+		{
+			name:     "set default",
+			call:     func(c *Client) error { _, err := c.SetDefaultPaymentMethod("cus_1", "pm_1"); return err },
+			path:     "/customers/cus_1",
+			wantForm: url.Values{"invoice_settings[default_payment_method]": {"pm_1"}},
+		},
+		{
+			name:     "clear default",
+			call:     func(c *Client) error { _, err := c.SetDefaultPaymentMethod("cus_1", ""); return err },
+			path:     "/customers/cus_1",
+			wantForm: url.Values{"invoice_settings[default_payment_method]": {""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != tt.path {
+					t.Errorf("request = %s %s, want POST %s", r.Method, r.URL.Path, tt.path)
+				}
+				if got := formOf(t, r); got.Encode() != tt.wantForm.Encode() {
+					t.Errorf("form = %s, want %s", got.Encode(), tt.wantForm.Encode())
+				}
+				if r.Header.Get("Idempotency-Key") == "" {
+					t.Error("write sent without an Idempotency-Key")
+				}
+				writeJSON(w, http.StatusOK, PaymentMethod{ID: "pm_1", Type: "card", Customer: "cus_1"})
+			})
+
+			if err := tt.call(client); err != nil {
+				t.Fatalf("call: %v", err)
+			}
+		})
+	}
+}
+
+func TestAttachPaymentMethodDecodes(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"pm_1","object":"payment_method","type":"card","customer":"cus_1",
+			"card":{"brand":"visa","last4":"4242","exp_month":12,"exp_year":2030,"funding":"credit"}}`))
+	})
+
+	paymentMethod, err := client.AttachPaymentMethod("pm_1", "cus_1")
+	if err != nil {
+		t.Fatalf("AttachPaymentMethod: %v", err)
+	}
+	if paymentMethod.Customer != "cus_1" || paymentMethod.Card == nil || paymentMethod.Card.Last4 != "4242" {
+		t.Errorf("payment method = %+v, card %+v", paymentMethod, paymentMethod.Card)
+	}
+}
+
+func TestCreateSetupIntentEncodesForm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input SetupIntentInput
+		want  url.Values
+	}{
+		{
+			name:  "automatic payment methods",
+			input: SetupIntentInput{Customer: "cus_1"},
+			want:  url.Values{"customer": {"cus_1"}, "automatic_payment_methods[enabled]": {"true"}},
+		},
+		{
+			name: "explicit types and usage",
+			input: SetupIntentInput{
+				Customer:           "cus_1",
+				PaymentMethodTypes: []string{"card", "us_bank_account"},
+				Usage:              "off_session",
+				Description:        "Card for renewals",
+				Metadata:           map[string]string{"source": "hub"},
+			},
+			want: url.Values{
+				"customer":               {"cus_1"},
+				"payment_method_types[]": {"card", "us_bank_account"},
+				"usage":                  {"off_session"},
+				"description":            {"Card for renewals"},
+				"metadata[source]":       {"hub"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/setup_intents" {
+					t.Errorf("request = %s %s, want POST /setup_intents", r.Method, r.URL.Path)
+				}
+				if got := formOf(t, r); got.Encode() != tt.want.Encode() {
+					t.Errorf("form = %s, want %s", got.Encode(), tt.want.Encode())
+				}
+				writeJSON(w, http.StatusOK, SetupIntent{ID: "seti_1", Customer: "cus_1", Status: "requires_payment_method", ClientSecret: "seti_1_secret_x"})
+			})
+
+			setupIntent, err := client.CreateSetupIntent(tt.input)
+			if err != nil {
+				t.Fatalf("CreateSetupIntent: %v", err)
+			}
+			if setupIntent.ID != "seti_1" || setupIntent.ClientSecret != "seti_1_secret_x" {
+				t.Errorf("setup intent = %+v", setupIntent)
+			}
+		})
+	}
+}
+
+func TestPaymentMethodErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		call        func(*Client) error
+		wantStatus  int
+		wantType    string
+		wantMessage string
+	}{
+		{
+			name:        "attach declined",
+			status:      http.StatusPaymentRequired,
+			body:        `{"error":{"type":"card_error","code":"card_declined","message":"Your card was declined."}}`,
+			call:        func(c *Client) error { _, err := c.AttachPaymentMethod("pm_1", "cus_1"); return err },
+			wantStatus:  http.StatusPaymentRequired,
+			wantType:    "card_error",
+			wantMessage: "Your card was declined.",
+		},
+		{
+			name:        "detach of a detached payment method",
+			status:      http.StatusBadRequest,
+			body:        `{"error":{"type":"invalid_request_error","message":"The payment method you provided is not attached to a customer so detachment is impossible."}}`,
+			call:        func(c *Client) error { _, err := c.DetachPaymentMethod("pm_1"); return err },
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "invalid_request_error",
+			wantMessage: "The payment method you provided is not attached to a customer so detachment is impossible.",
+		},
+		{
+			name:   "setup intent for a missing customer",
+			status: http.StatusBadRequest,
+			body:   `{"error":{"type":"invalid_request_error","param":"customer","message":"No such customer: 'cus_missing'"}}`,
+			call: func(c *Client) error {
+				_, err := c.CreateSetupIntent(SetupIntentInput{Customer: "cus_missing"})
+				return err
+			},
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "invalid_request_error",
+			wantMessage: "No such customer: 'cus_missing'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			err := tt.call(client)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.wantStatus || apiErr.Type != tt.wantType || apiErr.Message != tt.wantMessage {
+				t.Errorf("error = %+v, want status %d, type %q, message %q", apiErr, tt.wantStatus, tt.wantType, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package stripe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// Provider adapts the Stripe client to the platforms.BillingProvider interface
+type Provider struct {
+	client *Client
+}
+
+var (
+	_ platforms.BillingProvider    = (*Provider)(nil)
+	_ platforms.ResponseObservable = (*Provider)(nil)
+)
+
+// NewProvider wraps a Stripe client as a BillingProvider
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+// Client returns the underlying Stripe client for platform-specific calls
+func (p *Provider) Client() *Client {
+	return p.client
+}
+
+// WithResponseObserver returns a provider whose calls report the status of
+// every Stripe response to observe
+func (p *Provider) WithResponseObserver(observe func(status int)) platforms.BillingProvider {
+	return NewProvider(p.client.WithResponseObserver(observe))
+}
+
+// Platform returns the platform type
+func (p *Provider) Platform() models.PlatformType {
+	return models.PlatformStripe
+}
+
+// TestConnection tests the API connection
+func (p *Provider) TestConnection() error {
+	return p.client.TestConnection()
+}
+
+var _ platforms.EnvironmentDetector = (*Provider)(nil)
+
+// DetectEnvironment reports whether the key reaches test mode or live mode.
+// Stripe's livemode flag is preferred; the key prefix is used when no
+// response carried one. A key whose prefix contradicts livemode is an error.
+func (p *Provider) DetectEnvironment() (*platforms.EnvironmentReport, error) {
+	key, err := p.client.InspectKey()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &platforms.EnvironmentReport{
+		IsSandbox:   !key.Live,
+		Source:      "key prefix",
+		Restricted:  key.Type == KeyRestricted,
+		Permissions: key.Permissions,
+	}
+	if key.Livemode != nil {
+		if *key.Livemode != key.Live {
+			return nil, fmt.Errorf("stripe reported livemode=%t for a key with a %s prefix", *key.Livemode, keyMode(key.Live))
+		}
+		report.Source = "livemode"
+	}
+	return report, nil
+}
+
+func keyMode(live bool) string {
+	if live {
+		return "live"
+	}
+	return "test"
+}
+
+// createdAfter maps UpdatedSince onto Stripe's created[gt] filter; Stripe
+// lists cannot filter on update time
+func createdAfter(params platforms.ListParams) int64 {
+	if params.UpdatedSince == nil {
+		return 0
+	}
+	return params.UpdatedSince.Unix()
+}
+
+// ListCustomers returns a page of normalized customers
+func (p *Provider) ListCustomers(params platforms.ListParams) ([]models.Customer, string, error) {
+	list, err := p.client.ListCustomers(params.Limit, params.Cursor, createdAfter(params))
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Customer, len(list.Data))
+	for i, customer := range list.Data {
+		result[i] = customerToModel(customer)
+	}
+	next := ""
+	if list.HasMore && len(list.Data) > 0 {
+		next = list.Data[len(list.Data)-1].ID
+	}
+	return result, next, nil
+}
+
+// GetCustomer returns a single normalized customer
+func (p *Provider) GetCustomer(id string) (*models.Customer, error) {
+	customer, err := p.client.GetCustomer(id)
+	if err != nil {
+		return nil, err
+	}
+	result := customerToModel(*customer)
+	return &result, nil
+}
+
+// CreateCustomer creates a customer from the platform-agnostic request
+func (p *Provider) CreateCustomer(input models.CreateCustomerRequest) (*models.Customer, error) {
+	customerInput := CustomerInput{
+		Name:        strings.TrimSpace(input.FirstName + " " + input.LastName),
+		Email:       input.Email,
+		Description: input.Organization,
+	}
+	if input.Reference != "" {
+		customerInput.Metadata = map[string]string{"reference": input.Reference}
+	}
+
+	customer, err := p.client.CreateCustomer(customerInput)
+	if err != nil {
+		return nil, err
+	}
+	result := customerToModel(*customer)
+	return &result, nil
+}
+
+// ListSubscriptions returns a page of normalized subscriptions
+func (p *Provider) ListSubscriptions(params platforms.ListParams) ([]models.Subscription, string, error) {
+	list, err := p.client.ListSubscriptions(params.Limit, params.Cursor, createdAfter(params))
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Subscription, len(list.Data))
+	for i, sub := range list.Data {
+		result[i] = subscriptionToModel(sub)
+	}
+	next := ""
+	if list.HasMore && len(list.Data) > 0 {
+		next = list.Data[len(list.Data)-1].ID
+	}
+	return result, next, nil
+}
+
+// GetSubscription returns a single normalized subscription
+func (p *Provider) GetSubscription(id string) (*models.Subscription, error) {
+	subscription, err := p.client.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	result := subscriptionToModel(*subscription)
+	return &result, nil
+}
+
+// ListInvoices returns a page of normalized invoices
+func (p *Provider) ListInvoices(params platforms.ListParams) ([]models.Invoice, string, error) {
+	list, err := p.client.ListInvoices(params.Limit, params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Invoice, len(list.Data))
+	for i, invoice := range list.Data {
+		result[i] = invoiceToModel(invoice)
+	}
+	next := ""
+	if list.HasMore && len(list.Data) > 0 {
+		next = list.Data[len(list.Data)-1].ID
+	}
+	return result, next, nil
+}
+
+// GetInvoice returns a single normalized invoice
+func (p *Provider) GetInvoice(id string) (*models.Invoice, error) {
+	invoice, err := p.client.GetInvoice(id)
+	if err != nil {
+		return nil, err
+	}
+	result := invoiceToModel(*invoice)
+	return &result, nil
+}
+
+// ListPayments returns a page of charges as normalized payments
+func (p *Provider) ListPayments(params platforms.ListParams) ([]models.Payment, string, error) {
+	list, err := p.client.ListCharges(params.Limit, params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Payment, len(list.Data))
+	for i, charge := range list.Data {
+		result[i] = chargeToModel(charge)
+	}
+	next := ""
+	if list.HasMore && len(list.Data) > 0 {
+		next = list.Data[len(list.Data)-1].ID
+	}
+	return result, next, nil
+}
+
+// ListProducts returns a page of normalized products
+func (p *Provider) ListProducts(params platforms.ListParams) ([]models.Product, string, error) {
+	list, err := p.client.ListProducts(params.Limit, params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Product, len(list.Data))
+	for i, product := range list.Data {
+		result[i] = productToModel(product)
+	}
+	next := ""
+	if list.HasMore && len(list.Data) > 0 {
+		next = list.Data[len(list.Data)-1].ID
+	}
+	return result, next, nil
+}
+
+func customerToModel(customer Customer) models.Customer {
+	// Stripe stores a single name; split it so the common fields are populated
+	firstName, lastName, _ := strings.Cut(customer.Name, " ")
+	return models.Customer{
+		ID:           customer.ID,
+		Reference:    customer.Metadata["reference"],
+		FirstName:    firstName,
+		LastName:     lastName,
+		Email:        customer.Email,
+		Organization: customer.Description,
+		CreatedAt:    platforms.UnixTime(customer.Created),
+		RawData:      platforms.RawData(customer),
+	}
+}
+
+func subscriptionToModel(sub Subscription) models.Subscription {
+	return models.Subscription{
+		ID:                 sub.ID,
+		CustomerID:         sub.Customer,
+		State:              sub.Status,
+		CurrentPeriodStart: platforms.UnixTime(sub.CurrentPeriodStart),
+		CurrentPeriodEnd:   platforms.UnixTime(sub.CurrentPeriodEnd),
+		CreatedAt:          platforms.UnixTime(sub.Created),
+		RawData:            platforms.RawData(sub),
+	}
+}
+
+// NormalizeInvoice converts a Stripe invoice to the normalized model, for
+// callers that obtained it from the Client directly
+func NormalizeInvoice(invoice Invoice) models.Invoice {
+	return invoiceToModel(invoice)
+}
+
+func invoiceToModel(invoice Invoice) models.Invoice {
+	result := models.Invoice{
+		ID:         invoice.ID,
+		Number:     invoice.Number,
+		CustomerID: invoice.Customer,
+		Status:     invoice.Status,
+		Total:      platforms.FormatAmount(invoice.Total, invoice.Currency),
+		Currency:   invoice.Currency,
+		CreatedAt:  platforms.UnixTime(invoice.Created),
+		RawData:    platforms.RawData(invoice),
+	}
+	if invoice.DueDate != nil {
+		result.DueDate = platforms.UnixTime(*invoice.DueDate)
+	}
+	return result
+}
+
+func chargeToModel(charge Charge) models.Payment {
+	return models.Payment{
+		ID:        charge.ID,
+		Amount:    platforms.FormatAmount(charge.Amount, charge.Currency),
+		Currency:  charge.Currency,
+		Status:    charge.Status,
+		CreatedAt: platforms.UnixTime(charge.Created),
+		RawData:   platforms.RawData(charge),
+	}
+}
+
+func productToModel(product Product) models.Product {
+	return models.Product{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		CreatedAt:   platforms.UnixTime(product.Created),
+		RawData:     platforms.RawData(product),
+	}
+}
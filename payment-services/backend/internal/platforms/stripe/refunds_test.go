@@ -0,0 +1,140 @@
+package stripe
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newTestClient returns a client pointed at a test server running handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := NewClient("sk_test_abc")
+	client.baseURL = server.URL
+	client.retryDelay = 0
+	return client
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// formOf parses the form a test server received, failing on a bad body
+func formOf(t *testing.T, r *http.Request) url.Values {
+	t.Helper()
+	if err := r.ParseForm(); err != nil {
+		t.Fatalf("parse form: %v", err)
+	}
+	return r.Form
+}
+
+func TestCreateRefundEncodesForm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input RefundInput
+		want  url.Values
+	}{
+		{
+			name:  "full refund of a charge",
+			input: RefundInput{Charge: "ch_1"},
+			want:  url.Values{"charge": {"ch_1"}},
+		},
+		{
+			name:  "partial refund of a PaymentIntent with a reason",
+			input: RefundInput{PaymentIntent: "pi_1", Amount: 1250, Reason: "requested_by_customer"},
+			want:  url.Values{"payment_intent": {"pi_1"}, "amount": {"1250"}, "reason": {"requested_by_customer"}},
+		},
+		{
+			name:  "metadata",
+			input: RefundInput{Charge: "ch_1", Metadata: map[string]string{"ticket": "T-9"}},
+			want:  url.Values{"charge": {"ch_1"}, "metadata[ticket]": {"T-9"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/refunds" {
+					t.Errorf("request = %s %s, want POST /refunds", r.Method, r.URL.Path)
+				}
+				if got := formOf(t, r); got.Encode() != tt.want.Encode() {
+					t.Errorf("form = %s, want %s", got.Encode(), tt.want.Encode())
+				}
+				if r.Header.Get("Idempotency-Key") == "" {
+					t.Error("refund sent without an Idempotency-Key")
+				}
+				writeJSON(w, http.StatusOK, Refund{ID: "re_1", Amount: 1250, Status: "succeeded"})
+			})
+
+			refund, err := client.CreateRefund(tt.input)
+			if err != nil {
+				t.Fatalf("CreateRefund: %v", err)
+			}
+			if refund.ID != "re_1" || refund.Status != "succeeded" {
+				t.Errorf("refund = %+v", refund)
+			}
+		})
+	}
+}
+
+func TestRefundErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		call        func(*Client) error
+		wantStatus  int
+		wantType    string
+		wantMessage string
+	}{
+		{
+			name:        "already refunded",
+			status:      http.StatusBadRequest,
+			body:        `{"error":{"type":"invalid_request_error","code":"charge_already_refunded","message":"Charge ch_1 has already been refunded."}}`,
+			call:        func(c *Client) error { _, err := c.CreateRefund(RefundInput{Charge: "ch_1"}); return err },
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "invalid_request_error",
+			wantMessage: "Charge ch_1 has already been refunded.",
+		},
+		{
+			name:        "unparseable error body",
+			status:      http.StatusBadGateway,
+			body:        `<html>bad gateway</html>`,
+			call:        func(c *Client) error { _, err := c.CreateRefund(RefundInput{Charge: "ch_1"}); return err },
+			wantStatus:  http.StatusBadGateway,
+			wantMessage: "API error (status 502): <html>bad gateway</html>\n",
+		},
+		{
+			name:        "missing refund",
+			status:      http.StatusNotFound,
+			body:        `{"error":{"type":"invalid_request_error","message":"No such refund"}}`,
+			call:        func(c *Client) error { _, err := c.GetRefund("re_missing"); return err },
+			wantStatus:  http.StatusNotFound,
+			wantMessage: "refund not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Stripe-Should-Retry", "false")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body + "\n"))
+			})
+
+			err := tt.call(client)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.wantStatus || apiErr.Type != tt.wantType || apiErr.Message != tt.wantMessage {
+				t.Errorf("error = %+v, want status %d, type %q, message %q", apiErr, tt.wantStatus, tt.wantType, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package stripe
+
+import (
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+func init() {
+	platforms.Register(platforms.Definition{
+		Type:        models.PlatformStripe,
+		DisplayName: "Stripe",
+		Credentials: []platforms.CredentialField{
+			{Type: "api_key", Label: "API key", Required: true},
+			{Type: "webhook_secret", Label: "Webhook signing secret", Required: false},
+		},
+		TreeContainers: []platforms.TreeContainer{
+			{Type: "customers", Name: "Customers"},
+			{Type: "subscriptions", Name: "Subscriptions"},
+			{Type: "product-families", Name: "Product Families"},
+			{Type: "invoices", Name: "Invoices"},
+			{Type: "payments", Name: "Payments"},
+			{Type: "coupons", Name: "Coupons"},
+		},
+		NewProvider: func(cfg platforms.ConnectionConfig) (platforms.BillingProvider, error) {
+			return NewProvider(NewClient(cfg.Credentials["api_key"])), nil
+		},
+		InferSandbox: func(cfg platforms.ConnectionConfig) (bool, bool, error) {
+			apiKey := cfg.Credentials["api_key"]
+			if apiKey == "" {
+				// Stored by secret reference; checked when the connection is tested
+				return false, false, nil
+			}
+			key, err := ParseKey(apiKey)
+			if err != nil {
+				return false, false, err
+			}
+			return !key.Live, true, nil
+		},
+		// Stripe lists can only filter on created
+		IncrementalByCreation: true,
+	})
+}
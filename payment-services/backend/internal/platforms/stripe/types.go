@@ -4,18 +4,24 @@ import "time"
 
 // Customer represents a Stripe customer
 type Customer struct {
-	ID          string            `json:"id"`
-	Object      string            `json:"object"`
-	Name        string            `json:"name,omitempty"`
-	Email       string            `json:"email,omitempty"`
-	Phone       string            `json:"phone,omitempty"`
-	Description string            `json:"description,omitempty"`
-	Created     int64             `json:"created"`
-	Metadata    map[string]string `json:"metadata,omitempty"`
-	Address     *Address          `json:"address,omitempty"`
-	Currency    string            `json:"currency,omitempty"`
-	Delinquent  bool              `json:"delinquent"`
-	Livemode    bool              `json:"livemode"`
+	ID              string                   `json:"id"`
+	Object          string                   `json:"object"`
+	Name            string                   `json:"name,omitempty"`
+	Email           string                   `json:"email,omitempty"`
+	Phone           string                   `json:"phone,omitempty"`
+	Description     string                   `json:"description,omitempty"`
+	Created         int64                    `json:"created"`
+	Metadata        map[string]string        `json:"metadata,omitempty"`
+	Address         *Address                 `json:"address,omitempty"`
+	Currency        string                   `json:"currency,omitempty"`
+	Delinquent      bool                     `json:"delinquent"`
+	InvoiceSettings *CustomerInvoiceSettings `json:"invoice_settings,omitempty"`
+	Livemode        bool                     `json:"livemode"`
+}
+
+// CustomerInvoiceSettings holds a customer's invoice defaults
+type CustomerInvoiceSettings struct {
+	DefaultPaymentMethod string `json:"default_payment_method,omitempty"` // Charged for invoices and subscriptions that don't name one
 }
 
 // CreatedTime returns the created timestamp as time.Time
@@ -173,7 +179,9 @@ type Invoice struct {
 	Object           string `json:"object"`
 	Customer         string `json:"customer"`
 	Subscription     string `json:"subscription,omitempty"`
-	Status           string `json:"status"`
+	Status           string `json:"status"` // draft, open, paid, uncollectible, void
+	CollectionMethod string `json:"collection_method,omitempty"`
+	AutoAdvance      bool   `json:"auto_advance"`
 	Currency         string `json:"currency"`
 	AmountDue        int64  `json:"amount_due"`
 	AmountPaid       int64  `json:"amount_paid"`
@@ -200,6 +208,45 @@ type InvoiceList struct {
 	Data    []Invoice `json:"data"`
 }
 
+// InvoiceInput is the input for creating a draft invoice
+// Maps to POST /v1/invoices - https://docs.stripe.com/api/invoices/create
+type InvoiceInput struct {
+	Customer         string            `json:"customer"`
+	CollectionMethod string            `json:"collection_method,omitempty"` // charge_automatically (default) or send_invoice
+	DaysUntilDue     int               `json:"days_until_due,omitempty"`    // Required if collection_method=send_invoice
+	Description      string            `json:"description,omitempty"`
+	AutoAdvance      *bool             `json:"auto_advance,omitempty"` // Finalize automatically about an hour after creation
+	Currency         string            `json:"currency,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+// InvoiceItem represents a Stripe invoice item
+// https://docs.stripe.com/api/invoiceitems
+type InvoiceItem struct {
+	ID          string `json:"id"`
+	Object      string `json:"object"`
+	Customer    string `json:"customer"`
+	Invoice     string `json:"invoice,omitempty"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description,omitempty"`
+	Quantity    int64  `json:"quantity"`
+	Date        int64  `json:"date"`
+	Livemode    bool   `json:"livemode"`
+}
+
+// InvoiceItemInput is the input for adding an item to a draft invoice.
+// Give either Price or Amount and Currency.
+// Maps to POST /v1/invoiceitems - https://docs.stripe.com/api/invoiceitems/create
+type InvoiceItemInput struct {
+	Price       string            `json:"price,omitempty"`    // Price ID, or
+	Amount      int64             `json:"amount,omitempty"`   // Total amount in cents
+	Currency    string            `json:"currency,omitempty"` // Required with amount
+	Quantity    int64             `json:"quantity,omitempty"` // Only with price
+	Description string            `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
 // PaymentIntent represents a Stripe payment intent
 type PaymentIntent struct {
 	ID            string `json:"id"`
@@ -304,6 +351,199 @@ type SubscriptionUpdateInput struct {
 	Metadata             map[string]string `json:"metadata,omitempty"`
 }
 
+// Refund represents a Stripe refund
+// https://docs.stripe.com/api/refunds
+type Refund struct {
+	ID            string            `json:"id"`
+	Object        string            `json:"object"`
+	Amount        int64             `json:"amount"`
+	Currency      string            `json:"currency"`
+	Charge        string            `json:"charge,omitempty"`
+	PaymentIntent string            `json:"payment_intent,omitempty"`
+	Reason        string            `json:"reason,omitempty"` // duplicate, fraudulent, requested_by_customer
+	Status        string            `json:"status"`           // pending, requires_action, succeeded, failed, canceled
+	FailureReason string            `json:"failure_reason,omitempty"`
+	Created       int64             `json:"created"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// RefundList is the response for listing refunds
+type RefundList struct {
+	Object  string   `json:"object"`
+	URL     string   `json:"url"`
+	HasMore bool     `json:"has_more"`
+	Data    []Refund `json:"data"`
+}
+
+// RefundInput is the input for creating a refund
+// Maps to POST /v1/refunds - https://docs.stripe.com/api/refunds/create
+type RefundInput struct {
+	Charge        string            `json:"charge,omitempty"`         // Charge to refund, or
+	PaymentIntent string            `json:"payment_intent,omitempty"` // PaymentIntent to refund
+	Amount        int64             `json:"amount,omitempty"`         // Amount in cents; omit to refund everything not yet refunded
+	Reason        string            `json:"reason,omitempty"`         // duplicate, fraudulent, requested_by_customer
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// CreditNote represents a Stripe credit note
+// https://docs.stripe.com/api/credit_notes
+type CreditNote struct {
+	ID              string             `json:"id"`
+	Object          string             `json:"object"`
+	Number          string             `json:"number,omitempty"`
+	Invoice         string             `json:"invoice"`
+	Customer        string             `json:"customer"`
+	Amount          int64              `json:"amount"`
+	Subtotal        int64              `json:"subtotal"`
+	Total           int64              `json:"total"`
+	Currency        string             `json:"currency"`
+	Type            string             `json:"type,omitempty"`   // pre_payment or post_payment
+	Status          string             `json:"status,omitempty"` // issued or void; empty on previews
+	Reason          string             `json:"reason,omitempty"` // duplicate, fraudulent, order_change, product_unsatisfactory
+	Memo            string             `json:"memo,omitempty"`
+	OutOfBandAmount *int64             `json:"out_of_band_amount,omitempty"`
+	Refund          string             `json:"refund,omitempty"`
+	PDF             string             `json:"pdf,omitempty"`
+	Lines           CreditNoteLineList `json:"lines"`
+	VoidedAt        *int64             `json:"voided_at,omitempty"`
+	Created         int64              `json:"created"`
+	Livemode        bool               `json:"livemode"`
+	Metadata        map[string]string  `json:"metadata,omitempty"`
+}
+
+// CreditNoteLine is a line of a credit note
+type CreditNoteLine struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"` // invoice_line_item or custom_line_item
+	InvoiceLineItem string `json:"invoice_line_item,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Quantity        int64  `json:"quantity,omitempty"`
+	UnitAmount      *int64 `json:"unit_amount,omitempty"`
+	Amount          int64  `json:"amount"`
+}
+
+// CreditNoteLineList is the list of lines on a credit note
+type CreditNoteLineList struct {
+	Object  string           `json:"object"`
+	HasMore bool             `json:"has_more"`
+	Data    []CreditNoteLine `json:"data"`
+}
+
+// CreditNoteList is the response for listing credit notes
+type CreditNoteList struct {
+	Object  string       `json:"object"`
+	URL     string       `json:"url"`
+	HasMore bool         `json:"has_more"`
+	Data    []CreditNote `json:"data"`
+}
+
+// CreditNoteInput is the input for previewing or creating a credit note.
+// Give either Amount or Lines. The credit can be split between a refund,
+// the customer's balance and an out-of-band payment; if none is given,
+// Stripe reduces the amount due on an open invoice or credits the balance
+// of a paid one.
+// Maps to POST /v1/credit_notes - https://docs.stripe.com/api/credit_notes/create
+type CreditNoteInput struct {
+	Invoice         string                `json:"invoice"`
+	Amount          int64                 `json:"amount,omitempty"` // Total in cents, when not itemized by Lines
+	Lines           []CreditNoteLineInput `json:"lines,omitempty"`
+	Reason          string                `json:"reason,omitempty"` // duplicate, fraudulent, order_change, product_unsatisfactory
+	Memo            string                `json:"memo,omitempty"`
+	RefundAmount    int64                 `json:"refund_amount,omitempty"`      // Part to refund to the payment method
+	CreditAmount    int64                 `json:"credit_amount,omitempty"`      // Part to credit to the customer's balance
+	OutOfBandAmount int64                 `json:"out_of_band_amount,omitempty"` // Part settled outside Stripe
+	Metadata        map[string]string     `json:"metadata,omitempty"`
+}
+
+// CreditNoteLineInput is one line of a credit note to preview or create
+type CreditNoteLineInput struct {
+	Type            string `json:"type"`                        // invoice_line_item or custom_line_item
+	InvoiceLineItem string `json:"invoice_line_item,omitempty"` // Required for invoice_line_item
+	Amount          int64  `json:"amount,omitempty"`            // invoice_line_item: amount to credit
+	Quantity        int64  `json:"quantity,omitempty"`
+	UnitAmount      int64  `json:"unit_amount,omitempty"` // custom_line_item: price per unit in cents
+	Description     string `json:"description,omitempty"` // Required for custom_line_item
+}
+
+// PaymentMethod represents a Stripe payment method
+// https://docs.stripe.com/api/payment_methods
+type PaymentMethod struct {
+	ID             string                `json:"id"`
+	Object         string                `json:"object"`
+	Type           string                `json:"type"`               // card, us_bank_account, sepa_debit, ...
+	Customer       string                `json:"customer,omitempty"` // Empty until attached
+	Card           *PaymentMethodCard    `json:"card,omitempty"`
+	BillingDetails *PaymentMethodBilling `json:"billing_details,omitempty"`
+	Created        int64                 `json:"created"`
+	Livemode       bool                  `json:"livemode"`
+	Metadata       map[string]string     `json:"metadata,omitempty"`
+}
+
+// PaymentMethodCard describes a card payment method
+type PaymentMethodCard struct {
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+	Funding  string `json:"funding,omitempty"` // credit, debit, prepaid, unknown
+	Country  string `json:"country,omitempty"`
+}
+
+// PaymentMethodBilling is the billing contact of a payment method
+type PaymentMethodBilling struct {
+	Name    string   `json:"name,omitempty"`
+	Email   string   `json:"email,omitempty"`
+	Phone   string   `json:"phone,omitempty"`
+	Address *Address `json:"address,omitempty"`
+}
+
+// PaymentMethodList is the response for listing payment methods
+type PaymentMethodList struct {
+	Object  string          `json:"object"`
+	URL     string          `json:"url"`
+	HasMore bool            `json:"has_more"`
+	Data    []PaymentMethod `json:"data"`
+}
+
+// SetupIntent represents a Stripe SetupIntent, used to collect a payment
+// method for later charges. The client secret is handed to Stripe.js, which
+// collects the details; on success the payment method is attached to the
+// customer.
+// https://docs.stripe.com/api/setup_intents
+type SetupIntent struct {
+	ID                 string            `json:"id"`
+	Object             string            `json:"object"`
+	Customer           string            `json:"customer,omitempty"`
+	Status             string            `json:"status"` // requires_payment_method, requires_confirmation, requires_action, processing, canceled, succeeded
+	Usage              string            `json:"usage"`  // off_session or on_session
+	ClientSecret       string            `json:"client_secret,omitempty"`
+	PaymentMethod      string            `json:"payment_method,omitempty"`
+	PaymentMethodTypes []string          `json:"payment_method_types,omitempty"`
+	Description        string            `json:"description,omitempty"`
+	CancellationReason string            `json:"cancellation_reason,omitempty"`
+	Created            int64             `json:"created"`
+	Livemode           bool              `json:"livemode"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+// SetupIntentList is the response for listing SetupIntents
+type SetupIntentList struct {
+	Object  string        `json:"object"`
+	URL     string        `json:"url"`
+	HasMore bool          `json:"has_more"`
+	Data    []SetupIntent `json:"data"`
+}
+
+// SetupIntentInput is the input for creating a SetupIntent
+// Maps to POST /v1/setup_intents - https://docs.stripe.com/api/setup_intents/create
+type SetupIntentInput struct {
+	Customer           string            `json:"customer"`
+	PaymentMethodTypes []string          `json:"payment_method_types,omitempty"` // Default: the types enabled in the Stripe dashboard
+	Usage              string            `json:"usage,omitempty"`                // off_session (default) or on_session
+	Description        string            `json:"description,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
 // APIError represents a Stripe API error
 type APIError struct {
 	StatusCode int
@@ -0,0 +1,117 @@
+package stripe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header Stripe signs webhook deliveries with
+const SignatureHeader = "Stripe-Signature"
+
+// DefaultWebhookTolerance is how far a delivery's signed timestamp may be
+// from our clock, matching Stripe's own libraries
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// ErrInvalidSignature is returned when a webhook delivery was not signed with
+// the endpoint's secret, or its timestamp is outside the tolerance
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Event is a webhook event. Data.Object holds the object the event is
+// about, such as a subscription or invoice.
+type Event struct {
+	ID         string    `json:"id"`
+	Object     string    `json:"object"`
+	Type       string    `json:"type"`
+	Created    int64     `json:"created"`
+	Livemode   bool      `json:"livemode"`
+	APIVersion string    `json:"api_version,omitempty"`
+	Data       EventData `json:"data"`
+}
+
+// EventData carries the object an event is about
+type EventData struct {
+	Object json.RawMessage `json:"object"`
+}
+
+// ObjectID returns the ID of the event's object
+func (e *Event) ObjectID() (string, error) {
+	var object struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(e.Data.Object, &object); err != nil {
+		return "", fmt.Errorf("failed to decode %s object: %w", e.Type, err)
+	}
+	if object.ID == "" {
+		return "", fmt.Errorf("%s event %s has no object ID", e.Type, e.ID)
+	}
+	return object.ID, nil
+}
+
+// VerifySignature checks a Stripe-Signature header against the raw request
+// body. The header carries a timestamp (t=) and one or more HMAC-SHA256
+// signatures (v1=) of "<timestamp>.<body>"; any v1 signature made with
+// secret is accepted if the timestamp is within tolerance of now.
+func VerifySignature(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	var timestamp string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return fmt.Errorf("%w: header has no timestamp or v1 signature", ErrInvalidSignature)
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(signedAt, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside the %s tolerance", ErrInvalidSignature, tolerance)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no signature matches the endpoint secret", ErrInvalidSignature)
+}
+
+// ConstructEvent verifies a webhook delivery and decodes its event
+func ConstructEvent(payload []byte, header, secret string, tolerance time.Duration) (*Event, error) {
+	if err := VerifySignature(payload, header, secret, tolerance, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode event: %w", err)
+	}
+	if event.ID == "" || event.Type == "" {
+		return nil, fmt.Errorf("event is missing its id or type")
+	}
+	return &event, nil
+}
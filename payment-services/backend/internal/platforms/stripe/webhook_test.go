@@ -0,0 +1,151 @@
+package stripe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "whsec_test_secret"
+
+// sign returns the v1 signature Stripe would send for payload at timestamp
+func sign(payload []byte, timestamp int64, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","object":"event","type":"invoice.paid"}`)
+	now := time.Unix(1_700_000_000, 0)
+	ts := now.Unix()
+	good := sign(payload, ts, testWebhookSecret)
+	tolerance := DefaultWebhookTolerance
+
+	tests := []struct {
+		name    string
+		header  string
+		payload []byte
+		wantErr string // empty when the signature is accepted
+	}{
+		{name: "valid", header: "t=" + strconv.FormatInt(ts, 10) + ",v1=" + good},
+		{name: "spaces around parts", header: "t=" + strconv.FormatInt(ts, 10) + ", v1=" + good},
+		{name: "v0 signature ignored", header: "t=" + strconv.FormatInt(ts, 10) + ",v0=deadbeef,v1=" + good},
+		{
+			name:   "matching signature after a stale one",
+			header: "t=" + strconv.FormatInt(ts, 10) + ",v1=" + sign(payload, ts, "whsec_old") + ",v1=" + good,
+		},
+		{
+			name:   "matching signature before another",
+			header: "t=" + strconv.FormatInt(ts, 10) + ",v1=" + good + ",v1=" + sign(payload, ts, "whsec_other"),
+		},
+		{
+			name:   "bad hex skipped when another v1 matches",
+			header: "t=" + strconv.FormatInt(ts, 10) + ",v1=zz-not-hex,v1=" + good,
+		},
+		{
+			name:   "at the edge of the tolerance",
+			header: "t=" + strconv.FormatInt(ts-int64(tolerance/time.Second), 10) + ",v1=" + sign(payload, ts-int64(tolerance/time.Second), testWebhookSecret),
+		},
+
+		{
+			name:    "no v1 matches",
+			header:  "t=" + strconv.FormatInt(ts, 10) + ",v1=" + sign(payload, ts, "whsec_old") + ",v1=" + sign(payload, ts, "whsec_other"),
+			wantErr: "no signature matches",
+		},
+		{name: "bad hex only", header: "t=" + strconv.FormatInt(ts, 10) + ",v1=zz-not-hex", wantErr: "no timestamp or v1 signature"},
+		{name: "missing t", header: "v1=" + good, wantErr: "no timestamp or v1 signature"},
+		{name: "missing v1", header: "t=" + strconv.FormatInt(ts, 10), wantErr: "no timestamp or v1 signature"},
+		{name: "empty header", header: "", wantErr: "no timestamp or v1 signature"},
+		{name: "malformed timestamp", header: "t=yesterday,v1=" + good, wantErr: "malformed timestamp"},
+		{
+			name:    "stale timestamp",
+			header:  "t=" + strconv.FormatInt(ts-301, 10) + ",v1=" + sign(payload, ts-301, testWebhookSecret),
+			wantErr: "outside the",
+		},
+		{
+			name:    "future timestamp",
+			header:  "t=" + strconv.FormatInt(ts+301, 10) + ",v1=" + sign(payload, ts+301, testWebhookSecret),
+			wantErr: "outside the",
+		},
+		{
+			name:    "signature for another timestamp",
+			header:  "t=" + strconv.FormatInt(ts+1, 10) + ",v1=" + good,
+			wantErr: "no signature matches",
+		},
+		{
+			name:    "tampered payload",
+			header:  "t=" + strconv.FormatInt(ts, 10) + ",v1=" + good,
+			payload: []byte(`{"id":"evt_1","object":"event","type":"invoice.voided"}`),
+			wantErr: "no signature matches",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := payload
+			if tt.payload != nil {
+				body = tt.payload
+			}
+			err := VerifySignature(body, tt.header, testWebhookSecret, tolerance, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("VerifySignature = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidSignature) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("VerifySignature = %v, want ErrInvalidSignature containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConstructEvent(t *testing.T) {
+	now := time.Now().Unix()
+	header := func(payload []byte, ts int64) string {
+		return "t=" + strconv.FormatInt(ts, 10) + ",v1=" + sign(payload, ts, testWebhookSecret)
+	}
+
+	tests := []struct {
+		name          string
+		payload       string
+		timestamp     int64
+		wantSignature bool // error wraps ErrInvalidSignature
+		wantErr       string
+	}{
+		{name: "valid event", payload: `{"id":"evt_1","type":"invoice.paid","livemode":true,"data":{"object":{"id":"in_1"}}}`, timestamp: now},
+		{name: "stale delivery", payload: `{"id":"evt_1","type":"invoice.paid"}`, timestamp: now - 3600, wantSignature: true, wantErr: "outside the"},
+		{name: "future delivery", payload: `{"id":"evt_1","type":"invoice.paid"}`, timestamp: now + 3600, wantSignature: true, wantErr: "outside the"},
+		{name: "signed but not JSON", payload: `not json`, timestamp: now, wantErr: "failed to decode event"},
+		{name: "missing type", payload: `{"id":"evt_1"}`, timestamp: now, wantErr: "missing its id or type"},
+		{name: "missing id", payload: `{"type":"invoice.paid"}`, timestamp: now, wantErr: "missing its id or type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(tt.payload)
+			event, err := ConstructEvent(payload, header(payload, tt.timestamp), testWebhookSecret, DefaultWebhookTolerance)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ConstructEvent = %v", err)
+				}
+				objectID, err := event.ObjectID()
+				if event.ID != "evt_1" || !event.Livemode || err != nil || objectID != "in_1" {
+					t.Errorf("event = %+v, object %q, %v", event, objectID, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ConstructEvent = %v, want error containing %q", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrInvalidSignature) != tt.wantSignature {
+				t.Errorf("errors.Is(%v, ErrInvalidSignature) = %v, want %v", err, !tt.wantSignature, tt.wantSignature)
+			}
+		})
+	}
+}
@@ -12,17 +12,26 @@ import (
 	"time"
 )
 
+// DefaultPageSize is the page size used when a list call does not give one
+const DefaultPageSize = 50
+
 // Client is the Zuora API client
 type Client struct {
 	baseURL      string
 	clientID     string
 	clientSecret string
 	httpClient   *http.Client
+	observe      func(status int) // see WithResponseObserver
+
+	// Token management, shared with copies of the client
+	token *tokenCache
+}
 
-	// Token management
+// tokenCache holds the OAuth access token and when it expires
+type tokenCache struct {
+	mu          sync.RWMutex
 	accessToken string
-	tokenExpiry time.Time
-	tokenMutex  sync.RWMutex
+	expiry      time.Time
 }
 
 // NewClient creates a new Zuora API client
@@ -33,26 +42,35 @@ func NewClient(baseURL, clientID, clientSecret string) *Client {
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		token:        &tokenCache{},
 	}
 }
 
+// WithResponseObserver returns a copy of the client that calls observe with
+// the status of every response it receives from the Zuora API; token requests are not reported
+func (c *Client) WithResponseObserver(observe func(status int)) *Client {
+	clone := *c
+	clone.observe = observe
+	return &clone
+}
+
 // getAccessToken returns a valid access token, refreshing if necessary
 func (c *Client) getAccessToken() (string, error) {
-	c.tokenMutex.RLock()
-	if c.accessToken != "" && time.Now().Before(c.tokenExpiry) {
-		token := c.accessToken
-		c.tokenMutex.RUnlock()
+	c.token.mu.RLock()
+	if c.token.accessToken != "" && time.Now().Before(c.token.expiry) {
+		token := c.token.accessToken
+		c.token.mu.RUnlock()
 		return token, nil
 	}
-	c.tokenMutex.RUnlock()
+	c.token.mu.RUnlock()
 
 	// Need to refresh token
-	c.tokenMutex.Lock()
-	defer c.tokenMutex.Unlock()
+	c.token.mu.Lock()
+	defer c.token.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if c.accessToken != "" && time.Now().Before(c.tokenExpiry) {
-		return c.accessToken, nil
+	if c.token.accessToken != "" && time.Now().Before(c.token.expiry) {
+		return c.token.accessToken, nil
 	}
 
 	// Request new token
@@ -83,11 +101,11 @@ func (c *Client) getAccessToken() (string, error) {
 		return "", fmt.Errorf("failed to decode token response: %w", err)
 	}
 
-	c.accessToken = tokenResp.AccessToken
+	c.token.accessToken = tokenResp.AccessToken
 	// Set expiry with a 60-second buffer
-	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second)
+	c.token.expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second)
 
-	return c.accessToken, nil
+	return c.token.accessToken, nil
 }
 
 // doRequest performs an HTTP request to the Zuora API
@@ -115,7 +133,20 @@ func (c *Client) doRequest(method, path string, body interface{}) (*http.Respons
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	return c.httpClient.Do(req)
+	resp, err := c.httpClient.Do(req)
+	if err == nil && c.observe != nil {
+		c.observe(resp.StatusCode)
+	}
+	return resp, err
+}
+
+// ResetToken discards the cached access token so the next request
+// authenticates again
+func (c *Client) ResetToken() {
+	c.token.mu.Lock()
+	c.token.accessToken = ""
+	c.token.expiry = time.Time{}
+	c.token.mu.Unlock()
 }
 
 // TestConnection tests the API connection
@@ -145,23 +176,21 @@ func (c *Client) TestConnection() error {
 	return nil
 }
 
-// ListAccounts returns a list of accounts using ZOQL query
-func (c *Client) ListAccounts(page, pageSize int) ([]Account, error) {
-	if pageSize <= 0 {
-		pageSize = 50
-	}
-	if page <= 0 {
-		page = 1
-	}
-
-	// Use ZOQL to query accounts (ZOQL doesn't support ORDER BY or LIMIT)
-	query := "SELECT Id, Name, AccountNumber, Status, Currency, Balance, CreatedDate FROM Account"
-
+// runQuery executes a ZOQL query, or fetches the next batch of an earlier
+// query when queryLocator is set
+func (c *Client) runQuery(queryString, queryLocator string) (*ZOQLQueryResponse, error) {
+	path := "/v1/action/query"
 	queryReq := map[string]string{
-		"queryString": query,
+		"queryString": queryString,
+	}
+	if queryLocator != "" {
+		path = "/v1/action/queryMore"
+		queryReq = map[string]string{
+			"queryLocator": queryLocator,
+		}
 	}
 
-	resp, err := c.doRequest("POST", "/v1/action/query", queryReq)
+	resp, err := c.doRequest("POST", path, queryReq)
 	if err != nil {
 		return nil, err
 	}
@@ -177,9 +206,47 @@ func (c *Client) ListAccounts(page, pageSize int) ([]Account, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if !result.Done {
-		// For simplicity, we're not handling pagination here
-		// In production, you'd follow the queryLocator for more results
+	return &result, nil
+}
+
+// updatedSinceClause builds a ZOQL WHERE clause on UpdatedDate, or "" when updatedSince is nil
+func updatedSinceClause(updatedSince *time.Time) string {
+	if updatedSince == nil {
+		return ""
+	}
+	return fmt.Sprintf(" WHERE UpdatedDate > '%s'", updatedSince.UTC().Format("2006-01-02T15:04:05-07:00"))
+}
+
+// nextLocator returns the queryLocator for the next batch, or "" when the query is done
+func nextLocator(result *ZOQLQueryResponse) string {
+	if result.Done {
+		return ""
+	}
+	return result.QueryLocator
+}
+
+// ListAccounts returns a list of accounts using ZOQL query
+func (c *Client) ListAccounts(page, pageSize int) ([]Account, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	accounts, _, err := c.QueryAccounts("", nil)
+	return accounts, err
+}
+
+// QueryAccounts returns one batch of accounts and the queryLocator for the
+// next batch. When updatedSince is set only accounts changed after it are returned.
+func (c *Client) QueryAccounts(queryLocator string, updatedSince *time.Time) ([]Account, string, error) {
+	// Use ZOQL to query accounts (ZOQL doesn't support ORDER BY or LIMIT)
+	query := "SELECT Id, Name, AccountNumber, Status, Currency, Balance, CreatedDate FROM Account" + updatedSinceClause(updatedSince)
+
+	result, err := c.runQuery(query, queryLocator)
+	if err != nil {
+		return nil, "", err
 	}
 
 	// Convert ZOQL records to Account structs
@@ -203,7 +270,7 @@ func (c *Client) ListAccounts(page, pageSize int) ([]Account, error) {
 		accounts = append(accounts, account)
 	}
 
-	return accounts, nil
+	return accounts, nextLocator(result), nil
 }
 
 // Helper to safely get string from map
@@ -268,33 +335,25 @@ func (c *Client) CreateAccount(input CreateAccountRequest) (*Account, error) {
 // ListSubscriptions returns a list of subscriptions using ZOQL query
 func (c *Client) ListSubscriptions(page, pageSize int) ([]Subscription, error) {
 	if pageSize <= 0 {
-		pageSize = 50
+		pageSize = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
 	}
 
-	// Use ZOQL to query subscriptions (ZOQL doesn't support ORDER BY or LIMIT)
-	query := "SELECT Id, Name, AccountId, Status, ContractEffectiveDate, TermStartDate, TermEndDate, CreatedDate FROM Subscription"
+	subscriptions, _, err := c.QuerySubscriptions("", nil)
+	return subscriptions, err
+}
 
-	queryReq := map[string]string{
-		"queryString": query,
-	}
+// QuerySubscriptions returns one batch of subscriptions and the queryLocator for
+// the next batch. When updatedSince is set only subscriptions changed after it are returned.
+func (c *Client) QuerySubscriptions(queryLocator string, updatedSince *time.Time) ([]Subscription, string, error) {
+	// Use ZOQL to query subscriptions (ZOQL doesn't support ORDER BY or LIMIT)
+	query := "SELECT Id, Name, AccountId, Status, ContractEffectiveDate, TermStartDate, TermEndDate, CreatedDate FROM Subscription" + updatedSinceClause(updatedSince)
 
-	resp, err := c.doRequest("POST", "/v1/action/query", queryReq)
+	result, err := c.runQuery(query, queryLocator)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, NewAPIError(resp.StatusCode, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(body)))
-	}
-
-	var result ZOQLQueryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, "", err
 	}
 
 	// Convert ZOQL records to Subscription structs
@@ -317,7 +376,7 @@ func (c *Client) ListSubscriptions(page, pageSize int) ([]Subscription, error) {
 		subscriptions = append(subscriptions, sub)
 	}
 
-	return subscriptions, nil
+	return subscriptions, nextLocator(result), nil
 }
 
 // GetSubscription returns a single subscription by key
@@ -349,33 +408,24 @@ func (c *Client) GetSubscription(subscriptionKey string) (*Subscription, error)
 // ListProducts returns a list of products using ZOQL query
 func (c *Client) ListProducts(page, pageSize int) ([]Product, error) {
 	if pageSize <= 0 {
-		pageSize = 50
+		pageSize = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
 	}
 
+	products, _, err := c.QueryProducts("")
+	return products, err
+}
+
+// QueryProducts returns one batch of products and the queryLocator for the next batch
+func (c *Client) QueryProducts(queryLocator string) ([]Product, string, error) {
 	// Use ZOQL to query products
 	query := "SELECT Id, Name, SKU, Description, Category, EffectiveStartDate, EffectiveEndDate, CreatedDate FROM Product"
 
-	queryReq := map[string]string{
-		"queryString": query,
-	}
-
-	resp, err := c.doRequest("POST", "/v1/action/query", queryReq)
+	result, err := c.runQuery(query, queryLocator)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, NewAPIError(resp.StatusCode, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(body)))
-	}
-
-	var result ZOQLQueryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, "", err
 	}
 
 	// Convert ZOQL records to Product structs
@@ -398,7 +448,7 @@ func (c *Client) ListProducts(page, pageSize int) ([]Product, error) {
 		products = append(products, product)
 	}
 
-	return products, nil
+	return products, nextLocator(result), nil
 }
 
 // GetProduct returns a single product by key
@@ -456,33 +506,24 @@ func (c *Client) ListProductRatePlans(productKey string) ([]ProductRatePlan, err
 // ListInvoices returns a list of invoices using ZOQL query
 func (c *Client) ListInvoices(page, pageSize int) ([]Invoice, error) {
 	if pageSize <= 0 {
-		pageSize = 50
+		pageSize = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
 	}
 
+	invoices, _, err := c.QueryInvoices("")
+	return invoices, err
+}
+
+// QueryInvoices returns one batch of invoices and the queryLocator for the next batch
+func (c *Client) QueryInvoices(queryLocator string) ([]Invoice, string, error) {
 	// Use ZOQL to query invoices
 	query := "SELECT Id, InvoiceNumber, AccountId, InvoiceDate, DueDate, Status, Amount, Balance, CreatedDate FROM Invoice"
 
-	queryReq := map[string]string{
-		"queryString": query,
-	}
-
-	resp, err := c.doRequest("POST", "/v1/action/query", queryReq)
+	result, err := c.runQuery(query, queryLocator)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, NewAPIError(resp.StatusCode, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(body)))
-	}
-
-	var result ZOQLQueryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, "", err
 	}
 
 	// Convert ZOQL records to Invoice structs
@@ -510,7 +551,7 @@ func (c *Client) ListInvoices(page, pageSize int) ([]Invoice, error) {
 		invoices = append(invoices, invoice)
 	}
 
-	return invoices, nil
+	return invoices, nextLocator(result), nil
 }
 
 // GetInvoice returns a single invoice by ID
@@ -542,33 +583,24 @@ func (c *Client) GetInvoice(invoiceID string) (*Invoice, error) {
 // ListPayments returns a list of payments using ZOQL query
 func (c *Client) ListPayments(page, pageSize int) ([]Payment, error) {
 	if pageSize <= 0 {
-		pageSize = 50
+		pageSize = DefaultPageSize
 	}
 	if page <= 0 {
 		page = 1
 	}
 
+	payments, _, err := c.QueryPayments("")
+	return payments, err
+}
+
+// QueryPayments returns one batch of payments and the queryLocator for the next batch
+func (c *Client) QueryPayments(queryLocator string) ([]Payment, string, error) {
 	// Use ZOQL to query payments
 	query := "SELECT Id, PaymentNumber, AccountId, Amount, EffectiveDate, Status, Type, CreatedDate FROM Payment"
 
-	queryReq := map[string]string{
-		"queryString": query,
-	}
-
-	resp, err := c.doRequest("POST", "/v1/action/query", queryReq)
+	result, err := c.runQuery(query, queryLocator)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, NewAPIError(resp.StatusCode, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(body)))
-	}
-
-	var result ZOQLQueryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, "", err
 	}
 
 	// Convert ZOQL records to Payment structs
@@ -593,5 +625,5 @@ func (c *Client) ListPayments(page, pageSize int) ([]Payment, error) {
 		payments = append(payments, payment)
 	}
 
-	return payments, nil
+	return payments, nextLocator(result), nil
 }
@@ -0,0 +1,51 @@
+package zuora
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResponseObserverSkipsTokenRequests(t *testing.T) {
+	var tokenRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth/token":
+			tokenRequests.Add(1)
+			json.NewEncoder(w).Encode(TokenResponse{AccessToken: "tok", ExpiresIn: 3600})
+		case "/v1/accounts/A-1":
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "basicInfo": map[string]string{"id": "a1"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	base := NewClient(server.URL, "id", "secret")
+	var statuses []int
+	observed := base.WithResponseObserver(func(status int) { statuses = append(statuses, status) })
+
+	if _, err := observed.GetAccount("A-1"); err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if _, err := observed.GetAccount("A-2"); err == nil {
+		t.Fatal("GetAccount of a missing account succeeded")
+	}
+	if len(statuses) != 2 || statuses[0] != http.StatusOK || statuses[1] != http.StatusNotFound {
+		t.Errorf("observed %v, want [200 404] without the token request", statuses)
+	}
+
+	// The copy shares the original's token rather than fetching its own
+	if _, err := base.GetAccount("A-1"); err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if n := tokenRequests.Load(); n != 1 {
+		t.Errorf("%d token requests, want 1 shared by both clients", n)
+	}
+	if len(statuses) != 2 {
+		t.Errorf("the original client reported to the copy's observer: %v", statuses)
+	}
+}
@@ -0,0 +1,55 @@
+package zuora
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// sandboxHostLabels mark Zuora REST hosts that serve non-production tenants:
+// API Sandbox (rest.apisandbox.zuora.com, rest.sandbox.na.zuora.com, ...),
+// Central Sandbox (rest.test.zuora.com, rest.test.eu.zuora.com, ...) and
+// Performance Test (rest.pt1.zuora.com)
+var sandboxHostLabels = map[string]bool{
+	"apisandbox": true,
+	"sandbox":    true,
+	"test":       true,
+	"pt1":        true,
+}
+
+// InferSandbox tells from a REST base URL whether it is a sandbox data
+// center. known is false for hosts outside zuora.com, such as proxies.
+func InferSandbox(baseURL string) (sandbox, known bool, err error) {
+	if baseURL == "" {
+		return false, false, nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return false, false, fmt.Errorf("invalid Zuora base URL %q", baseURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host != "zuora.com" && !strings.HasSuffix(host, ".zuora.com") {
+		return false, false, nil
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(host, ".zuora.com"), ".") {
+		if sandboxHostLabels[label] {
+			return true, true, nil
+		}
+	}
+	return false, true, nil
+}
+
+var _ platforms.EnvironmentDetector = (*Provider)(nil)
+
+// DetectEnvironment reports the environment implied by the client's data
+// center, or nil for a host it does not recognize
+func (p *Provider) DetectEnvironment() (*platforms.EnvironmentReport, error) {
+	sandbox, known, err := InferSandbox(p.client.baseURL)
+	if err != nil || !known {
+		return nil, err
+	}
+	return &platforms.EnvironmentReport{IsSandbox: sandbox, Source: "base_url"}, nil
+}
@@ -0,0 +1,88 @@
+package zuora
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+func TestInferSandbox(t *testing.T) {
+	tests := []struct {
+		baseURL     string
+		wantSandbox bool
+		wantKnown   bool
+		wantErr     bool
+	}{
+		// Production data centers
+		{"https://rest.zuora.com", false, true, false},
+		{"https://rest.na.zuora.com", false, true, false},
+		{"https://rest.eu.zuora.com/", false, true, false},
+		{"https://rest.ap.zuora.com", false, true, false},
+		{"https://zuora.com", false, true, false},
+
+		// Sandboxes
+		{"https://rest.apisandbox.zuora.com", true, true, false},
+		{"https://rest.sandbox.na.zuora.com", true, true, false},
+		{"https://rest.sandbox.eu.zuora.com", true, true, false},
+		{"https://rest.test.zuora.com", true, true, false},
+		{"https://rest.test.eu.zuora.com", true, true, false},
+		{"https://rest.pt1.zuora.com", true, true, false},
+		{"https://REST.APISANDBOX.ZUORA.COM", true, true, false},
+		{"https://rest.apisandbox.zuora.com:443/v1", true, true, false},
+
+		// Hosts that only look like Zuora say nothing
+		{"https://zuora.com.attacker.example", false, false, false},
+		{"https://sandbox.notzuora.com", false, false, false},
+		{"https://billing-proxy.internal", false, false, false},
+		{"", false, false, false},
+
+		{"rest.zuora.com", false, false, true},
+		{"https://%zz", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			sandbox, known, err := InferSandbox(tt.baseURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InferSandbox(%q) error = %v, wantErr %v", tt.baseURL, err, tt.wantErr)
+			}
+			if sandbox != tt.wantSandbox || known != tt.wantKnown {
+				t.Errorf("InferSandbox(%q) = sandbox %v, known %v; want %v, %v",
+					tt.baseURL, sandbox, known, tt.wantSandbox, tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestCheckSandboxAgainstBaseURL(t *testing.T) {
+	def, ok := platforms.Lookup(models.PlatformZuora)
+	if !ok {
+		t.Fatal("zuora is not registered")
+	}
+
+	tests := []struct {
+		baseURL   string
+		isSandbox bool
+		wantErr   string
+	}{
+		{"https://rest.apisandbox.zuora.com", true, ""},
+		{"https://rest.na.zuora.com", false, ""},
+		{"https://rest.na.zuora.com", true, "credentials are for a live environment"},
+		{"https://rest.test.eu.zuora.com", false, "credentials are for a sandbox/test environment"},
+		{"https://billing-proxy.internal", true, ""},
+		{"https://billing-proxy.internal", false, ""},
+	}
+	for _, tt := range tests {
+		err := def.CheckSandbox(platforms.ConnectionConfig{BaseURL: tt.baseURL, IsSandbox: tt.isSandbox})
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("CheckSandbox(%q, sandbox=%v) = %v, want nil", tt.baseURL, tt.isSandbox, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CheckSandbox(%q, sandbox=%v) = %v, want error containing %q", tt.baseURL, tt.isSandbox, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package zuora
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// Provider adapts the Zuora client to the platforms.BillingProvider interface
+type Provider struct {
+	client *Client
+}
+
+var (
+	_ platforms.BillingProvider    = (*Provider)(nil)
+	_ platforms.ResponseObservable = (*Provider)(nil)
+)
+
+// NewProvider wraps a Zuora client as a BillingProvider
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+// Client returns the underlying Zuora client for platform-specific calls
+func (p *Provider) Client() *Client {
+	return p.client
+}
+
+// WithResponseObserver returns a provider whose calls report the status of
+// every Zuora response to observe
+func (p *Provider) WithResponseObserver(observe func(status int)) platforms.BillingProvider {
+	return NewProvider(p.client.WithResponseObserver(observe))
+}
+
+// Platform returns the platform type
+func (p *Provider) Platform() models.PlatformType {
+	return models.PlatformZuora
+}
+
+// TestConnection tests the API connection
+func (p *Provider) TestConnection() error {
+	return p.client.TestConnection()
+}
+
+var _ platforms.CredentialResetter = (*Provider)(nil)
+
+// ResetCredentials drops the client's OAuth token
+func (p *Provider) ResetCredentials() {
+	p.client.ResetToken()
+}
+
+// ListCustomers returns a batch of accounts as normalized customers. The
+// cursor is the ZOQL queryLocator, so Limit is decided by Zuora.
+func (p *Provider) ListCustomers(params platforms.ListParams) ([]models.Customer, string, error) {
+	accounts, next, err := p.client.QueryAccounts(params.Cursor, params.UpdatedSince)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Customer, len(accounts))
+	for i, account := range accounts {
+		result[i] = accountToModel(account)
+	}
+	return result, next, nil
+}
+
+// GetCustomer returns a single account as a normalized customer
+func (p *Provider) GetCustomer(id string) (*models.Customer, error) {
+	account, err := p.client.GetAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	result := accountToModel(*account)
+	return &result, nil
+}
+
+// CreateCustomer creates an account from the platform-agnostic request
+func (p *Provider) CreateCustomer(input models.CreateCustomerRequest) (*models.Customer, error) {
+	name := input.Organization
+	if name == "" {
+		name = strings.TrimSpace(input.FirstName + " " + input.LastName)
+	}
+	contact := &Contact{
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		WorkEmail: input.Email,
+	}
+
+	account, err := p.client.CreateAccount(CreateAccountRequest{
+		Name:          name,
+		Currency:      "USD",
+		BillToContact: contact,
+		SoldToContact: contact,
+		Notes:         input.Reference,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := accountToModel(*account)
+	return &result, nil
+}
+
+// ListSubscriptions returns a batch of normalized subscriptions, paged by queryLocator
+func (p *Provider) ListSubscriptions(params platforms.ListParams) ([]models.Subscription, string, error) {
+	subscriptions, next, err := p.client.QuerySubscriptions(params.Cursor, params.UpdatedSince)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Subscription, len(subscriptions))
+	for i, sub := range subscriptions {
+		result[i] = subscriptionToModel(sub)
+	}
+	return result, next, nil
+}
+
+// GetSubscription returns a single normalized subscription
+func (p *Provider) GetSubscription(id string) (*models.Subscription, error) {
+	subscription, err := p.client.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	result := subscriptionToModel(*subscription)
+	return &result, nil
+}
+
+// ListInvoices returns a batch of normalized invoices, paged by queryLocator
+func (p *Provider) ListInvoices(params platforms.ListParams) ([]models.Invoice, string, error) {
+	invoices, next, err := p.client.QueryInvoices(params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Invoice, len(invoices))
+	for i, invoice := range invoices {
+		result[i] = invoiceToModel(invoice)
+	}
+	return result, next, nil
+}
+
+// ListPayments returns a batch of normalized payments, paged by queryLocator
+func (p *Provider) ListPayments(params platforms.ListParams) ([]models.Payment, string, error) {
+	payments, next, err := p.client.QueryPayments(params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Payment, len(payments))
+	for i, payment := range payments {
+		result[i] = paymentToModel(payment)
+	}
+	return result, next, nil
+}
+
+// ListProducts returns a batch of normalized products, paged by queryLocator
+func (p *Provider) ListProducts(params platforms.ListParams) ([]models.Product, string, error) {
+	products, next, err := p.client.QueryProducts(params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.Product, len(products))
+	for i, product := range products {
+		result[i] = productToModel(product)
+	}
+	return result, next, nil
+}
+
+func accountToModel(account Account) models.Customer {
+	result := models.Customer{
+		ID:           account.ID,
+		Reference:    account.AccountNumber,
+		Organization: account.Name,
+		CreatedAt:    account.CreatedDate,
+		RawData:      platforms.RawData(account),
+	}
+	if account.BillToContact != nil {
+		result.FirstName = account.BillToContact.FirstName
+		result.LastName = account.BillToContact.LastName
+		result.Email = account.BillToContact.WorkEmail
+	}
+	return result
+}
+
+func subscriptionToModel(sub Subscription) models.Subscription {
+	result := models.Subscription{
+		ID:                 sub.ID,
+		CustomerID:         sub.AccountID,
+		State:              sub.Status,
+		CurrentPeriodStart: platforms.ParseDate(sub.TermStartDate),
+		CurrentPeriodEnd:   platforms.ParseDate(sub.TermEndDate),
+		CreatedAt:          sub.CreatedDate,
+		RawData:            platforms.RawData(sub),
+	}
+	if len(sub.RatePlans) > 0 {
+		result.ProductName = sub.RatePlans[0].ProductName
+	}
+	return result
+}
+
+func invoiceToModel(invoice Invoice) models.Invoice {
+	return models.Invoice{
+		ID:         invoice.ID,
+		Number:     invoice.InvoiceNumber,
+		CustomerID: invoice.AccountID,
+		Status:     invoice.Status,
+		Total:      strconv.FormatFloat(invoice.Amount, 'f', 2, 64),
+		Currency:   invoice.Currency,
+		DueDate:    platforms.ParseDate(invoice.DueDate),
+		CreatedAt:  invoice.CreatedDate,
+		RawData:    platforms.RawData(invoice),
+	}
+}
+
+func paymentToModel(payment Payment) models.Payment {
+	return models.Payment{
+		ID:        payment.ID,
+		Amount:    strconv.FormatFloat(payment.Amount, 'f', 2, 64),
+		Status:    payment.Status,
+		CreatedAt: payment.CreatedDate,
+		RawData:   platforms.RawData(payment),
+	}
+}
+
+func productToModel(product Product) models.Product {
+	return models.Product{
+		ID:          product.ID,
+		Name:        product.Name,
+		Handle:      product.SKU,
+		Description: product.Description,
+		CreatedAt:   product.CreatedDate,
+		RawData:     platforms.RawData(product),
+	}
+}
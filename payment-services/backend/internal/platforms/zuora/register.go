@@ -0,0 +1,45 @@
+package zuora
+
+import (
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// Default REST endpoints (NA data center) used when a connection has no base_url
+const (
+	DefaultSandboxBaseURL    = "https://rest.sandbox.na.zuora.com"
+	DefaultProductionBaseURL = "https://rest.na.zuora.com"
+)
+
+func init() {
+	platforms.Register(platforms.Definition{
+		Type:        models.PlatformZuora,
+		DisplayName: "Zuora",
+		Credentials: []platforms.CredentialField{
+			{Type: "client_id", Label: "Client ID", Required: true},
+			{Type: "client_secret", Label: "Client Secret", Required: true},
+		},
+		TreeContainers: []platforms.TreeContainer{
+			{Type: "customers", Name: "Customers"},
+			{Type: "subscriptions", Name: "Subscriptions"},
+			{Type: "product-families", Name: "Product Families"},
+			{Type: "invoices", Name: "Invoices"},
+			{Type: "payments", Name: "Payments"},
+		},
+		NewProvider: func(cfg platforms.ConnectionConfig) (platforms.BillingProvider, error) {
+			// Fall back to default URLs if base_url is not set
+			baseURL := cfg.BaseURL
+			if baseURL == "" {
+				if cfg.IsSandbox {
+					baseURL = DefaultSandboxBaseURL
+				} else {
+					baseURL = DefaultProductionBaseURL
+				}
+			}
+			return NewProvider(NewClient(baseURL, cfg.Credentials["client_id"], cfg.Credentials["client_secret"])), nil
+		},
+		InferSandbox: func(cfg platforms.ConnectionConfig) (bool, bool, error) {
+			return InferSandbox(cfg.BaseURL)
+		},
+	})
+}
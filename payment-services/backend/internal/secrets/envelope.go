@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Sealed is an encrypted credential as stored in platform_credentials
+type Sealed struct {
+	Value   string // base64 nonce+ciphertext of the credential
+	DataKey string // base64 nonce+ciphertext of the data key, wrapped by the KEK
+	KeyID   string // ID of the KEK that wrapped DataKey
+}
+
+// Seal encrypts plaintext under a new data key wrapped by the current KEK.
+// The associated data binds the ciphertext to its row, so a value copied onto
+// a different connection or credential type will not decrypt.
+func (k *Keyring) Seal(plaintext string, associatedData []byte) (Sealed, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Sealed{}, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	value, err := encrypt(dataKey, []byte(plaintext), associatedData)
+	if err != nil {
+		return Sealed{}, err
+	}
+	wrapped, err := encrypt(k.keys[k.current], dataKey, []byte(k.current))
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	return Sealed{
+		Value:   base64.StdEncoding.EncodeToString(value),
+		DataKey: base64.StdEncoding.EncodeToString(wrapped),
+		KeyID:   k.current,
+	}, nil
+}
+
+// Open decrypts a value produced by Seal
+func (k *Keyring) Open(sealed Sealed, associatedData []byte) (string, error) {
+	kek, ok := k.keys[sealed.KeyID]
+	if !ok {
+		return "", fmt.Errorf("credential was encrypted with unknown key %q", sealed.KeyID)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(sealed.DataKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid data key encoding: %w", err)
+	}
+	dataKey, err := decrypt(kek, wrapped, []byte(sealed.KeyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key with key %q: %w", sealed.KeyID, err)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(sealed.Value)
+	if err != nil {
+		return "", fmt.Errorf("invalid credential encoding: %w", err)
+	}
+	plaintext, err := decrypt(dataKey, value, associatedData)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt credential: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// encrypt seals plaintext with AES-GCM, prefixing the random nonce
+func encrypt(key, plaintext, associatedData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// decrypt opens a nonce-prefixed AES-GCM ciphertext
+func decrypt(key, ciphertext, associatedData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, body := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, body, associatedData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,154 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, current string, ids ...string) *Keyring {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for i, id := range ids {
+		keys[id] = bytes.Repeat([]byte{byte(i + 1)}, KeySize)
+	}
+	k, err := NewKeyring(current, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// flipByte returns base64 value with one byte of its decoded form changed
+func flipByte(t *testing.T, value string, i int) string {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[i%len(raw)] ^= 0x01
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	k := testKeyring(t, "k1", "k1")
+	aad := []byte("7/api_key")
+
+	for _, plaintext := range []string{"sk_live_abc123", "", strings.Repeat("x", 4096)} {
+		sealed, err := k.Seal(plaintext, aad)
+		if err != nil {
+			t.Fatalf("Seal: %v", err)
+		}
+		if sealed.KeyID != "k1" {
+			t.Errorf("sealed under %q, want k1", sealed.KeyID)
+		}
+		if plaintext != "" && strings.Contains(sealed.Value, plaintext) {
+			t.Error("sealed value contains the plaintext")
+		}
+		got, err := k.Open(sealed, aad)
+		if err != nil || got != plaintext {
+			t.Errorf("Open = %q, %v, want the sealed plaintext", got, err)
+		}
+	}
+
+	// Each value gets its own data key and nonce
+	a, _ := k.Seal("same", aad)
+	b, _ := k.Seal("same", aad)
+	if a.Value == b.Value || a.DataKey == b.DataKey {
+		t.Error("sealing the same plaintext twice gave the same ciphertext or data key")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	k := testKeyring(t, "k1", "k1")
+	sealed, err := k.Seal("sk_live_abc123", []byte("7/api_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Open(sealed, nil); err == nil {
+		t.Error("Open without the associated data succeeded")
+	}
+
+	tests := []struct {
+		name    string
+		sealed  Sealed
+		aad     string // defaults to the associated data it was sealed with
+		wantErr string
+	}{
+		{name: "another connection", sealed: sealed, aad: "8/api_key", wantErr: "failed to decrypt credential"},
+		{name: "another credential type", sealed: sealed, aad: "7/webhook_secret", wantErr: "failed to decrypt credential"},
+		{
+			name:    "unknown key ID",
+			sealed:  Sealed{Value: sealed.Value, DataKey: sealed.DataKey, KeyID: "k9"},
+			wantErr: `unknown key "k9"`,
+		},
+		{
+			name:    "tampered data key",
+			sealed:  Sealed{Value: sealed.Value, DataKey: flipByte(t, sealed.DataKey, 20), KeyID: "k1"},
+			wantErr: "failed to unwrap data key",
+		},
+		{
+			name:    "tampered ciphertext",
+			sealed:  Sealed{Value: flipByte(t, sealed.Value, 20), DataKey: sealed.DataKey, KeyID: "k1"},
+			wantErr: "failed to decrypt credential",
+		},
+		{
+			name:    "tampered nonce",
+			sealed:  Sealed{Value: flipByte(t, sealed.Value, 0), DataKey: sealed.DataKey, KeyID: "k1"},
+			wantErr: "failed to decrypt credential",
+		},
+		{
+			name:    "truncated ciphertext",
+			sealed:  Sealed{Value: base64.StdEncoding.EncodeToString([]byte("short")), DataKey: sealed.DataKey, KeyID: "k1"},
+			wantErr: "ciphertext too short",
+		},
+		{
+			name:    "data key not base64",
+			sealed:  Sealed{Value: sealed.Value, DataKey: "%%%", KeyID: "k1"},
+			wantErr: "invalid data key encoding",
+		},
+		{
+			name:    "value not base64",
+			sealed:  Sealed{Value: "%%%", DataKey: sealed.DataKey, KeyID: "k1"},
+			wantErr: "invalid credential encoding",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aad := tt.aad
+			if aad == "" {
+				aad = "7/api_key"
+			}
+			got, err := k.Open(tt.sealed, []byte(aad))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Open = %q, %v, want error containing %q", got, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenWithRetiredKey(t *testing.T) {
+	old := testKeyring(t, "k1", "k1")
+	aad := []byte("7/api_key")
+	sealed, err := old.Seal("sk_live_abc123", aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same key bytes under the same ID, now retired in favour of k2
+	rotated := testKeyring(t, "k2", "k1", "k2")
+	if got, err := rotated.Open(sealed, aad); err != nil || got != "sk_live_abc123" {
+		t.Errorf("Open with the retired key = %q, %v", got, err)
+	}
+	resealed, err := rotated.Seal("sk_live_abc123", aad)
+	if err != nil || resealed.KeyID != "k2" {
+		t.Errorf("Seal after rotation used %q, %v, want k2", resealed.KeyID, err)
+	}
+
+	// A data key wrapped under one ID does not unwrap when relabelled as another
+	relabelled := Sealed{Value: sealed.Value, DataKey: sealed.DataKey, KeyID: "k2"}
+	if _, err := rotated.Open(relabelled, aad); err == nil {
+		t.Error("data key unwrapped under a different key ID")
+	}
+}
@@ -0,0 +1,172 @@
+// Package secrets encrypts platform credentials at rest.
+//
+// Credentials are sealed with envelope encryption: each value is encrypted
+// with a fresh AES-256-GCM data key, and the data key is itself encrypted
+// ("wrapped") with a key-encryption key (KEK) held in a Keyring. Only the
+// wrapped data key and the KEK's ID are stored next to the ciphertext, so a
+// database dump alone is not enough to recover any credential.
+package secrets
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// KeySize is the length in bytes of key-encryption keys and data keys (AES-256)
+const KeySize = 32
+
+// Environment variables read by LoadKeyringFromEnv
+const (
+	EnvKEK      = "CREDENTIAL_KEK"      // base64 key used as the current KEK
+	EnvKEKFile  = "CREDENTIAL_KEK_FILE" // file holding the keyring, one "id:base64key" per line
+	EnvKEKID    = "CREDENTIAL_KEK_ID"   // ID of the current KEK
+	EnvKEKOld   = "CREDENTIAL_KEK_OLD"  // comma-separated "id:base64key" entries still accepted for decryption
+	defaultKEID = "default"
+)
+
+// ErrNoKeyring is returned when an encrypted credential is read but no
+// key-encryption key has been configured
+var ErrNoKeyring = errors.New("credential is encrypted but no key-encryption key is configured")
+
+// Keyring holds the key-encryption keys. New values are always sealed with the
+// current key; any key in the ring can open values sealed under it.
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewKeyring creates a keyring whose current key is currentID
+func NewKeyring(currentID string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring has no keys")
+	}
+	for id, key := range keys {
+		if id == "" || strings.ContainsAny(id, ":, \t") {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+	}
+	if _, ok := keys[currentID]; !ok {
+		return nil, fmt.Errorf("current key %q is not in the keyring", currentID)
+	}
+	return &Keyring{current: currentID, keys: keys}, nil
+}
+
+// CurrentKeyID returns the ID of the key used for new values
+func (k *Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+// KeyIDs returns the IDs of every key in the ring, sorted
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// LoadKeyringFromEnv builds a keyring from the CREDENTIAL_KEK* environment
+// variables. The current key comes from CREDENTIAL_KEK or, when that is unset,
+// from CREDENTIAL_KEK_FILE. CREDENTIAL_KEK_ID names the current key; it
+// defaults to "default" for CREDENTIAL_KEK and may be omitted for a file that
+// holds a single key. Retired keys listed in CREDENTIAL_KEK_OLD (or in the
+// file) stay usable for decryption until a rotation has re-encrypted
+// everything under the current key.
+//
+// It returns nil with no error when no key is configured at all.
+func LoadKeyringFromEnv() (*Keyring, error) {
+	keys := make(map[string][]byte)
+	currentID := os.Getenv(EnvKEKID)
+
+	if old := os.Getenv(EnvKEKOld); old != "" {
+		if err := parseKeyEntries(strings.Split(old, ","), keys); err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvKEKOld, err)
+		}
+	}
+
+	switch {
+	case os.Getenv(EnvKEK) != "":
+		key, err := decodeKey(os.Getenv(EnvKEK))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvKEK, err)
+		}
+		if currentID == "" {
+			currentID = defaultKEID
+		}
+		keys[currentID] = key
+
+	case os.Getenv(EnvKEKFile) != "":
+		path := os.Getenv(EnvKEKFile)
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		fileKeys := make(map[string][]byte)
+		if err := parseKeyEntries(strings.Split(string(contents), "\n"), fileKeys); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if currentID == "" {
+			if len(fileKeys) != 1 {
+				return nil, fmt.Errorf("%s must be set when %s holds more than one key", EnvKEKID, path)
+			}
+			for id := range fileKeys {
+				currentID = id
+			}
+		}
+		for id, key := range fileKeys {
+			keys[id] = key
+		}
+
+	default:
+		if len(keys) > 0 || currentID != "" {
+			return nil, fmt.Errorf("%s or %s must be set", EnvKEK, EnvKEKFile)
+		}
+		return nil, nil
+	}
+
+	return NewKeyring(currentID, keys)
+}
+
+// parseKeyEntries adds "id:base64key" entries to keys, skipping blank lines and # comments
+func parseKeyEntries(entries []string, keys map[string][]byte) error {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return fmt.Errorf("key entry must be id:base64key")
+		}
+		id = strings.TrimSpace(id)
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", id, err)
+		}
+		if _, dup := keys[id]; dup {
+			return fmt.Errorf("key %q is listed twice", id)
+		}
+		keys[id] = key
+	}
+	return nil
+}
+
+// decodeKey decodes a standard base64 key of KeySize bytes
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("key is not valid base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadKeyringFromEnv(t *testing.T) {
+	key := func(b byte) string { return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, KeySize)) }
+	short := base64.StdEncoding.EncodeToString([]byte("too short"))
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		file    string // contents of CREDENTIAL_KEK_FILE, when set
+		nilRing bool
+		current string
+		ids     []string
+		wantErr string
+	}{
+		{name: "nothing configured", nilRing: true},
+		{name: "single key", env: map[string]string{EnvKEK: key(1)}, current: "default", ids: []string{"default"}},
+		{name: "named key", env: map[string]string{EnvKEK: key(1), EnvKEKID: "2026-01"}, current: "2026-01", ids: []string{"2026-01"}},
+		{
+			name:    "key with retired keys",
+			env:     map[string]string{EnvKEK: key(1), EnvKEKID: "k3", EnvKEKOld: "k1:" + key(2) + ", k2:" + key(3)},
+			current: "k3",
+			ids:     []string{"k1", "k2", "k3"},
+		},
+		{name: "file with one key", file: "k1:" + key(1) + "\n", current: "k1", ids: []string{"k1"}},
+		{
+			name:    "file with comments and several keys",
+			env:     map[string]string{EnvKEKID: "k2"},
+			file:    "# rotated 2026-01\nk1:" + key(1) + "\n\n  k2 : " + key(2) + "\n",
+			current: "k2",
+			ids:     []string{"k1", "k2"},
+		},
+		{
+			name:    "inline key takes precedence over the file",
+			env:     map[string]string{EnvKEK: key(1)},
+			file:    "k1:" + key(2) + "\n",
+			current: "default",
+			ids:     []string{"default"},
+		},
+
+		{name: "key not base64", env: map[string]string{EnvKEK: "not base64!"}, wantErr: "CREDENTIAL_KEK: key is not valid base64"},
+		{name: "key too short", env: map[string]string{EnvKEK: short}, wantErr: "key must be 32 bytes, got 9"},
+		{name: "invalid key ID", env: map[string]string{EnvKEK: key(1), EnvKEKID: "a:b"}, wantErr: `invalid key ID "a:b"`},
+		{name: "retired entry without an ID", env: map[string]string{EnvKEK: key(1), EnvKEKOld: key(2)}, wantErr: "CREDENTIAL_KEK_OLD: key entry must be id:base64key"},
+		{name: "retired entry not base64", env: map[string]string{EnvKEK: key(1), EnvKEKOld: "k1:???"}, wantErr: `CREDENTIAL_KEK_OLD: key "k1": key is not valid base64`},
+		{name: "retired key listed twice", env: map[string]string{EnvKEK: key(1), EnvKEKOld: "k1:" + key(2) + ",k1:" + key(3)}, wantErr: `key "k1" is listed twice`},
+		{name: "retired keys without a current key", env: map[string]string{EnvKEKOld: "k1:" + key(2)}, wantErr: "CREDENTIAL_KEK or CREDENTIAL_KEK_FILE must be set"},
+		{name: "key ID without a key", env: map[string]string{EnvKEKID: "k1"}, wantErr: "CREDENTIAL_KEK or CREDENTIAL_KEK_FILE must be set"},
+		{name: "file with several keys and no ID", file: "k1:" + key(1) + "\nk2:" + key(2) + "\n", wantErr: "CREDENTIAL_KEK_ID must be set"},
+		{name: "file names a missing current key", env: map[string]string{EnvKEKID: "k9"}, file: "k1:" + key(1) + "\n", wantErr: `current key "k9" is not in the keyring`},
+		{name: "file with a malformed line", file: "k1 " + key(1) + "\n", wantErr: "key entry must be id:base64key"},
+		{name: "file with a short key", file: "k1:" + short + "\n", wantErr: `key "k1": key must be 32 bytes`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{EnvKEK, EnvKEKFile, EnvKEKID, EnvKEKOld} {
+				t.Setenv(name, tt.env[name])
+			}
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "keyring")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv(EnvKEKFile, path)
+			}
+
+			k, err := LoadKeyringFromEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadKeyringFromEnv = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadKeyringFromEnv: %v", err)
+			}
+			if tt.nilRing {
+				if k != nil {
+					t.Errorf("LoadKeyringFromEnv = %v, want no keyring", k.KeyIDs())
+				}
+				return
+			}
+			if k.CurrentKeyID() != tt.current || !reflect.DeepEqual(k.KeyIDs(), tt.ids) {
+				t.Errorf("keyring current %q with %v, want %q with %v", k.CurrentKeyID(), k.KeyIDs(), tt.current, tt.ids)
+			}
+		})
+	}
+}
+
+func TestLoadKeyringFromEnvMissingFile(t *testing.T) {
+	t.Setenv(EnvKEK, "")
+	t.Setenv(EnvKEKFile, filepath.Join(t.TempDir(), "missing"))
+	if _, err := LoadKeyringFromEnv(); err == nil || !strings.Contains(err.Error(), "failed to read key file") {
+		t.Errorf("LoadKeyringFromEnv = %v, want a read error", err)
+	}
+}
+
+func TestNewKeyringRejects(t *testing.T) {
+	good := bytes.Repeat([]byte{1}, KeySize)
+	tests := []struct {
+		name    string
+		current string
+		keys    map[string][]byte
+		wantErr string
+	}{
+		{"no keys", "k1", nil, "keyring has no keys"},
+		{"empty ID", "", map[string][]byte{"": good}, `invalid key ID ""`},
+		{"ID with a comma", "a,b", map[string][]byte{"a,b": good}, `invalid key ID "a,b"`},
+		{"short key", "k1", map[string][]byte{"k1": good[:16]}, "must be 32 bytes, got 16"},
+		{"current key missing", "k2", map[string][]byte{"k1": good}, `current key "k2" is not in the keyring`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyring(tt.current, tt.keys); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewKeyring = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Backend resolves secret references of one URI scheme
+type Backend interface {
+	Resolve(ctx context.Context, ref *url.URL) (string, error)
+}
+
+// Resolver looks up credentials that a connection stores by reference
+// (env://NAME, file:///path, vault://mount/path#field) instead of by value
+type Resolver struct {
+	backends map[string]Backend
+}
+
+// NewResolver creates a resolver with no backends
+func NewResolver() *Resolver {
+	return &Resolver{backends: make(map[string]Backend)}
+}
+
+// Register makes backend handle references with the given scheme
+func (r *Resolver) Register(scheme string, backend Backend) {
+	r.backends[strings.ToLower(scheme)] = backend
+}
+
+// Validate checks that ref parses and its scheme has a backend, without resolving it
+func (r *Resolver) Validate(ref string) error {
+	_, _, err := r.lookup(ref)
+	return err
+}
+
+// Resolve returns the secret that ref points to
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	u, backend, err := r.lookup(ref)
+	if err != nil {
+		return "", err
+	}
+	value, err := backend.Resolve(ctx, u)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s secret: %w", u.Scheme, err)
+	}
+	if value == "" {
+		return "", fmt.Errorf("%s secret %q is empty", u.Scheme, redactRef(u))
+	}
+	return value, nil
+}
+
+func (r *Resolver) lookup(ref string) (*url.URL, Backend, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme == "" {
+		return nil, nil, fmt.Errorf("invalid secret reference %q", ref)
+	}
+	if r == nil {
+		return nil, nil, fmt.Errorf("secret references are not enabled")
+	}
+	backend, ok := r.backends[strings.ToLower(u.Scheme)]
+	if !ok {
+		return nil, nil, fmt.Errorf("secret backend %q is not enabled", u.Scheme)
+	}
+	return u, backend, nil
+}
+
+// redactRef drops any userinfo so references can appear in error messages
+func redactRef(u *url.URL) string {
+	c := *u
+	c.User = nil
+	return c.String()
+}
+
+// Environment variables read by NewResolverFromEnv
+const (
+	EnvSecretEnvPrefixes = "SECRET_ENV_PREFIXES" // comma-separated variable prefixes env:// may read
+	EnvSecretFileRoot    = "SECRET_FILE_ROOT"    // directory file:// may read from
+	EnvVaultAddr         = "VAULT_ADDR"
+	EnvVaultToken        = "VAULT_TOKEN"
+	EnvVaultNamespace    = "VAULT_NAMESPACE"
+)
+
+// DefaultSecretFileRoot is where file:// references are read from when SECRET_FILE_ROOT is unset
+const DefaultSecretFileRoot = "/run/secrets"
+
+// NewResolverFromEnv builds a resolver from the process environment.
+//
+// Anyone who can create a connection chooses its references, so each backend
+// is confined: env:// only reads variables whose names start with one of
+// SECRET_ENV_PREFIXES (and is disabled when that is unset), file:// only reads
+// below SECRET_FILE_ROOT, and vault:// is enabled only when VAULT_ADDR is set.
+func NewResolverFromEnv() *Resolver {
+	r := NewResolver()
+
+	var prefixes []string
+	for _, prefix := range strings.Split(os.Getenv(EnvSecretEnvPrefixes), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) > 0 {
+		r.Register("env", EnvBackend{Prefixes: prefixes})
+	}
+
+	root := os.Getenv(EnvSecretFileRoot)
+	if root == "" {
+		root = DefaultSecretFileRoot
+	}
+	r.Register("file", FileBackend{Root: root})
+
+	if addr := os.Getenv(EnvVaultAddr); addr != "" {
+		r.Register("vault", NewVaultBackend(addr, os.Getenv(EnvVaultToken), os.Getenv(EnvVaultNamespace)))
+	}
+
+	return r
+}
+
+// EnvBackend resolves env://NAME from the process environment
+type EnvBackend struct {
+	Prefixes []string // names must start with one of these
+}
+
+// Resolve implements Backend
+func (b EnvBackend) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	name := ref.Host
+	if name == "" {
+		name = ref.Opaque
+	}
+	if name == "" {
+		return "", fmt.Errorf("env reference %q has no variable name", ref)
+	}
+
+	allowed := false
+	for _, prefix := range b.Prefixes {
+		if strings.HasPrefix(name, prefix) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("environment variable %s is not allowed by %s", name, EnvSecretEnvPrefixes)
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// FileBackend resolves file:///path by reading a file below Root, such as a
+// mounted Kubernetes or Docker secret. A single trailing newline is dropped.
+type FileBackend struct {
+	Root string
+}
+
+// Resolve implements Backend
+func (b FileBackend) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	if ref.Host != "" && ref.Host != "localhost" {
+		return "", fmt.Errorf("file reference %q must be a local path", ref)
+	}
+
+	root, err := filepath.Abs(b.Root)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Clean(ref.Path)
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("file reference %q must be an absolute path", ref)
+	}
+	// Resolve symlinks so a link cannot point outside the root
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if resolvedRoot, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolvedRoot
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside %s", ref.Path, b.Root)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSuffix(string(contents), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// VaultBackend resolves references against a Vault-compatible KV HTTP API.
+//
+// A reference has the form vault://mount/path/to/secret#field. The KV v2
+// engine is assumed; add ?kv=1 for a v1 mount. The field defaults to "value".
+type VaultBackend struct {
+	address    string
+	token      string
+	namespace  string
+	httpClient *http.Client
+}
+
+// NewVaultBackend creates a backend for the Vault server at address
+func NewVaultBackend(address, token, namespace string) *VaultBackend {
+	return &VaultBackend{
+		address:   strings.TrimSuffix(address, "/"),
+		token:     token,
+		namespace: namespace,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Resolve implements Backend
+func (b *VaultBackend) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	mount := ref.Host
+	secretPath := strings.Trim(ref.Path, "/")
+	if mount == "" || secretPath == "" {
+		return "", fmt.Errorf("vault reference %q must be vault://mount/path#field", redactRef(ref))
+	}
+	field := ref.Fragment
+	if field == "" {
+		field = "value"
+	}
+
+	kvV1 := ref.Query().Get("kv") == "1"
+	apiPath := "/v1/" + mount + "/data/" + secretPath
+	if kvV1 {
+		apiPath = "/v1/" + mount + "/" + secretPath
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.address+apiPath, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	if b.token != "" {
+		req.Header.Set("X-Vault-Token", b.token)
+	}
+	if b.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.namespace)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %d for %s/%s", resp.StatusCode, mount, secretPath)
+	}
+
+	// KV v1 returns the secret under "data"; v2 nests it one level deeper
+	var result struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	data := result.Data
+	if !kvV1 {
+		var v2 struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &v2); err != nil {
+			return "", fmt.Errorf("invalid vault response: %w", err)
+		}
+		data = v2.Data
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	value, ok := fields[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s/%s has no string field %q", mount, secretPath, field)
+	}
+	return value, nil
+}
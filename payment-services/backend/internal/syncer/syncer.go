@@ -0,0 +1,583 @@
+// Package syncer copies data from billing platforms into the local cache tables
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/db"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// ErrSyncInProgress is returned when a sync is already running for a connection
+var ErrSyncInProgress = errors.New("a sync is already running for this connection")
+
+// ErrStopped is returned when a sync is requested after the engine has stopped
+var ErrStopped = errors.New("the sync engine is shutting down")
+
+// syncLockKey returns the pg_advisory_lock key held for the length of a
+// connection's sync; the lock is what keeps replicas from syncing the same
+// connection at once. The key is a 64-bit hash of the ID, so connections
+// whose IDs differ only above the low 32 bits no longer share a lock.
+func syncLockKey(connectionID int64) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "sync:%d", connectionID)
+	return int64(h.Sum64())
+}
+
+// pageSize is the number of records requested from a platform per list call
+const pageSize = 100
+
+// cursorOverlap is subtracted from a stored high-water mark before it is used,
+// so clock skew between us and the platform cannot hide a change
+const cursorOverlap = 5 * time.Minute
+
+// Entity types; only customers and subscriptions are tracked in sync_cursors
+const (
+	entityCustomers     = "customers"
+	entitySubscriptions = "subscriptions"
+	entityInvoices      = "invoices"
+	entityPayments      = "payments"
+	entityProducts      = "products"
+)
+
+// Engine runs at most one sync per connection at a time across all replicas,
+// each in its own goroutine
+type Engine struct {
+	db    *db.DB
+	store syncStore
+
+	// ctx is cancelled by Stop; runs check it between pages
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	stopping bool
+	runs     sync.WaitGroup
+}
+
+// NewEngine creates a sync engine backed by the given database
+func NewEngine(database *db.DB) *Engine {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Engine{
+		db:     database,
+		store:  pgSyncStore{db: database},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start records a new run for the connection and syncs it in the background.
+// Unless full is set, each entity only fetches records changed since its
+// cursor. It returns ErrSyncInProgress if the connection is already being
+// synced, by this or any other replica.
+func (e *Engine) Start(connectionID int64, provider platforms.BillingProvider, full bool) (*models.SyncRun, error) {
+	run, done, err := e.begin(connectionID, full)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer done()
+		e.run(run, provider)
+	}()
+
+	return run, nil
+}
+
+// Stop cancels the runs in progress and waits for them to record their
+// outcome, or for ctx to expire. No new runs start once Stop is called.
+func (e *Engine) Stop(ctx context.Context) error {
+	e.mu.Lock()
+	e.stopping = true
+	e.mu.Unlock()
+	e.cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		e.runs.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// begin takes the connection's sync lock and records a new run. The caller
+// must call done once the run has finished to release the lock.
+func (e *Engine) begin(connectionID int64, full bool) (*models.SyncRun, func(), error) {
+	e.mu.Lock()
+	if e.stopping {
+		e.mu.Unlock()
+		return nil, nil, ErrStopped
+	}
+	e.runs.Add(1)
+	e.mu.Unlock()
+
+	// Advisory locks belong to a session, so the lock is taken and released
+	// on a connection held for the whole run
+	conn, err := e.db.Pool().Acquire(e.ctx)
+	if err != nil {
+		e.runs.Done()
+		return nil, nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	var locked bool
+	key := syncLockKey(connectionID)
+	err = conn.QueryRow(e.ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked)
+	if err != nil || !locked {
+		conn.Release()
+		e.runs.Done()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to acquire sync lock: %w", err)
+		}
+		return nil, nil, ErrSyncInProgress
+	}
+	done := func() {
+		conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		conn.Release()
+		e.runs.Done()
+	}
+
+	mode := models.SyncIncremental
+	if full {
+		mode = models.SyncFull
+	}
+
+	run, err := e.createRun(connectionID, mode)
+	if err != nil {
+		done()
+		return nil, nil, err
+	}
+	return run, done, nil
+}
+
+// IsRunning reports whether any replica is syncing the connection
+func (e *Engine) IsRunning(ctx context.Context, connectionID int64) (bool, error) {
+	// pg_locks shows a single-key lock as its high and low 32 bits, with objsubid 1
+	key := uint64(syncLockKey(connectionID))
+	var running bool
+	err := e.db.Pool().QueryRow(ctx, `
+		SELECT EXISTS (
+		    SELECT 1 FROM pg_locks
+		    WHERE locktype = 'advisory' AND classid = $1::int8::oid AND objid = $2::int8::oid
+		      AND objsubid = 1 AND granted
+		)
+	`, int64(key>>32), int64(key&0xffff_ffff)).Scan(&running)
+	return running, err
+}
+
+// RunScheduler syncs every connection not synced within interval, checking
+// once per interval until ctx is cancelled or the engine stops. Runs are
+// incremental, except on platforms whose incremental syncs cannot see
+// updates (platforms.Definition.IncrementalByCreation), which are synced in
+// full. Scheduled runs go one at a time; connections already being synced
+// elsewhere are skipped. providerFor builds a connection's provider.
+func (e *Engine) RunScheduler(ctx context.Context, interval time.Duration, providerFor func(connectionID int64) (platforms.BillingProvider, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.syncDue(ctx, interval, providerFor)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+// syncDue syncs the connections whose last successful sync is older than interval
+func (e *Engine) syncDue(ctx context.Context, interval time.Duration, providerFor func(connectionID int64) (platforms.BillingProvider, error)) {
+	rows, err := e.db.Pool().Query(ctx, `
+		SELECT id FROM platform_connections
+		WHERE last_sync_at IS NULL OR last_sync_at < $1
+		ORDER BY last_sync_at NULLS FIRST
+	`, time.Now().Add(-interval))
+	if err != nil {
+		log.Printf("syncer: failed to list connections due for sync: %v", err)
+		return
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		log.Printf("syncer: failed to list connections due for sync: %v", err)
+		return
+	}
+
+	for _, connectionID := range ids {
+		if ctx.Err() != nil || e.ctx.Err() != nil {
+			return
+		}
+
+		provider, err := providerFor(connectionID)
+		if err != nil {
+			log.Printf("syncer: skipping scheduled sync of connection %d: %v", connectionID, err)
+			continue
+		}
+		def, _ := platforms.Lookup(provider.Platform())
+		run, done, err := e.begin(connectionID, def.IncrementalByCreation)
+		if errors.Is(err, ErrSyncInProgress) {
+			continue
+		}
+		if err != nil {
+			log.Printf("syncer: failed to start scheduled sync of connection %d: %v", connectionID, err)
+			continue
+		}
+		e.run(run, provider)
+		done()
+	}
+}
+
+// LatestRun returns the most recent run for the connection, or nil if it has never been synced
+func (e *Engine) LatestRun(connectionID int64) (*models.SyncRun, error) {
+	var run models.SyncRun
+	err := e.db.Pool().QueryRow(context.Background(), `
+		SELECT id, connection_id, mode, status, customers_synced, subscriptions_synced,
+		       invoices_synced, payments_synced, products_synced,
+		       COALESCE(error_message, ''), started_at, finished_at
+		FROM sync_runs
+		WHERE connection_id = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`, connectionID).Scan(
+		&run.ID, &run.ConnectionID, &run.Mode, &run.Status, &run.CustomersSynced, &run.SubscriptionsSynced,
+		&run.InvoicesSynced, &run.PaymentsSynced, &run.ProductsSynced, &run.ErrorMessage, &run.StartedAt, &run.FinishedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A run whose replica died holds no lock and will never finish
+	if run.Status == models.SyncRunning {
+		running, err := e.IsRunning(context.Background(), connectionID)
+		if err != nil {
+			return nil, err
+		}
+		if !running {
+			run.Status = models.SyncFailed
+			run.ErrorMessage = "interrupted before it finished"
+		}
+	}
+	return &run, nil
+}
+
+func (e *Engine) createRun(connectionID int64, mode models.SyncMode) (*models.SyncRun, error) {
+	// We hold the connection's sync lock, so no replica is syncing it and any
+	// run still marked running was cut short by a crash
+	_, err := e.db.Pool().Exec(context.Background(), `
+		UPDATE sync_runs
+		SET status = 'failed', error_message = 'interrupted before it finished', finished_at = NOW()
+		WHERE connection_id = $1 AND status = 'running'
+	`, connectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close interrupted sync runs: %w", err)
+	}
+
+	run := &models.SyncRun{ConnectionID: connectionID, Mode: mode, Status: models.SyncRunning}
+	err = e.db.Pool().QueryRow(context.Background(), `
+		INSERT INTO sync_runs (connection_id, mode, status)
+		VALUES ($1, $2, $3)
+		RETURNING id, started_at
+	`, connectionID, run.Mode, run.Status).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record sync run: %w", err)
+	}
+	return run, nil
+}
+
+// run syncs every cached entity and records the outcome. It stops early if
+// the engine is stopped.
+func (e *Engine) run(run *models.SyncRun, provider platforms.BillingProvider) {
+	ctx := e.ctx
+
+	var err error
+	run.CustomersSynced, err = e.syncCustomers(ctx, run, provider)
+	if err == nil {
+		run.SubscriptionsSynced, err = e.syncSubscriptions(ctx, run, provider)
+	}
+	if err == nil {
+		run.InvoicesSynced, err = e.syncInvoices(ctx, run, provider)
+	}
+	if err == nil {
+		run.PaymentsSynced, err = e.syncPayments(ctx, run, provider)
+	}
+	if err == nil {
+		run.ProductsSynced, err = e.syncProducts(ctx, run, provider)
+	}
+
+	e.finishRun(run, err)
+}
+
+func (e *Engine) finishRun(run *models.SyncRun, syncErr error) {
+	// Recorded even when the run was cancelled
+	ctx := context.Background()
+
+	run.Status = models.SyncSucceeded
+	switch {
+	case syncErr != nil && e.ctx.Err() != nil:
+		run.Status = models.SyncFailed
+		run.ErrorMessage = "interrupted by server shutdown"
+	case syncErr != nil:
+		run.Status = models.SyncFailed
+		run.ErrorMessage = syncErr.Error()
+	}
+
+	var finishedAt time.Time
+	err := e.db.Pool().QueryRow(ctx, `
+		UPDATE sync_runs
+		SET status = $2, customers_synced = $3, subscriptions_synced = $4, invoices_synced = $5,
+		    payments_synced = $6, products_synced = $7, error_message = NULLIF($8, ''), finished_at = NOW()
+		WHERE id = $1
+		RETURNING finished_at
+	`, run.ID, run.Status, run.CustomersSynced, run.SubscriptionsSynced, run.InvoicesSynced,
+		run.PaymentsSynced, run.ProductsSynced, run.ErrorMessage).Scan(&finishedAt)
+	if err != nil {
+		// The connection was deleted while syncing; nothing left to record
+		return
+	}
+	run.FinishedAt = &finishedAt
+
+	if syncErr == nil {
+		e.db.Pool().Exec(ctx, `
+			UPDATE platform_connections SET last_sync_at = $2 WHERE id = $1
+		`, run.ConnectionID, finishedAt)
+	}
+}
+
+// syncStore holds the sync cursors and prunes the cache tables
+type syncStore interface {
+	// highWaterMark returns an entity's stored high-water mark, or nil if it has none
+	highWaterMark(ctx context.Context, connectionID int64, entityType string) (*time.Time, error)
+	setHighWaterMark(ctx context.Context, connectionID int64, entityType string, mark time.Time) error
+	// deleteStale removes a connection's rows in table last synced before
+	deleteStale(ctx context.Context, table string, connectionID int64, before time.Time) error
+}
+
+// pgSyncStore keeps the cursors in the sync_cursors table
+type pgSyncStore struct {
+	db *db.DB
+}
+
+func (p pgSyncStore) highWaterMark(ctx context.Context, connectionID int64, entityType string) (*time.Time, error) {
+	var mark time.Time
+	err := p.db.Pool().QueryRow(ctx, `
+		SELECT high_water_mark FROM sync_cursors WHERE connection_id = $1 AND entity_type = $2
+	`, connectionID, entityType).Scan(&mark)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mark, nil
+}
+
+func (p pgSyncStore) setHighWaterMark(ctx context.Context, connectionID int64, entityType string, mark time.Time) error {
+	_, err := p.db.Pool().Exec(ctx, `
+		INSERT INTO sync_cursors (connection_id, entity_type, high_water_mark, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (connection_id, entity_type) DO UPDATE
+		SET high_water_mark = EXCLUDED.high_water_mark, updated_at = NOW()
+	`, connectionID, entityType, mark)
+	return err
+}
+
+func (p pgSyncStore) deleteStale(ctx context.Context, table string, connectionID int64, before time.Time) error {
+	_, err := p.db.Pool().Exec(ctx, fmt.Sprintf(`
+		DELETE FROM %s WHERE connection_id = $1 AND synced_at < $2
+	`, table), connectionID, before)
+	return err
+}
+
+// since returns the time to fetch changes from for an entity, or nil when
+// the entity has to be fetched in full
+func (e *Engine) since(ctx context.Context, run *models.SyncRun, entityType string) (*time.Time, error) {
+	if run.Mode == models.SyncFull {
+		return nil, nil
+	}
+
+	highWaterMark, err := e.store.highWaterMark(ctx, run.ConnectionID, entityType)
+	if err != nil || highWaterMark == nil {
+		return nil, err
+	}
+
+	since := highWaterMark.Add(-cursorOverlap)
+	return &since, nil
+}
+
+// advanceCursor moves an entity's high-water mark to the start of this run,
+// so changes made while the run was in progress are fetched next time
+func (e *Engine) advanceCursor(ctx context.Context, run *models.SyncRun, entityType string) error {
+	return e.store.setHighWaterMark(ctx, run.ConnectionID, entityType, run.StartedAt)
+}
+
+// syncPages calls list until the platform reports no further pages, passing
+// each record to store. Platforms that do not support the entity are skipped.
+// It stops between pages once ctx is cancelled.
+func syncPages[T any](ctx context.Context, list func(platforms.ListParams) ([]T, string, error), since *time.Time, store func(T) error) (int, error) {
+	count := 0
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+
+		page, next, err := list(platforms.ListParams{Cursor: cursor, Limit: pageSize, UpdatedSince: since})
+		if errors.Is(err, platforms.ErrNotSupported) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		for _, item := range page {
+			if err := store(item); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		// Guard against a platform handing back the same cursor forever
+		if next == "" || next == cursor {
+			return count, nil
+		}
+		cursor = next
+	}
+}
+
+// syncEntity fetches one entity type into its cache table. Incremental
+// entities start from their stored cursor; the rest are fetched in full on
+// every run because the platforms cannot filter them by update time.
+func syncEntity[T any](ctx context.Context, e *Engine, run *models.SyncRun, entityType, table string, incremental bool,
+	list func(platforms.ListParams) ([]T, string, error), store func(T) error) (int, error) {
+	var since *time.Time
+	if incremental {
+		var err error
+		if since, err = e.since(ctx, run, entityType); err != nil {
+			return 0, err
+		}
+	}
+
+	count, err := syncPages(ctx, list, since, store)
+	if err != nil {
+		return count, fmt.Errorf("failed to sync %s: %w", entityType, err)
+	}
+
+	// After a full fetch, anything not touched by this run no longer exists on the platform
+	if since == nil {
+		if err := e.store.deleteStale(ctx, table, run.ConnectionID, run.StartedAt); err != nil {
+			return count, err
+		}
+	}
+
+	if incremental {
+		return count, e.advanceCursor(ctx, run, entityType)
+	}
+	return count, nil
+}
+
+func (e *Engine) syncCustomers(ctx context.Context, run *models.SyncRun, provider platforms.BillingProvider) (int, error) {
+	return syncEntity(ctx, e, run, entityCustomers, "cached_customers", true, provider.ListCustomers, func(c models.Customer) error {
+		_, err := e.db.Pool().Exec(ctx, `
+			INSERT INTO cached_customers (connection_id, external_id, reference, first_name, last_name, email, organization, raw_data, synced_at)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, NOW())
+			ON CONFLICT (connection_id, external_id) DO UPDATE
+			SET reference = EXCLUDED.reference, first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name,
+			    email = EXCLUDED.email, organization = EXCLUDED.organization, raw_data = EXCLUDED.raw_data,
+			    synced_at = EXCLUDED.synced_at
+		`, run.ConnectionID, c.ID, c.Reference, c.FirstName, c.LastName, c.Email, c.Organization, c.RawData)
+		return err
+	})
+}
+
+func (e *Engine) syncSubscriptions(ctx context.Context, run *models.SyncRun, provider platforms.BillingProvider) (int, error) {
+	return syncEntity(ctx, e, run, entitySubscriptions, "cached_subscriptions", true, provider.ListSubscriptions, func(s models.Subscription) error {
+		return e.StoreSubscription(ctx, run.ConnectionID, s)
+	})
+}
+
+// StoreSubscription writes a subscription to the cache, replacing any cached copy
+func (e *Engine) StoreSubscription(ctx context.Context, connectionID int64, s models.Subscription) error {
+	_, err := e.db.Pool().Exec(ctx, `
+		INSERT INTO cached_subscriptions (connection_id, external_id, customer_external_id, product_name, state, current_period_start, current_period_end, raw_data, synced_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, NOW())
+		ON CONFLICT (connection_id, external_id) DO UPDATE
+		SET customer_external_id = EXCLUDED.customer_external_id, product_name = EXCLUDED.product_name,
+		    state = EXCLUDED.state, current_period_start = EXCLUDED.current_period_start,
+		    current_period_end = EXCLUDED.current_period_end, raw_data = EXCLUDED.raw_data,
+		    synced_at = EXCLUDED.synced_at
+	`, connectionID, s.ID, s.CustomerID, s.ProductName, s.State, s.CurrentPeriodStart, s.CurrentPeriodEnd, s.RawData)
+	return err
+}
+
+func (e *Engine) syncInvoices(ctx context.Context, run *models.SyncRun, provider platforms.BillingProvider) (int, error) {
+	return syncEntity(ctx, e, run, entityInvoices, "cached_invoices", false, provider.ListInvoices, func(i models.Invoice) error {
+		return e.StoreInvoice(ctx, run.ConnectionID, i)
+	})
+}
+
+// StoreInvoice writes an invoice to the cache, replacing any cached copy
+func (e *Engine) StoreInvoice(ctx context.Context, connectionID int64, i models.Invoice) error {
+	_, err := e.db.Pool().Exec(ctx, `
+		INSERT INTO cached_invoices (connection_id, external_id, number, customer_external_id, status, total, currency, due_date, created_at, raw_data, synced_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, '')::NUMERIC, NULLIF($7, ''), $8, $9, $10, NOW())
+		ON CONFLICT (connection_id, external_id) DO UPDATE
+		SET number = EXCLUDED.number, customer_external_id = EXCLUDED.customer_external_id,
+		    status = EXCLUDED.status, total = EXCLUDED.total, currency = EXCLUDED.currency,
+		    due_date = EXCLUDED.due_date, created_at = EXCLUDED.created_at, raw_data = EXCLUDED.raw_data,
+		    synced_at = EXCLUDED.synced_at
+	`, connectionID, i.ID, i.Number, i.CustomerID, i.Status, i.Total, i.Currency, i.DueDate, i.CreatedAt, i.RawData)
+	return err
+}
+
+// DeleteInvoice removes an invoice from the cache
+func (e *Engine) DeleteInvoice(ctx context.Context, connectionID int64, externalID string) error {
+	_, err := e.db.Pool().Exec(ctx, `
+		DELETE FROM cached_invoices WHERE connection_id = $1 AND external_id = $2
+	`, connectionID, externalID)
+	return err
+}
+
+func (e *Engine) syncPayments(ctx context.Context, run *models.SyncRun, provider platforms.BillingProvider) (int, error) {
+	return syncEntity(ctx, e, run, entityPayments, "cached_payments", false, provider.ListPayments, func(p models.Payment) error {
+		_, err := e.db.Pool().Exec(ctx, `
+			INSERT INTO cached_payments (connection_id, external_id, amount, currency, status, created_at, raw_data, synced_at)
+			VALUES ($1, $2, NULLIF($3, '')::NUMERIC, NULLIF($4, ''), NULLIF($5, ''), $6, $7, NOW())
+			ON CONFLICT (connection_id, external_id) DO UPDATE
+			SET amount = EXCLUDED.amount, currency = EXCLUDED.currency, status = EXCLUDED.status,
+			    created_at = EXCLUDED.created_at, raw_data = EXCLUDED.raw_data, synced_at = EXCLUDED.synced_at
+		`, run.ConnectionID, p.ID, p.Amount, p.Currency, p.Status, p.CreatedAt, p.RawData)
+		return err
+	})
+}
+
+func (e *Engine) syncProducts(ctx context.Context, run *models.SyncRun, provider platforms.BillingProvider) (int, error) {
+	return syncEntity(ctx, e, run, entityProducts, "cached_products", false, provider.ListProducts, func(p models.Product) error {
+		_, err := e.db.Pool().Exec(ctx, `
+			INSERT INTO cached_products (connection_id, external_id, name, handle, description, price, billing_interval, created_at, raw_data, synced_at)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, '')::NUMERIC, NULLIF($7, ''), $8, $9, NOW())
+			ON CONFLICT (connection_id, external_id) DO UPDATE
+			SET name = EXCLUDED.name, handle = EXCLUDED.handle, description = EXCLUDED.description,
+			    price = EXCLUDED.price, billing_interval = EXCLUDED.billing_interval,
+			    created_at = EXCLUDED.created_at, raw_data = EXCLUDED.raw_data, synced_at = EXCLUDED.synced_at
+		`, run.ConnectionID, p.ID, p.Name, p.Handle, p.Description, p.Price, p.Interval, p.CreatedAt, p.RawData)
+		return err
+	})
+}
@@ -0,0 +1,242 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/models"
+	"github.com/davealexenglish/payment-billing-hub/backend/internal/platforms"
+)
+
+// memSyncStore keeps cursors in memory and records the prunes it is asked for
+type memSyncStore struct {
+	marks   map[string]time.Time
+	deletes []string
+	err     error // returned by highWaterMark when set
+}
+
+func (m *memSyncStore) highWaterMark(_ context.Context, connectionID int64, entityType string) (*time.Time, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	mark, ok := m.marks[fmt.Sprintf("%d/%s", connectionID, entityType)]
+	if !ok {
+		return nil, nil
+	}
+	return &mark, nil
+}
+
+func (m *memSyncStore) setHighWaterMark(_ context.Context, connectionID int64, entityType string, mark time.Time) error {
+	m.marks[fmt.Sprintf("%d/%s", connectionID, entityType)] = mark
+	return nil
+}
+
+func (m *memSyncStore) deleteStale(_ context.Context, table string, connectionID int64, before time.Time) error {
+	m.deletes = append(m.deletes, fmt.Sprintf("%s/%d<%s", table, connectionID, before.Format(time.RFC3339)))
+	return nil
+}
+
+// fakeProvider serves customers in pages keyed by cursor and records every
+// list call; the embedded interface is nil, so other methods panic
+type fakeProvider struct {
+	platforms.BillingProvider
+	pages map[string][]models.Customer // cursor -> page
+	next  map[string]string            // cursor -> next cursor
+	err   error
+	calls []platforms.ListParams
+}
+
+func (f *fakeProvider) ListCustomers(params platforms.ListParams) ([]models.Customer, string, error) {
+	f.calls = append(f.calls, params)
+	if f.err != nil {
+		return nil, "", f.err
+	}
+	return f.pages[params.Cursor], f.next[params.Cursor], nil
+}
+
+func twoPages() *fakeProvider {
+	return &fakeProvider{
+		pages: map[string][]models.Customer{"": {{ID: "c1"}, {ID: "c2"}}, "c2": {{ID: "c3"}}},
+		next:  map[string]string{"": "c2"},
+	}
+}
+
+func TestSyncEntityCursors(t *testing.T) {
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stored := time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)
+	since := stored.Add(-cursorOverlap)
+
+	tests := []struct {
+		name        string
+		mode        models.SyncMode
+		incremental bool
+		cursor      bool // a high-water mark is stored before the run
+		wantSince   *time.Time
+		wantPrune   bool
+		wantMark    *time.Time
+	}{
+		{name: "first incremental run fetches everything", mode: models.SyncIncremental, incremental: true, wantPrune: true, wantMark: &started},
+		{name: "incremental run starts before the stored mark", mode: models.SyncIncremental, incremental: true, cursor: true, wantSince: &since, wantMark: &started},
+		{name: "full run ignores the stored mark", mode: models.SyncFull, incremental: true, cursor: true, wantPrune: true, wantMark: &started},
+		{name: "entity without a cursor is always fetched in full", mode: models.SyncIncremental, cursor: true, wantPrune: true, wantMark: &stored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memSyncStore{marks: map[string]time.Time{}}
+			if tt.cursor {
+				store.marks["7/customers"] = stored
+			}
+			e := &Engine{store: store}
+			run := &models.SyncRun{ConnectionID: 7, Mode: tt.mode, StartedAt: started}
+			provider := twoPages()
+
+			var got []string
+			count, err := syncEntity(context.Background(), e, run, entityCustomers, "cached_customers", tt.incremental,
+				provider.ListCustomers, func(c models.Customer) error {
+					got = append(got, c.ID)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("syncEntity: %v", err)
+			}
+			if count != 3 || !reflect.DeepEqual(got, []string{"c1", "c2", "c3"}) {
+				t.Errorf("stored %v (count %d), want c1 c2 c3", got, count)
+			}
+
+			for i, call := range provider.calls {
+				if !reflect.DeepEqual(call.UpdatedSince, tt.wantSince) {
+					t.Errorf("call %d asked for changes since %v, want %v", i+1, call.UpdatedSince, tt.wantSince)
+				}
+			}
+
+			wantDeletes := []string(nil)
+			if tt.wantPrune {
+				wantDeletes = []string{"cached_customers/7<2026-03-01T12:00:00Z"}
+			}
+			if !reflect.DeepEqual(store.deletes, wantDeletes) {
+				t.Errorf("pruned %v, want %v", store.deletes, wantDeletes)
+			}
+
+			mark, ok := store.marks["7/customers"]
+			if tt.wantMark == nil {
+				if ok {
+					t.Errorf("high-water mark = %v, want none", mark)
+				}
+			} else if !mark.Equal(*tt.wantMark) {
+				t.Errorf("high-water mark = %v, want %v", mark, *tt.wantMark)
+			}
+		})
+	}
+}
+
+func TestSyncEntityFailureKeepsCursor(t *testing.T) {
+	stored := time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)
+	store := &memSyncStore{marks: map[string]time.Time{"7/customers": stored}}
+	e := &Engine{store: store}
+	run := &models.SyncRun{ConnectionID: 7, Mode: models.SyncFull, StartedAt: stored.Add(time.Hour)}
+	provider := &fakeProvider{err: errors.New("upstream unavailable")}
+
+	_, err := syncEntity(context.Background(), e, run, entityCustomers, "cached_customers", true,
+		provider.ListCustomers, func(models.Customer) error { return nil })
+	if err == nil {
+		t.Fatal("syncEntity succeeded, want the list error")
+	}
+	if mark := store.marks["7/customers"]; !mark.Equal(stored) {
+		t.Errorf("high-water mark moved to %v after a failed run", mark)
+	}
+	if len(store.deletes) != 0 {
+		t.Errorf("a failed full run pruned %v", store.deletes)
+	}
+
+	// Nor does a run whose cursor cannot be read fetch anything
+	store.err = errors.New("connection refused")
+	run.Mode = models.SyncIncremental
+	provider = twoPages()
+	if _, err := syncEntity(context.Background(), e, run, entityCustomers, "cached_customers", true,
+		provider.ListCustomers, func(models.Customer) error { return nil }); err == nil || len(provider.calls) != 0 {
+		t.Errorf("syncEntity = %v after %d list calls, want the store error and none", err, len(provider.calls))
+	}
+}
+
+func TestSyncPages(t *testing.T) {
+	t.Run("follows cursors to the last page", func(t *testing.T) {
+		provider := twoPages()
+		count, err := syncPages(context.Background(), provider.ListCustomers, nil, func(models.Customer) error { return nil })
+		if err != nil || count != 3 {
+			t.Fatalf("syncPages = %d, %v, want 3", count, err)
+		}
+		if len(provider.calls) != 2 || provider.calls[0].Cursor != "" || provider.calls[1].Cursor != "c2" {
+			t.Errorf("list calls %+v, want cursors \"\" then c2", provider.calls)
+		}
+		for _, call := range provider.calls {
+			if call.Limit != pageSize {
+				t.Errorf("list call asked for %d records, want %d", call.Limit, pageSize)
+			}
+		}
+	})
+
+	t.Run("stops when a platform repeats its cursor", func(t *testing.T) {
+		provider := &fakeProvider{
+			pages: map[string][]models.Customer{"": {{ID: "c1"}}, "c1": {{ID: "c2"}}},
+			next:  map[string]string{"": "c1", "c1": "c1"},
+		}
+		count, err := syncPages(context.Background(), provider.ListCustomers, nil, func(models.Customer) error { return nil })
+		if err != nil || count != 2 || len(provider.calls) != 2 {
+			t.Errorf("syncPages = %d, %v after %d calls, want 2 records from 2 calls", count, err, len(provider.calls))
+		}
+	})
+
+	t.Run("skips unsupported entities", func(t *testing.T) {
+		provider := &fakeProvider{err: fmt.Errorf("listing: %w", platforms.ErrNotSupported)}
+		count, err := syncPages(context.Background(), provider.ListCustomers, nil, func(models.Customer) error { return nil })
+		if err != nil || count != 0 {
+			t.Errorf("syncPages = %d, %v, want 0, nil", count, err)
+		}
+	})
+
+	t.Run("stops on a store error", func(t *testing.T) {
+		provider := twoPages()
+		storeErr := errors.New("disk full")
+		count, err := syncPages(context.Background(), provider.ListCustomers, nil, func(c models.Customer) error {
+			if c.ID == "c2" {
+				return storeErr
+			}
+			return nil
+		})
+		if !errors.Is(err, storeErr) || count != 1 || len(provider.calls) != 1 {
+			t.Errorf("syncPages = %d, %v after %d calls, want 1 record then the store error", count, err, len(provider.calls))
+		}
+	})
+
+	t.Run("stops between pages once cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		provider := twoPages()
+		count, err := syncPages(ctx, provider.ListCustomers, nil, func(models.Customer) error {
+			cancel()
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) || count != 2 || len(provider.calls) != 1 {
+			t.Errorf("syncPages = %d, %v after %d calls, want the first page then context.Canceled", count, err, len(provider.calls))
+		}
+	})
+}
+
+func TestSyncLockKey(t *testing.T) {
+	// These shared a lock while the key was the ID truncated to 32 bits
+	ids := []int64{1, 1 + 1<<32, 2, 1 << 40}
+	seen := map[int64]int64{}
+	for _, id := range ids {
+		key := syncLockKey(id)
+		if other, ok := seen[key]; ok {
+			t.Errorf("connections %d and %d share lock key %d", other, id, key)
+		}
+		seen[key] = id
+		if syncLockKey(id) != key {
+			t.Errorf("syncLockKey(%d) is not stable", id)
+		}
+	}
+}
@@ -5,6 +5,8 @@ const api = axios.create({
   headers: {
     'Content-Type': 'application/json',
   },
+  // Send the session cookie set by /api/auth/login
+  withCredentials: true,
 })
 
 // Types
@@ -15,6 +17,7 @@ export interface PlatformConnection {
   subdomain?: string
   base_url?: string  // Used by Zuora for different data centers
   is_sandbox: boolean
+  mode: 'read_only' | 'read_write' | 'confirm_required'
   status: 'pending' | 'connected' | 'error'
   error_message?: string
   last_sync_at?: string
@@ -133,6 +136,30 @@ export interface CreateProductRequest {
   interval_unit: string
 }
 
+export interface User {
+  id: number
+  username: string
+  display_name?: string
+  is_active: boolean
+  last_login_at?: string
+  created_at: string
+}
+
+// Auth APIs
+export const login = async (username: string, password: string): Promise<User> => {
+  const response = await api.post('/api/auth/login', { username, password })
+  return response.data.user
+}
+
+export const logout = async (): Promise<void> => {
+  await api.post('/api/auth/logout')
+}
+
+export const getCurrentUser = async (): Promise<User> => {
+  const response = await api.get('/api/auth/me')
+  return response.data
+}
+
 // Connection APIs
 export const listConnections = async (): Promise<PlatformConnection[]> => {
   const response = await api.get('/api/connections')
@@ -523,6 +550,305 @@ export const listStripePayments = async (connectionId: number): Promise<StripePa
   return response.data || []
 }
 
+// Stripe Payment Methods
+// A customer's saved payment methods; the default one is charged for
+// charge_automatically invoices and subscriptions
+export interface StripePaymentMethod {
+  id: string
+  type: string
+  customer?: string
+  card?: {
+    brand: string
+    last4: string
+    exp_month: number
+    exp_year: number
+    funding?: string
+    country?: string
+  }
+  billing_details?: { name?: string; email?: string; phone?: string }
+  created: number
+}
+
+export const listStripePaymentMethods = async (connectionId: number, customerId: string, type?: string): Promise<StripePaymentMethod[]> => {
+  const response = await api.get(`/api/stripe/${connectionId}/customers/${customerId}/payment-methods`, { params: { type } })
+  return response.data || []
+}
+
+// An attached payment method. When setDefault was asked for, default_set is
+// false and default_error says why if the customer's default was not changed;
+// the payment method is attached either way.
+export interface StripeAttachedPaymentMethod extends StripePaymentMethod {
+  default_set: boolean
+  default_error?: string
+}
+
+export const attachStripePaymentMethod = async (connectionId: number, customerId: string, paymentMethodId: string, setDefault = false): Promise<StripeAttachedPaymentMethod> => {
+  const response = await api.post(`/api/stripe/${connectionId}/customers/${customerId}/payment-methods`, {
+    payment_method: paymentMethodId,
+    set_default: setDefault,
+  })
+  return response.data
+}
+
+export const detachStripePaymentMethod = async (connectionId: number, customerId: string, paymentMethodId: string): Promise<StripePaymentMethod> => {
+  const response = await api.delete(`/api/stripe/${connectionId}/customers/${customerId}/payment-methods/${paymentMethodId}`)
+  return response.data
+}
+
+// Pass an empty paymentMethodId to clear the default
+export const setStripeDefaultPaymentMethod = async (connectionId: number, customerId: string, paymentMethodId: string): Promise<void> => {
+  await api.put(`/api/stripe/${connectionId}/customers/${customerId}/default-payment-method`, { payment_method: paymentMethodId })
+}
+
+// Stripe SetupIntents
+// The client_secret is passed to Stripe.js to collect a card for later charges
+export interface StripeSetupIntent {
+  id: string
+  customer?: string
+  status: 'requires_payment_method' | 'requires_confirmation' | 'requires_action' | 'processing' | 'canceled' | 'succeeded'
+  usage: 'off_session' | 'on_session'
+  client_secret?: string
+  payment_method?: string
+  payment_method_types?: string[]
+  description?: string
+  created: number
+}
+
+// Maps to POST /v1/setup_intents - https://docs.stripe.com/api/setup_intents/create
+export interface StripeSetupIntentRequest {
+  payment_method_types?: string[]  // Default: the types enabled in the Stripe dashboard
+  usage?: 'off_session' | 'on_session'
+  description?: string
+  metadata?: Record<string, string>
+}
+
+export const listStripeSetupIntents = async (connectionId: number, customerId: string): Promise<StripeSetupIntent[]> => {
+  const response = await api.get(`/api/stripe/${connectionId}/customers/${customerId}/setup-intents`)
+  return response.data || []
+}
+
+export const createStripeSetupIntent = async (connectionId: number, customerId: string, req: StripeSetupIntentRequest = {}): Promise<StripeSetupIntent> => {
+  const response = await api.post(`/api/stripe/${connectionId}/customers/${customerId}/setup-intents`, req)
+  return response.data
+}
+
+export const getStripeSetupIntent = async (connectionId: number, setupIntentId: string): Promise<StripeSetupIntent> => {
+  const response = await api.get(`/api/stripe/${connectionId}/setup-intents/${setupIntentId}`)
+  return response.data
+}
+
+export const cancelStripeSetupIntent = async (connectionId: number, setupIntentId: string): Promise<StripeSetupIntent> => {
+  const response = await api.post(`/api/stripe/${connectionId}/setup-intents/${setupIntentId}/cancel`)
+  return response.data
+}
+
+// Stripe Refunds
+export interface StripeRefund {
+  id: string
+  amount: number
+  currency: string
+  charge?: string
+  payment_intent?: string
+  reason?: 'duplicate' | 'fraudulent' | 'requested_by_customer'
+  status: string
+  failure_reason?: string
+  created: number
+}
+
+// Maps to POST /v1/refunds - https://docs.stripe.com/api/refunds/create
+export interface StripeRefundRequest {
+  charge?: string          // Charge to refund, or
+  payment_intent?: string  // PaymentIntent to refund
+  amount?: number          // In cents; omit to refund everything not yet refunded
+  reason?: 'duplicate' | 'fraudulent' | 'requested_by_customer'
+  metadata?: Record<string, string>
+}
+
+export const listStripeRefunds = async (connectionId: number, filter?: { charge?: string; payment_intent?: string }): Promise<StripeRefund[]> => {
+  const response = await api.get(`/api/stripe/${connectionId}/refunds`, { params: filter })
+  return response.data || []
+}
+
+// Pass the same idempotency key when retrying a refund so it cannot be made twice
+export const createStripeRefund = async (connectionId: number, req: StripeRefundRequest, idempotencyKey?: string): Promise<StripeRefund> => {
+  const response = await api.post(`/api/stripe/${connectionId}/refunds`, req, {
+    headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined,
+  })
+  return response.data
+}
+
+// Stripe Credit Notes
+export interface StripeCreditNoteLine {
+  id: string
+  type: 'invoice_line_item' | 'custom_line_item'
+  invoice_line_item?: string
+  description?: string
+  quantity?: number
+  unit_amount?: number
+  amount: number
+}
+
+export interface StripeCreditNote {
+  id: string
+  number?: string
+  invoice: string
+  customer: string
+  amount: number
+  subtotal: number
+  total: number
+  currency: string
+  type?: 'pre_payment' | 'post_payment'
+  status?: 'issued' | 'void'  // absent on previews
+  reason?: string
+  memo?: string
+  pdf?: string
+  lines: { data: StripeCreditNoteLine[] }
+  voided_at?: number
+  created: number
+}
+
+// Maps to POST /v1/credit_notes - https://docs.stripe.com/api/credit_notes/create
+export interface StripeCreditNoteRequest {
+  invoice: string
+  amount?: number  // Total in cents, or itemize with lines
+  lines?: {
+    type: 'invoice_line_item' | 'custom_line_item'
+    invoice_line_item?: string
+    amount?: number
+    quantity?: number
+    unit_amount?: number
+    description?: string
+  }[]
+  reason?: 'duplicate' | 'fraudulent' | 'order_change' | 'product_unsatisfactory'
+  memo?: string
+  refund_amount?: number
+  credit_amount?: number
+  out_of_band_amount?: number
+  metadata?: Record<string, string>
+}
+
+export const listStripeCreditNotes = async (connectionId: number, invoiceId?: string): Promise<StripeCreditNote[]> => {
+  const response = await api.get(`/api/stripe/${connectionId}/credit-notes`, { params: { invoice: invoiceId } })
+  return response.data || []
+}
+
+export const previewStripeCreditNote = async (connectionId: number, req: StripeCreditNoteRequest): Promise<StripeCreditNote> => {
+  const response = await api.post(`/api/stripe/${connectionId}/credit-notes/preview`, req)
+  return response.data
+}
+
+export const createStripeCreditNote = async (connectionId: number, req: StripeCreditNoteRequest, idempotencyKey?: string): Promise<StripeCreditNote> => {
+  const response = await api.post(`/api/stripe/${connectionId}/credit-notes`, req, {
+    headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined,
+  })
+  return response.data
+}
+
+export const voidStripeCreditNote = async (connectionId: number, creditNoteId: string): Promise<StripeCreditNote> => {
+  const response = await api.post(`/api/stripe/${connectionId}/credit-notes/${creditNoteId}/void`)
+  return response.data
+}
+
+// Stripe Invoice lifecycle
+// Drafts can take items and be finalized or deleted; open invoices can be
+// paid, sent, voided or marked uncollectible
+export interface StripeInvoice {
+  id: string
+  number?: string
+  customer: string
+  status: 'draft' | 'open' | 'paid' | 'uncollectible' | 'void'
+  collection_method?: 'charge_automatically' | 'send_invoice'
+  auto_advance?: boolean
+  currency: string
+  subtotal: number
+  total: number
+  amount_due: number
+  amount_paid: number
+  amount_remaining: number
+  hosted_invoice_url?: string
+  invoice_pdf?: string
+  due_date?: number
+  created: number
+}
+
+export interface StripeInvoiceItem {
+  id: string
+  customer: string
+  invoice?: string
+  amount: number
+  currency: string
+  description?: string
+  quantity: number
+  date: number
+}
+
+// Maps to POST /v1/invoices - https://docs.stripe.com/api/invoices/create
+export interface StripeInvoiceRequest {
+  customer: string
+  collection_method?: 'charge_automatically' | 'send_invoice'
+  days_until_due?: number  // Required for send_invoice
+  description?: string
+  auto_advance?: boolean
+  currency?: string
+  metadata?: Record<string, string>
+}
+
+// Maps to POST /v1/invoiceitems - https://docs.stripe.com/api/invoiceitems/create
+export interface StripeInvoiceItemRequest {
+  price?: string     // Price to bill, or
+  amount?: number    // an amount in cents with currency
+  currency?: string
+  quantity?: number
+  description?: string
+  metadata?: Record<string, string>
+}
+
+export const createStripeInvoice = async (connectionId: number, req: StripeInvoiceRequest, idempotencyKey?: string): Promise<StripeInvoice> => {
+  const response = await api.post(`/api/stripe/${connectionId}/invoices`, req, {
+    headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined,
+  })
+  return response.data
+}
+
+export const addStripeInvoiceItem = async (connectionId: number, invoiceId: string, req: StripeInvoiceItemRequest, idempotencyKey?: string): Promise<StripeInvoiceItem> => {
+  const response = await api.post(`/api/stripe/${connectionId}/invoices/${invoiceId}/items`, req, {
+    headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined,
+  })
+  return response.data
+}
+
+export const finalizeStripeInvoice = async (connectionId: number, invoiceId: string, autoAdvance?: boolean): Promise<StripeInvoice> => {
+  const response = await api.post(`/api/stripe/${connectionId}/invoices/${invoiceId}/finalize`, { auto_advance: autoAdvance })
+  return response.data
+}
+
+// Pass the same idempotency key when retrying a payment so it cannot be charged twice
+export const payStripeInvoice = async (connectionId: number, invoiceId: string, options?: { payment_method?: string; paid_out_of_band?: boolean }, idempotencyKey?: string): Promise<StripeInvoice> => {
+  const response = await api.post(`/api/stripe/${connectionId}/invoices/${invoiceId}/pay`, options || {}, {
+    headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined,
+  })
+  return response.data
+}
+
+export const sendStripeInvoice = async (connectionId: number, invoiceId: string): Promise<StripeInvoice> => {
+  const response = await api.post(`/api/stripe/${connectionId}/invoices/${invoiceId}/send`)
+  return response.data
+}
+
+export const voidStripeInvoice = async (connectionId: number, invoiceId: string): Promise<StripeInvoice> => {
+  const response = await api.post(`/api/stripe/${connectionId}/invoices/${invoiceId}/void`)
+  return response.data
+}
+
+export const markStripeInvoiceUncollectible = async (connectionId: number, invoiceId: string): Promise<StripeInvoice> => {
+  const response = await api.post(`/api/stripe/${connectionId}/invoices/${invoiceId}/mark-uncollectible`)
+  return response.data
+}
+
+export const deleteStripeInvoice = async (connectionId: number, invoiceId: string): Promise<void> => {
+  await api.delete(`/api/stripe/${connectionId}/invoices/${invoiceId}`)
+}
+
 // Stripe Price operations
 // Note: Stripe prices are immutable - they can only be archived (deactivated), not deleted
 export interface StripePrice {
@@ -655,17 +981,39 @@ export default {
   listStripeCustomers,
   createStripeCustomer,
   updateStripeCustomer,
+  listStripePaymentMethods,
+  attachStripePaymentMethod,
+  detachStripePaymentMethod,
+  setStripeDefaultPaymentMethod,
+  listStripeSetupIntents,
+  createStripeSetupIntent,
+  getStripeSetupIntent,
+  cancelStripeSetupIntent,
   listStripeSubscriptions,
   listStripeProducts,
   createStripeProduct,
   listStripePrices,
   listStripeInvoices,
+  createStripeInvoice,
+  addStripeInvoiceItem,
+  finalizeStripeInvoice,
+  payStripeInvoice,
+  sendStripeInvoice,
+  voidStripeInvoice,
+  markStripeInvoiceUncollectible,
+  deleteStripeInvoice,
   listStripeCoupons,
   getStripeCoupon,
   createStripeCoupon,
   updateStripeCoupon,
   deleteStripeCoupon,
   listStripePayments,
+  listStripeRefunds,
+  createStripeRefund,
+  listStripeCreditNotes,
+  previewStripeCreditNote,
+  createStripeCreditNote,
+  voidStripeCreditNote,
   getStripePrice,
   archiveStripePrice,
   getStripeSubscription,